@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// APIKeyHeader is the header machine clients send their API key in
+const APIKeyHeader = "X-API-Key"
+
+// apiKeyTag starts every API key so leaked keys are easy to recognize and scan for
+const apiKeyTag = "mck"
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKeyAuthenticator resolves an API key to the principal it was issued for
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// GenerateAPIKey returns a new key of the form mck_<prefix>_<secret>, its prefix and
+// its hash. Only the prefix, used to look the key up, and the hash should be stored.
+func GenerateAPIKey() (key, prefix string, hash []byte, err error) {
+	prefixBytes := make([]byte, 6)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", nil, err
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+	key = apiKeyTag + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// ParseAPIKey returns the prefix of a key, or ErrInvalidAPIKey if it is malformed
+func ParseAPIKey(key string) (string, error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyTag || len(parts[1]) != 12 || parts[2] == "" {
+		return "", ErrInvalidAPIKey
+	}
+	return parts[1], nil
+}
+
+// HashAPIKey returns the hash stored for a key. Keys are long random strings, so a
+// fast hash is enough; a slow password hash would only add latency to every request.
+func HashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// APIKeyMatches reports whether key hashes to hash, in constant time
+func APIKeyMatches(key string, hash []byte) bool {
+	return subtle.ConstantTimeCompare(HashAPIKey(key), hash) == 1
+}
@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrMissingToken = errors.New("missing bearer token")
+	ErrInvalidToken = errors.New("invalid token")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// Principal is the authenticated caller described by a verified token
+type Principal struct {
+	Subject  string
+	Issuer   string
+	Audience []string
+	Scopes   []string
+	Roles    []string
+	// Tenant is the tenant the credentials were issued for, if they are bound to one
+	Tenant    string
+	ExpiresAt time.Time
+	// Claims holds every claim of the token, including the ones mapped above
+	Claims map[string]any
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal of the request, if authenticated
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
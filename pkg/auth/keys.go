@@ -0,0 +1,304 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeySet resolves the key that verifies a token signed with alg by the key kid
+type KeySet interface {
+	Key(ctx context.Context, kid, alg string) (any, error)
+}
+
+// jsonWebKey is a key of a JWKS document (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// Symmetric
+	K string `json:"k"`
+}
+
+type verificationKey struct {
+	alg string
+	key any
+}
+
+// StaticKeySet holds a fixed set of keys by key ID
+type StaticKeySet struct {
+	keys map[string]verificationKey
+}
+
+// NewStaticKeySet returns a key set of the given keys, which may be *rsa.PublicKey,
+// *ecdsa.PublicKey or []byte HMAC secrets. A key with an empty ID verifies tokens
+// without a kid header or with a kid not found in the set.
+func NewStaticKeySet(keys map[string]any) *StaticKeySet {
+	set := &StaticKeySet{keys: make(map[string]verificationKey, len(keys))}
+	for kid, key := range keys {
+		set.keys[kid] = verificationKey{key: key}
+	}
+	return set
+}
+
+// NewHMACKeySet returns a key set verifying HS256 tokens with secret
+func NewHMACKeySet(secret []byte) *StaticKeySet {
+	return &StaticKeySet{keys: map[string]verificationKey{"": {alg: AlgorithmHS256, key: secret}}}
+}
+
+func (s *StaticKeySet) Key(ctx context.Context, kid, alg string) (any, error) {
+	return lookupKey(s.keys, kid, alg)
+}
+
+// ParseJWKS parses a JWKS document into a static key set. Keys meant for
+// encryption and keys of unsupported types are skipped.
+func ParseJWKS(data []byte) (*StaticKeySet, error) {
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	return &StaticKeySet{keys: keys}, nil
+}
+
+// NewFileKeySet loads the JWKS document at path
+func NewFileKeySet(path string) (*StaticKeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks file: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+var errRefreshThrottled = errors.New("jwks was fetched too recently")
+
+// RemoteKeySetOptions holds the settings of a RemoteKeySet
+type RemoteKeySetOptions struct {
+	Client *http.Client
+	// RefreshInterval is how long fetched keys are used before fetching them again
+	RefreshInterval time.Duration
+	// MinRefreshInterval limits how often an unknown kid triggers a fetch, so
+	// tokens with made-up key IDs cannot hammer the JWKS endpoint
+	MinRefreshInterval time.Duration
+}
+
+// RemoteKeySet fetches keys from a JWKS URL and caches them. Keys are fetched
+// again after the refresh interval or when a token names an unknown key, which
+// picks up rotated keys. The last good keys are kept if a fetch fails.
+type RemoteKeySet struct {
+	url  string
+	opts RemoteKeySetOptions
+
+	mu          sync.Mutex
+	keys        map[string]verificationKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+func NewRemoteKeySet(url string, opts RemoteKeySetOptions) *RemoteKeySet {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = time.Hour
+	}
+	if opts.MinRefreshInterval <= 0 {
+		opts.MinRefreshInterval = time.Minute
+	}
+
+	return &RemoteKeySet{
+		url:  url,
+		opts: opts,
+	}
+}
+
+func (s *RemoteKeySet) Key(ctx context.Context, kid, alg string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keys == nil || time.Since(s.fetchedAt) > s.opts.RefreshInterval {
+		if err := s.refresh(ctx); err != nil && s.keys == nil {
+			return nil, err
+		}
+	}
+
+	key, err := lookupKey(s.keys, kid, alg)
+	if errors.Is(err, ErrUnknownKey) {
+		if refreshErr := s.refresh(ctx); refreshErr == nil {
+			key, err = lookupKey(s.keys, kid, alg)
+		}
+	}
+
+	return key, err
+}
+
+// refresh fetches the keys, at most once per MinRefreshInterval; the caller holds the lock
+func (s *RemoteKeySet) refresh(ctx context.Context) error {
+	if !s.attemptedAt.IsZero() && time.Since(s.attemptedAt) < s.opts.MinRefreshInterval {
+		return errRefreshThrottled
+	}
+	s.attemptedAt = time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create jwks request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read jwks: %w", err)
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	return nil
+}
+
+func lookupKey(keys map[string]verificationKey, kid, alg string) (any, error) {
+	key, ok := keys[kid]
+	if !ok {
+		key, ok = keys[""]
+	}
+	if !ok && kid == "" && len(keys) == 1 {
+		for _, only := range keys {
+			key, ok = only, true
+		}
+	}
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	if key.alg != "" && key.alg != alg {
+		return nil, fmt.Errorf("%w: key %q is not for %s", ErrUnknownKey, kid, alg)
+	}
+
+	return key.key, nil
+}
+
+func parseJWKS(data []byte) (map[string]verificationKey, error) {
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks: %w", err)
+	}
+
+	keys := make(map[string]verificationKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwk %q: %w", jwk.Kid, err)
+		}
+		if key == nil {
+			continue
+		}
+
+		keys[jwk.Kid] = verificationKey{alg: jwk.Alg, key: key}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks has no signing keys")
+	}
+
+	return keys, nil
+}
+
+// publicKey decodes the key, returning nil for unsupported key types
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, fmt.Errorf("rsa exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		if _, err := key.ECDH(); err != nil {
+			return nil, fmt.Errorf("invalid ec point: %w", err)
+		}
+		return key, nil
+
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, fmt.Errorf("invalid symmetric key: %w", err)
+		}
+		return secret, nil
+	}
+
+	return nil, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func rsaJWK(kid string, key *rsa.PublicKey) jsonWebKey {
+	return jsonWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: AlgorithmRS256,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// jwksServer serves a JWKS document whose keys can be replaced, counting fetches
+type jwksServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	keys    []jsonWebKey
+	fetches int
+}
+
+func newJWKSServer(t *testing.T, keys ...jsonWebKey) *jwksServer {
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		json.NewEncoder(w).Encode(map[string]any{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) rotate(keys ...jsonWebKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *jwksServer) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func TestRemoteKeySetPicksUpRotatedKeys(t *testing.T) {
+	oldKey := newRSAKey(t)
+	newKey := newRSAKey(t)
+	server := newJWKSServer(t, rsaJWK("old", &oldKey.PublicKey))
+
+	keys := NewRemoteKeySet(server.URL, RemoteKeySetOptions{MinRefreshInterval: time.Nanosecond})
+	verifier := NewVerifier(keys, testVerifierConfig())
+	ctx := context.Background()
+
+	if _, err := verifier.Verify(ctx, sign(t, jwt.SigningMethodRS256, "old", oldKey, testClaims())); err != nil {
+		t.Fatalf("Verify with the old key: %v", err)
+	}
+
+	server.rotate(rsaJWK("new", &newKey.PublicKey))
+
+	// A token naming the new key triggers a fetch
+	if _, err := verifier.Verify(ctx, sign(t, jwt.SigningMethodRS256, "new", newKey, testClaims())); err != nil {
+		t.Fatalf("Verify with the new key: %v", err)
+	}
+	if n := server.fetchCount(); n != 2 {
+		t.Errorf("fetched the jwks %d times, want 2", n)
+	}
+
+	// The retired key no longer verifies
+	_, err := verifier.Verify(ctx, sign(t, jwt.SigningMethodRS256, "old", oldKey, testClaims()))
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify with the retired key error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRemoteKeySetThrottlesUnknownKeys(t *testing.T) {
+	key := newRSAKey(t)
+	server := newJWKSServer(t, rsaJWK("current", &key.PublicKey))
+
+	keys := NewRemoteKeySet(server.URL, RemoteKeySetOptions{MinRefreshInterval: time.Hour})
+	ctx := context.Background()
+
+	if _, err := keys.Key(ctx, "current", AlgorithmRS256); err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	for range 5 {
+		if _, err := keys.Key(ctx, "made-up", AlgorithmRS256); !errors.Is(err, ErrUnknownKey) {
+			t.Fatalf("Key error = %v, want ErrUnknownKey", err)
+		}
+	}
+
+	if n := server.fetchCount(); n != 1 {
+		t.Errorf("fetched the jwks %d times, want 1", n)
+	}
+}
+
+func TestRemoteKeySetKeepsKeysWhenFetchFails(t *testing.T) {
+	key := newRSAKey(t)
+	fail := false
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": []jsonWebKey{rsaJWK("current", &key.PublicKey)}})
+	}))
+	defer server.Close()
+
+	keys := NewRemoteKeySet(server.URL, RemoteKeySetOptions{
+		RefreshInterval:    time.Nanosecond,
+		MinRefreshInterval: time.Nanosecond,
+	})
+	ctx := context.Background()
+
+	if _, err := keys.Key(ctx, "current", AlgorithmRS256); err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+
+	if _, err := keys.Key(ctx, "current", AlgorithmRS256); err != nil {
+		t.Fatalf("Key after a failed fetch: %v", err)
+	}
+}
+
+func TestParseJWKSSkipsEncryptionKeys(t *testing.T) {
+	key := newRSAKey(t)
+	enc := rsaJWK("enc", &key.PublicKey)
+	enc.Use = "enc"
+	data, _ := json.Marshal(map[string]any{"keys": []jsonWebKey{enc, rsaJWK("sig", &key.PublicKey)}})
+
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		t.Fatalf("ParseJWKS: %v", err)
+	}
+	if _, err := keys.Key(context.Background(), "enc", AlgorithmRS256); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Key error = %v, want ErrUnknownKey", err)
+	}
+	if _, err := keys.Key(context.Background(), "sig", AlgorithmRS256); err != nil {
+		t.Errorf("Key: %v", err)
+	}
+}
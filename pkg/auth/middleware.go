@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrorHandler writes the response of a request that failed authentication
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Middleware rejects requests without a valid bearer token and puts the principal
+// into the context of the others. onError writes the 401 response; the
+// WWW-Authenticate header is already set when it is called.
+func Middleware(verifier *Verifier, onError ErrorHandler) func(http.Handler) http.Handler {
+	return middleware(verifier, onError, false)
+}
+
+// OptionalMiddleware is like Middleware but lets requests without an Authorization
+// header through anonymously, leaving the decision to the authorization policy.
+// Requests with an invalid token are still rejected.
+func OptionalMiddleware(verifier *Verifier, onError ErrorHandler) func(http.Handler) http.Handler {
+	return middleware(verifier, onError, true)
+}
+
+func middleware(verifier *Verifier, onError ErrorHandler, optional bool) func(http.Handler) http.Handler {
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			if header == "" && optional {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, err := ParseBearer(header)
+			if err == nil {
+				var principal *Principal
+				principal, err = verifier.Verify(r.Context(), token)
+				if err == nil {
+					trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("enduser.id", principal.Subject))
+					next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+					return
+				}
+			}
+
+			w.Header().Set("WWW-Authenticate", Challenge(err))
+			onError(w, r, err)
+		})
+	}
+}
+
+// APIKeyMiddleware authenticates requests carrying an X-API-Key header and puts the
+// principal of the key into the context. Requests without the header pass through
+// unchanged. onError writes the response of requests with a key that fails.
+func APIKeyMiddleware(keys APIKeyAuthenticator, onError ErrorHandler) func(http.Handler) http.Handler {
+	if onError == nil {
+		onError = defaultErrorHandler
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(APIKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := keys.AuthenticateAPIKey(r.Context(), key)
+			if err != nil {
+				onError(w, r, err)
+				return
+			}
+
+			trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("enduser.id", principal.Subject))
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// ParseBearer extracts the token from an Authorization header value
+func ParseBearer(header string) (string, error) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", ErrMissingToken
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// Challenge returns the WWW-Authenticate header value of RFC 6750 for an authentication error
+func Challenge(err error) string {
+	if errors.Is(err, ErrMissingToken) {
+		return `Bearer`
+	}
+	return `Bearer error="invalid_token"`
+}
+
+func defaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": Message(err)})
+}
+
+// Message returns a description of an authentication error that is safe to show to clients
+func Message(err error) string {
+	switch {
+	case errors.Is(err, ErrMissingToken):
+		return "authentication required"
+	case errors.Is(err, ErrInvalidAPIKey):
+		return "invalid, expired or revoked api key"
+	}
+	return "invalid or expired token"
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+var ErrForbidden = errors.New("forbidden")
+
+// Rule grants a permission to principals holding any of the roles or any of the scopes
+type Rule struct {
+	Roles  []string
+	Scopes []string
+}
+
+// Policy decides which principals hold which permissions. Permissions without a
+// rule are denied to everyone.
+type Policy struct {
+	rules    map[string]Rule
+	allowAll bool
+}
+
+func NewPolicy(rules map[string]Rule) *Policy {
+	return &Policy{rules: rules}
+}
+
+// AllowAll returns a policy granting every permission, even to anonymous callers.
+// It is meant for running without authentication.
+func AllowAll() *Policy {
+	return &Policy{allowAll: true}
+}
+
+// Authorize returns nil if the principal of ctx holds permission, ErrMissingToken
+// if the caller is anonymous and an error wrapping ErrForbidden otherwise
+func (p *Policy) Authorize(ctx context.Context, permission string) error {
+	if p.allowAll {
+		return nil
+	}
+
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return ErrMissingToken
+	}
+
+	if rule, ok := p.rules[permission]; ok && rule.grants(principal) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s is not granted %s", ErrForbidden, principal.Subject, permission)
+}
+
+// Allows reports whether the caller of ctx holds permission
+func (p *Policy) Allows(ctx context.Context, permission string) bool {
+	return p.Authorize(ctx, permission) == nil
+}
+
+// Require returns middleware letting only callers holding permission through.
+// onError writes the response of the others.
+func (p *Policy) Require(permission string, onError ErrorHandler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := p.Authorize(r.Context(), permission); err != nil {
+				onError(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (r Rule) grants(p *Principal) bool {
+	for _, role := range p.Roles {
+		if slices.Contains(r.Roles, role) {
+			return true
+		}
+	}
+	for _, scope := range p.Scopes {
+		if slices.Contains(r.Scopes, scope) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmHS256 = "HS256"
+)
+
+// VerifierConfig holds the checks applied to every token
+type VerifierConfig struct {
+	// Issuer must match the iss claim when set
+	Issuer string
+	// Audience must share at least one value with the aud claim when set
+	Audience []string
+	// Algorithms lists the accepted signing algorithms; defaults to RS256 and ES256
+	Algorithms []string
+	// TenantClaim names the claim binding a token to a tenant; tokens are not bound
+	// to one if empty
+	TenantClaim string
+	// Leeway allows for clock skew when checking exp, nbf and iat
+	Leeway time.Duration
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+// Verifier checks the signature and claims of JWT bearer tokens
+type Verifier struct {
+	keys   KeySet
+	cfg    VerifierConfig
+	parser *jwt.Parser
+}
+
+func NewVerifier(keys KeySet, cfg VerifierConfig) *Verifier {
+	if len(cfg.Algorithms) == 0 {
+		cfg.Algorithms = []string{AlgorithmRS256, AlgorithmES256}
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(cfg.Algorithms),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithTimeFunc(cfg.Now),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+
+	return &Verifier{
+		keys:   keys,
+		cfg:    cfg,
+		parser: jwt.NewParser(opts...),
+	}
+}
+
+// Verify returns the principal of a valid token. Every failure wraps ErrInvalidToken.
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := v.parser.ParseWithClaims(raw, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.keys.Key(ctx, kid, token.Method.Alg())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrInvalidToken)
+	}
+
+	audience, _ := claims.GetAudience()
+	if len(v.cfg.Audience) > 0 && !slices.ContainsFunc(audience, func(aud string) bool {
+		return slices.Contains(v.cfg.Audience, aud)
+	}) {
+		return nil, fmt.Errorf("%w: token is not intended for this audience", ErrInvalidToken)
+	}
+
+	issuer, _ := claims.GetIssuer()
+	principal := &Principal{
+		Subject:  subject,
+		Issuer:   issuer,
+		Audience: audience,
+		Scopes:   scopesClaim(claims),
+		Roles:    stringsClaim(claims["roles"]),
+		Claims:   claims,
+	}
+	if v.cfg.TenantClaim != "" {
+		principal.Tenant, _ = claims[v.cfg.TenantClaim].(string)
+	}
+	if exp, _ := claims.GetExpirationTime(); exp != nil {
+		principal.ExpiresAt = exp.Time
+	}
+
+	return principal, nil
+}
+
+// scopesClaim reads the OAuth 2.0 "scope" claim, a space separated string, or the
+// "scp" claim some providers use instead, which may also be an array
+func scopesClaim(claims jwt.MapClaims) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	if scp, ok := claims["scp"].(string); ok {
+		return strings.Fields(scp)
+	}
+	return stringsClaim(claims["scp"])
+}
+
+// stringsClaim reads a claim holding a string or an array of strings
+func stringsClaim(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":       "user-1",
+		"iss":       "https://issuer.test",
+		"aud":       "products",
+		"iat":       testNow.Add(-time.Minute).Unix(),
+		"exp":       testNow.Add(time.Hour).Unix(),
+		"scope":     "products:read products:write",
+		"roles":     []any{"admin"},
+		"tenant_id": "acme",
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return raw
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	return key
+}
+
+func newECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ec key: %v", err)
+	}
+	return key
+}
+
+func testVerifierConfig() VerifierConfig {
+	return VerifierConfig{
+		Issuer:      "https://issuer.test",
+		Audience:    []string{"products"},
+		TenantClaim: "tenant_id",
+		Now:         func() time.Time { return testNow },
+	}
+}
+
+func TestVerifierAcceptsValidTokens(t *testing.T) {
+	rsaKey := newRSAKey(t)
+	ecKey := newECKey(t)
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	tests := []struct {
+		name   string
+		keys   KeySet
+		algs   []string
+		method jwt.SigningMethod
+		key    any
+	}{
+		{
+			name:   "RS256",
+			keys:   NewStaticKeySet(map[string]any{"rsa": &rsaKey.PublicKey}),
+			method: jwt.SigningMethodRS256,
+			key:    rsaKey,
+		},
+		{
+			name:   "ES256",
+			keys:   NewStaticKeySet(map[string]any{"ec": &ecKey.PublicKey}),
+			method: jwt.SigningMethodES256,
+			key:    ecKey,
+		},
+		{
+			name:   "HS256",
+			keys:   NewHMACKeySet(secret),
+			algs:   []string{AlgorithmHS256},
+			method: jwt.SigningMethodHS256,
+			key:    secret,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testVerifierConfig()
+			cfg.Algorithms = tt.algs
+			verifier := NewVerifier(tt.keys, cfg)
+
+			principal, err := verifier.Verify(context.Background(), sign(t, tt.method, "", tt.key, testClaims()))
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+
+			if principal.Subject != "user-1" || principal.Issuer != "https://issuer.test" || principal.Tenant != "acme" {
+				t.Errorf("principal = %+v", principal)
+			}
+			if len(principal.Scopes) != 2 || principal.Scopes[1] != "products:write" {
+				t.Errorf("scopes = %v", principal.Scopes)
+			}
+			if len(principal.Roles) != 1 || principal.Roles[0] != "admin" {
+				t.Errorf("roles = %v", principal.Roles)
+			}
+			if !principal.ExpiresAt.Equal(testNow.Add(time.Hour)) {
+				t.Errorf("expires at %v", principal.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestVerifierRejectsInvalidClaims(t *testing.T) {
+	key := newRSAKey(t)
+	keys := NewStaticKeySet(map[string]any{"": &key.PublicKey})
+
+	tests := []struct {
+		name   string
+		change func(jwt.MapClaims)
+	}{
+		{"expired", func(c jwt.MapClaims) { c["exp"] = testNow.Add(-time.Minute).Unix() }},
+		{"no expiry", func(c jwt.MapClaims) { delete(c, "exp") }},
+		{"not yet valid", func(c jwt.MapClaims) { c["nbf"] = testNow.Add(time.Minute).Unix() }},
+		{"issued in the future", func(c jwt.MapClaims) { c["iat"] = testNow.Add(time.Minute).Unix() }},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://other.test" }},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = []string{"orders", "billing"} }},
+		{"no audience", func(c jwt.MapClaims) { delete(c, "aud") }},
+		{"no subject", func(c jwt.MapClaims) { delete(c, "sub") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := testClaims()
+			tt.change(claims)
+
+			_, err := NewVerifier(keys, testVerifierConfig()).Verify(context.Background(), sign(t, jwt.SigningMethodRS256, "", key, claims))
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("Verify error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifierLeeway(t *testing.T) {
+	key := newRSAKey(t)
+	keys := NewStaticKeySet(map[string]any{"": &key.PublicKey})
+	claims := testClaims()
+	claims["exp"] = testNow.Add(-10 * time.Second).Unix()
+	raw := sign(t, jwt.SigningMethodRS256, "", key, claims)
+
+	cfg := testVerifierConfig()
+	cfg.Leeway = 30 * time.Second
+	if _, err := NewVerifier(keys, cfg).Verify(context.Background(), raw); err != nil {
+		t.Errorf("Verify within leeway: %v", err)
+	}
+}
+
+func TestVerifierRejectsTamperedTokens(t *testing.T) {
+	key := newRSAKey(t)
+	other := newRSAKey(t)
+	keys := NewStaticKeySet(map[string]any{"": &key.PublicKey})
+
+	raw := sign(t, jwt.SigningMethodRS256, "", other, testClaims())
+	if _, err := NewVerifier(keys, testVerifierConfig()).Verify(context.Background(), raw); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifierRejectsAlgorithmConfusion(t *testing.T) {
+	key := newRSAKey(t)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	// The public key, which is no secret, used as an HMAC secret
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	none := sign(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, testClaims())
+	hmac := sign(t, jwt.SigningMethodHS256, "", publicPEM, testClaims())
+
+	tests := []struct {
+		name string
+		algs []string
+		raw  string
+	}{
+		{"none", nil, none},
+		{"HS256 not accepted", nil, hmac},
+		{"HS256 with an RSA key", []string{AlgorithmRS256, AlgorithmHS256}, hmac},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testVerifierConfig()
+			cfg.Algorithms = tt.algs
+			keys := NewStaticKeySet(map[string]any{"": &key.PublicKey})
+
+			if _, err := NewVerifier(keys, cfg).Verify(context.Background(), tt.raw); !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("Verify error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifierRejectsKeyOfOtherAlgorithm(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	ecKey := newECKey(t)
+
+	// The JWKS key is declared for HS256, so an ES256 token naming it must fail even
+	// though ES256 is accepted
+	keys, err := ParseJWKS([]byte(`{"keys":[{"kty":"oct","kid":"hmac","alg":"HS256","k":"MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY"}]}`))
+	if err != nil {
+		t.Fatalf("ParseJWKS: %v", err)
+	}
+
+	cfg := testVerifierConfig()
+	cfg.Algorithms = []string{AlgorithmES256, AlgorithmHS256}
+	verifier := NewVerifier(keys, cfg)
+
+	if _, err := verifier.Verify(context.Background(), sign(t, jwt.SigningMethodHS256, "hmac", secret, testClaims())); err != nil {
+		t.Fatalf("Verify HS256: %v", err)
+	}
+
+	_, err = verifier.Verify(context.Background(), sign(t, jwt.SigningMethodES256, "hmac", ecKey, testClaims()))
+	if !errors.Is(err, ErrInvalidToken) || !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("Verify error = %v, want ErrInvalidToken and ErrUnknownKey", err)
+	}
+}
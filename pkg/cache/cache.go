@@ -0,0 +1,18 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores byte values by key with a time to live
+type Cache interface {
+	// Get returns the value for key and whether it was found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value for key; a ttl of zero uses the backend default
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the keys
+	Delete(ctx context.Context, keys ...string) error
+	// Clear removes every key
+	Clear(ctx context.Context) error
+}
@@ -3,13 +3,12 @@ package config
 import (
 	"errors"
 	"fmt"
-	"log"
-	"os"
-	"path/filepath"
+	"microservice/pkg/locale"
+	"microservice/pkg/ratelimit"
+	"microservice/pkg/tenant"
 	"strconv"
 	"strings"
-
-	"github.com/joho/godotenv"
+	"time"
 )
 
 var (
@@ -21,6 +20,7 @@ var (
 
 	// Server config errors
 	ErrServerPortInvalid    = errors.New("server port must be a valid number")
+	ErrGRPCPortInvalid      = errors.New("grpc port must be a valid number different from the server port")
 	ErrServerTimeoutInvalid = errors.New("server timeout must be positive")
 )
 
@@ -88,6 +88,7 @@ func (c *DBConfig) Validate() error {
 
 type ServerConfig struct {
 	Port             string
+	GRPCPort         string
 	Timeout          int
 	LogLevel         string
 	AllowedOrigins   string
@@ -95,18 +96,31 @@ type ServerConfig struct {
 	AllowedHeaders   string
 	AllowCredentials bool
 	MaxAge           int
+	ExposedHeaders   string
+	// Cache-Control directives of the cacheable routes
+	ProductCacheControl     string
+	ProductListCacheControl string
 }
 
 func (s ServerConfig) GetAddr() string {
 	return ":" + s.Port
 }
 
+func (s ServerConfig) GetGRPCAddr() string {
+	return ":" + s.GRPCPort
+}
+
 func (s ServerConfig) Validate() error {
 	port, err := strconv.Atoi(s.Port)
 	if err != nil || port < 1 || port > 65535 {
 		return ErrServerPortInvalid
 	}
 
+	grpcPort, err := strconv.Atoi(s.GRPCPort)
+	if err != nil || grpcPort < 1 || grpcPort > 65535 || s.GRPCPort == s.Port {
+		return ErrGRPCPortInvalid
+	}
+
 	if s.Timeout <= 0 {
 		return ErrServerTimeoutInvalid
 	}
@@ -132,12 +146,363 @@ type TelemetryConfig struct {
 	LogLevel       string
 }
 
+// OutboxConfig holds the settings of the outbox relay
+type OutboxConfig struct {
+	Enabled      bool
+	PollInterval time.Duration
+	BatchSize    int
+	Retention    time.Duration
+	// MaxAttempts is the number of failed publications after which a message is parked
+	MaxAttempts int
+}
+
+// Supported message bus drivers
+const (
+	MessagingDriverMemory = "memory"
+	MessagingDriverNATS   = "nats"
+)
+
+// MessagingConfig holds the message bus configuration
+type MessagingConfig struct {
+	Driver      string
+	NATSURL     string
+	NATSStream  string
+	TopicPrefix string
+}
+
+// Validate checks if the messaging configuration is valid
+func (c MessagingConfig) Validate() error {
+	switch c.Driver {
+	case MessagingDriverMemory:
+	case MessagingDriverNATS:
+		if c.NATSURL == "" {
+			return fmt.Errorf("nats url is required")
+		}
+		if c.NATSStream == "" {
+			return fmt.Errorf("nats stream is required")
+		}
+	default:
+		return fmt.Errorf("messaging driver must be one of: %s, %s", MessagingDriverMemory, MessagingDriverNATS)
+	}
+
+	if c.TopicPrefix == "" {
+		return fmt.Errorf("topic prefix is required")
+	}
+
+	return nil
+}
+
+// Supported cache backends
+const (
+	CacheBackendMemory = "memory"
+	CacheBackendRedis  = "redis"
+)
+
+// CacheConfig holds the read cache configuration
+type CacheConfig struct {
+	Enabled       bool
+	Backend       string
+	MaxEntries    int
+	TTL           time.Duration
+	NegativeTTL   time.Duration
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+}
+
+// Validate checks if the cache configuration is valid
+func (c CacheConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	switch c.Backend {
+	case CacheBackendMemory:
+		if c.MaxEntries <= 0 {
+			return fmt.Errorf("cache max entries must be positive")
+		}
+	case CacheBackendRedis:
+		if c.RedisAddr == "" {
+			return fmt.Errorf("redis address is required")
+		}
+	default:
+		return fmt.Errorf("cache backend must be one of: %s, %s", CacheBackendMemory, CacheBackendRedis)
+	}
+
+	return nil
+}
+
+// GraphQLConfig holds the GraphQL endpoint configuration
+type GraphQLConfig struct {
+	Enabled       bool
+	MaxDepth      int
+	MaxComplexity int
+	// Introspection and Playground expose the schema; disable them in production
+	// unless the API is public
+	Introspection bool
+	Playground    bool
+}
+
+// Validate checks if the GraphQL configuration is valid
+func (c GraphQLConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.MaxDepth <= 0 {
+		return fmt.Errorf("graphql max depth must be positive")
+	}
+
+	if c.MaxComplexity <= 0 {
+		return fmt.Errorf("graphql max complexity must be positive")
+	}
+
+	return nil
+}
+
+// Supported token signing algorithms
+var authAlgorithms = map[string]bool{"RS256": true, "ES256": true, "HS256": true}
+
+// AuthConfig holds the bearer token authentication configuration. Keys come from
+// exactly one of JWKSURL, JWKSFile or HMACSecret.
+type AuthConfig struct {
+	Enabled    bool
+	Issuer     string
+	Audience   []string
+	Algorithms []string
+	JWKSURL    string
+	JWKSFile   string
+	HMACSecret string
+	// JWKSRefreshInterval is how long keys fetched from JWKSURL are cached
+	JWKSRefreshInterval time.Duration
+	// Leeway allows for clock skew between the issuer and this service
+	Leeway time.Duration
+}
+
+// Validate checks if the auth configuration is valid
+func (c AuthConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	sources := 0
+	for _, source := range []string{c.JWKSURL, c.JWKSFile, c.HMACSecret} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return fmt.Errorf("exactly one of jwks url, jwks file or hmac secret is required")
+	}
+
+	if len(c.Algorithms) == 0 {
+		return fmt.Errorf("at least one algorithm is required")
+	}
+	for _, alg := range c.Algorithms {
+		if !authAlgorithms[alg] {
+			return fmt.Errorf("algorithm must be one of: RS256, ES256, HS256")
+		}
+		// A public key published as JWKS must never be accepted as an HMAC secret
+		if (alg == "HS256") != (c.HMACSecret != "") {
+			return fmt.Errorf("HS256 must be used with, and only with, an hmac secret")
+		}
+	}
+
+	if c.HMACSecret != "" && len(c.HMACSecret) < 32 {
+		return fmt.Errorf("hmac secret must be at least 32 bytes")
+	}
+
+	if c.Leeway < 0 {
+		return fmt.Errorf("leeway must not be negative")
+	}
+
+	return nil
+}
+
+// Supported rate limit backends
+const (
+	RateLimitBackendMemory = "memory"
+	RateLimitBackendRedis  = "redis"
+)
+
+// RateLimitConfig holds the rate limiting configuration. Limits are written as
+// requests/window and route rules as [METHOD ]PATH=LIMIT; see ratelimit.ParseRule.
+type RateLimitConfig struct {
+	Enabled bool
+	Backend string
+	Default string
+	Routes  []string
+	// PerAddress limits the requests of each address before they are authenticated,
+	// throttling attempts to guess credentials
+	PerAddress string
+	// TrustForwarded identifies anonymous clients by the X-Forwarded-For header set
+	// by the proxy in front of the service instead of the connection address
+	TrustForwarded bool
+	RedisAddr      string
+	RedisPassword  string
+	RedisDB        int
+}
+
+// Validate checks if the rate limit configuration is valid
+func (c RateLimitConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	switch c.Backend {
+	case RateLimitBackendMemory:
+	case RateLimitBackendRedis:
+		if c.RedisAddr == "" {
+			return fmt.Errorf("redis address is required")
+		}
+	default:
+		return fmt.Errorf("rate limit backend must be one of: %s, %s", RateLimitBackendMemory, RateLimitBackendRedis)
+	}
+
+	if _, err := ratelimit.ParseLimit(c.Default); err != nil {
+		return err
+	}
+
+	if _, err := ratelimit.ParseLimit(c.PerAddress); err != nil {
+		return err
+	}
+
+	if _, err := ratelimit.ParseRules(c.Routes); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IdempotencyConfig holds the settings of Idempotency-Key handling
+type IdempotencyConfig struct {
+	Enabled bool
+	// TTL is how long keys and their responses are kept
+	TTL             time.Duration
+	CleanupInterval time.Duration
+}
+
+// Validate checks if the idempotency configuration is valid
+func (c IdempotencyConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.TTL <= 0 {
+		return fmt.Errorf("idempotency ttl must be positive")
+	}
+
+	if c.CleanupInterval <= 0 {
+		return fmt.Errorf("idempotency cleanup interval must be positive")
+	}
+
+	return nil
+}
+
+// TenancyConfig holds the multi-tenancy configuration. When disabled every request
+// belongs to the default tenant.
+type TenancyConfig struct {
+	Enabled bool
+	// Header names the header selecting the tenant
+	Header string
+	// BaseDomain resolves the tenant from the subdomain of hosts below it
+	BaseDomain string
+	// Claim names the token claim binding a bearer token to a tenant
+	Claim string
+	// Default is the tenant of requests naming none; they are rejected if empty
+	Default string
+	// AllowUnbound lets tokens without the tenant claim access every tenant; they are
+	// rejected otherwise
+	AllowUnbound bool
+}
+
+// Validate checks if the tenancy configuration is valid
+func (c TenancyConfig) Validate() error {
+	if c.Default != "" && !tenant.Valid(c.Default) {
+		return fmt.Errorf("default tenant must be a lowercase DNS label of at most 63 characters")
+	}
+
+	if !c.Enabled {
+		if c.Default == "" {
+			return fmt.Errorf("default tenant is required when tenancy is disabled")
+		}
+		return nil
+	}
+
+	if c.Header == "" && c.BaseDomain == "" && c.Claim == "" && c.Default == "" {
+		return fmt.Errorf("at least one of tenant header, base domain, claim or default is required")
+	}
+
+	// Without the claim no token is bound to a tenant
+	if c.Claim == "" && !c.AllowUnbound {
+		return fmt.Errorf("tenant claim is required unless unbound credentials are allowed")
+	}
+
+	return nil
+}
+
+// LocaleConfig holds how requests select the locale of translated content
+type LocaleConfig struct {
+	// Default is the locale of the untranslated content
+	Default string
+	// Param names the query parameter selecting the locale, taking precedence over the
+	// Accept-Language header
+	Param string
+}
+
+// Validate checks if the locale configuration is valid
+func (c LocaleConfig) Validate() error {
+	canonical, err := locale.Canonical(c.Default)
+	if err != nil {
+		return fmt.Errorf("default locale must be a BCP 47 language tag")
+	}
+	if canonical != c.Default {
+		return fmt.Errorf("default locale must be in canonical form, as %s", canonical)
+	}
+
+	return nil
+}
+
+// TaxConfig holds the defaults of tax calculations. Prices are stored without tax.
+type TaxConfig struct {
+	// PriceDisplay is whether prices are shown with tax, inclusive, or without, exclusive
+	PriceDisplay string
+	// Rounding rounds tax amounts per line or once per rate on the total
+	Rounding string
+}
+
+// Validate checks if the tax configuration is valid
+func (c TaxConfig) Validate() error {
+	if c.PriceDisplay != "inclusive" && c.PriceDisplay != "exclusive" {
+		return fmt.Errorf("tax price display must be one of: inclusive, exclusive")
+	}
+
+	if c.Rounding != "line" && c.Rounding != "total" {
+		return fmt.Errorf("tax rounding must be one of: line, total")
+	}
+
+	return nil
+}
+
 // Config holds all application configuration
 type Config struct {
-	Env       Environment
-	DB        DBConfig
-	Server    ServerConfig
-	Telemetry TelemetryConfig
+	Env         Environment
+	DB          DBConfig
+	Server      ServerConfig
+	Telemetry   TelemetryConfig
+	Outbox      OutboxConfig
+	Messaging   MessagingConfig
+	Cache       CacheConfig
+	GraphQL     GraphQLConfig
+	Auth        AuthConfig
+	RateLimit   RateLimitConfig
+	Idempotency IdempotencyConfig
+	Tenancy     TenancyConfig
+	Locale      LocaleConfig
+	Tax         TaxConfig
+
+	// Sources tells which source supplied each setting, in the order they are read
+	Sources []Origin
 }
 
 // Validate checks if the configuration is valid
@@ -152,105 +517,172 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("server config: %w", err)
 	}
 
-	return nil
-}
-
-// InProduction returns true if the application is running in production
-func (c *Config) inProduction() bool {
-	return c.Env == "production"
-}
+	// Validate messaging configuration
+	if err := c.Messaging.Validate(); err != nil {
+		return fmt.Errorf("messaging config: %w", err)
+	}
 
-// LoadConfig loads configuration from environment variables and .env files and sets default values if they are not provided
-func LoadConfig(envPath string) (*Config, error) {
-	// Get current working directory
-	cwd, err := os.Getwd()
-	cwd = strings.TrimSuffix(cwd, envPath)
+	// Validate cache configuration
+	if err := c.Cache.Validate(); err != nil {
+		return fmt.Errorf("cache config: %w", err)
+	}
 
-	if err != nil {
-		log.Fatalf("Failed to get current working directory: %v", err)
+	// Validate GraphQL configuration
+	if err := c.GraphQL.Validate(); err != nil {
+		return fmt.Errorf("graphql config: %w", err)
 	}
-	envFile := filepath.Join(
-		cwd,
-		envPath,
-		".env",
-	)
 
-	err = godotenv.Load(envFile)
-	if err != nil {
-		log.Printf("Failed to load .env file %s: %v", envFile, err)
-		err = godotenv.Load()
+	// Validate auth configuration
+	if err := c.Auth.Validate(); err != nil {
+		return fmt.Errorf("auth config: %w", err)
 	}
 
-	// Try loading from the root directory
-	if err != nil {
-		log.Printf("Failed to load .env file %v", err)
+	// Validate rate limit configuration
+	if err := c.RateLimit.Validate(); err != nil {
+		return fmt.Errorf("rate limit config: %w", err)
 	}
 
-	// Continue with configuration regardless of whether .env was loaded
-	cfg := &Config{
-		DB: DBConfig{
-			Host:           GetEnv("DB_HOST", "localhost"),
-			Port:           GetEnv("DB_PORT", "5432"),
-			User:           GetEnv("DB_USER", "postgres"),
-			Password:       GetEnv("DB_PASSWORD", "postgres"),
-			Database:       GetEnv("DB_DATABASE", "products"),
-			SSLMode:        GetEnv("DB_SSLMODE", "disable"),
-			MigrationsPath: GetEnv("MIGRATIONS_PATH", "migrations"),
-		},
-		Server: ServerConfig{
-			Port:             GetEnv("SERVER_PORT", "8080"),
-			Timeout:          getEnvAsInt("SERVER_TIMEOUT", 30),
-			LogLevel:         GetEnv("LOG_LEVEL", "info"),
-			AllowedOrigins:   GetEnv("ALLOWED_ORIGINS", "*"),
-			AllowedMethods:   GetEnv("ALLOWED_METHODS", "GET, POST, PUT, DELETE, OPTIONS"),
-			AllowedHeaders:   GetEnv("ALLOWED_HEADERS", "Content-Type, Authorization, X-Requested-With, X-Request-ID"),
-			AllowCredentials: getEnvAsBool("ALLOW_CREDENTIALS", false),
-			MaxAge:           getEnvAsInt("MAX_AGE", 86400),
-		},
-		Env: Environment(GetEnv("ENV", "development")),
-		Telemetry: TelemetryConfig{
-			Enabled:        getEnvAsBool("TELEMETRY_ENABLED", true),
-			ServiceName:    GetEnv("SERVICE_NAME", "product-service"),
-			ServiceVersion: GetEnv("SERVICE_VERSION", "0.0.1"),
-			OTLPEndpoint:   GetEnv("TELEMETRY_OTLP_ENDPOINT", "jaeger:4317"),
-			JaegerEndpoint: GetEnv("TELEMETRY_JAEGER_ENDPOINT", "http://jaeger:14268/api/traces"),
-			MetricsEnabled: getEnvAsBool("TELEMETRY_METRICS_ENABLED", true),
-			MetricsPort:    getEnvAsInt("TELEMETRY_METRICS_PORT", 9090),
-			PrometheusPath: GetEnv("TELEMETRY_PROMETHEUS_PATH", "/metrics"),
-			LogLevel:       GetEnv("LOG_LEVEL", "info"),
-		},
+	// Validate idempotency configuration
+	if err := c.Idempotency.Validate(); err != nil {
+		return fmt.Errorf("idempotency config: %w", err)
 	}
 
-	// Validate the configuration
-	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+	// Validate tenancy configuration
+	if err := c.Tenancy.Validate(); err != nil {
+		return fmt.Errorf("tenancy config: %w", err)
 	}
 
-	return cfg, nil
-}
+	// Validate locale configuration
+	if err := c.Locale.Validate(); err != nil {
+		return fmt.Errorf("locale config: %w", err)
+	}
 
-func GetEnv(key string, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+	// Validate tax configuration
+	if err := c.Tax.Validate(); err != nil {
+		return fmt.Errorf("tax config: %w", err)
 	}
-	return value
+
+	return nil
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value, exists := os.LookupEnv(key); exists {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
-	}
-	return defaultValue
+// InProduction returns true if the application is running in production
+func (c *Config) inProduction() bool {
+	return c.Env == "production"
 }
 
-func getEnvAsBool(key string, defaultValue bool) bool {
-	if value, exists := os.LookupEnv(key); exists {
-		if boolValue, err := strconv.ParseBool(value); err == nil {
-			return boolValue
-		}
+// build reads the configuration through l. Keys name settings in config files and
+// flags, as db.host and --db-host.
+func build(l *loader) *Config {
+	return &Config{
+		DB: DBConfig{
+			Host:           l.string("db.host", "DB_HOST", "localhost"),
+			Port:           l.string("db.port", "DB_PORT", "5432"),
+			User:           l.string("db.user", "DB_USER", "postgres"),
+			Password:       l.secret("db.password", "DB_PASSWORD", "postgres"),
+			Database:       l.string("db.database", "DB_DATABASE", "products"),
+			SSLMode:        l.string("db.sslmode", "DB_SSLMODE", "disable"),
+			MigrationsPath: l.string("db.migrations_path", "MIGRATIONS_PATH", "migrations"),
+		},
+		Server: ServerConfig{
+			Port:             l.string("server.port", "SERVER_PORT", "8080"),
+			GRPCPort:         l.string("server.grpc_port", "GRPC_PORT", "50051"),
+			Timeout:          l.int("server.timeout", "SERVER_TIMEOUT", 30),
+			LogLevel:         l.string("server.log_level", "LOG_LEVEL", "info"),
+			AllowedOrigins:   l.string("server.allowed_origins", "ALLOWED_ORIGINS", "*"),
+			AllowedMethods:   l.string("server.allowed_methods", "ALLOWED_METHODS", "GET, POST, PUT, DELETE, OPTIONS"),
+			AllowedHeaders:   l.string("server.allowed_headers", "ALLOWED_HEADERS", "Content-Type, Authorization, X-Requested-With, X-Request-ID, X-API-Key, X-Tenant-ID, Idempotency-Key, If-None-Match, If-Modified-Since"),
+			AllowCredentials: l.bool("server.allow_credentials", "ALLOW_CREDENTIALS", false),
+			MaxAge:           l.int("server.max_age", "MAX_AGE", 86400),
+			ExposedHeaders:   l.string("server.exposed_headers", "EXPOSED_HEADERS", "ETag, Last-Modified, X-Request-ID, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After, Idempotent-Replayed"),
+
+			ProductCacheControl:     l.string("server.cache_control_product", "CACHE_CONTROL_PRODUCT", "public, max-age=60"),
+			ProductListCacheControl: l.string("server.cache_control_product_list", "CACHE_CONTROL_PRODUCT_LIST", "public, max-age=30"),
+		},
+		Env: Environment(l.string("env", "ENV", "development")),
+		Telemetry: TelemetryConfig{
+			Enabled:        l.bool("telemetry.enabled", "TELEMETRY_ENABLED", true),
+			ServiceName:    l.string("telemetry.service_name", "SERVICE_NAME", "product-service"),
+			ServiceVersion: l.string("telemetry.service_version", "SERVICE_VERSION", "0.0.1"),
+			OTLPEndpoint:   l.string("telemetry.otlp_endpoint", "TELEMETRY_OTLP_ENDPOINT", "jaeger:4317"),
+			JaegerEndpoint: l.string("telemetry.jaeger_endpoint", "TELEMETRY_JAEGER_ENDPOINT", "http://jaeger:14268/api/traces"),
+			MetricsEnabled: l.bool("telemetry.metrics_enabled", "TELEMETRY_METRICS_ENABLED", true),
+			MetricsPort:    l.int("telemetry.metrics_port", "TELEMETRY_METRICS_PORT", 9090),
+			PrometheusPath: l.string("telemetry.prometheus_path", "TELEMETRY_PROMETHEUS_PATH", "/metrics"),
+			LogLevel:       l.string("telemetry.log_level", "LOG_LEVEL", "info"),
+		},
+		Outbox: OutboxConfig{
+			Enabled:      l.bool("outbox.enabled", "OUTBOX_RELAY_ENABLED", true),
+			PollInterval: l.duration("outbox.poll_interval", "OUTBOX_POLL_INTERVAL", time.Second),
+			BatchSize:    l.int("outbox.batch_size", "OUTBOX_BATCH_SIZE", 100),
+			Retention:    l.duration("outbox.retention", "OUTBOX_RETENTION", 24*time.Hour),
+			MaxAttempts:  l.int("outbox.max_attempts", "OUTBOX_MAX_ATTEMPTS", 10),
+		},
+		Messaging: MessagingConfig{
+			Driver:      l.string("messaging.driver", "MESSAGING_DRIVER", MessagingDriverMemory),
+			NATSURL:     l.urls("messaging.nats_url", "NATS_URL", "nats://localhost:4222"),
+			NATSStream:  l.string("messaging.nats_stream", "NATS_STREAM", "CATALOG"),
+			TopicPrefix: l.string("messaging.topic_prefix", "MESSAGING_TOPIC_PREFIX", "catalog"),
+		},
+		Cache: CacheConfig{
+			Enabled:       l.bool("cache.enabled", "CACHE_ENABLED", true),
+			Backend:       l.string("cache.backend", "CACHE_BACKEND", CacheBackendMemory),
+			MaxEntries:    l.int("cache.max_entries", "CACHE_MAX_ENTRIES", 10000),
+			TTL:           l.duration("cache.ttl", "CACHE_TTL", 5*time.Minute),
+			NegativeTTL:   l.duration("cache.negative_ttl", "CACHE_NEGATIVE_TTL", 30*time.Second),
+			RedisAddr:     l.string("cache.redis_addr", "REDIS_ADDR", "localhost:6379"),
+			RedisPassword: l.secret("cache.redis_password", "REDIS_PASSWORD", ""),
+			RedisDB:       l.int("cache.redis_db", "REDIS_DB", 0),
+		},
+		GraphQL: GraphQLConfig{
+			Enabled:       l.bool("graphql.enabled", "GRAPHQL_ENABLED", true),
+			MaxDepth:      l.int("graphql.max_depth", "GRAPHQL_MAX_DEPTH", 8),
+			MaxComplexity: l.int("graphql.max_complexity", "GRAPHQL_MAX_COMPLEXITY", 1000),
+			Introspection: l.bool("graphql.introspection", "GRAPHQL_INTROSPECTION", true),
+			Playground:    l.bool("graphql.playground", "GRAPHQL_PLAYGROUND", false),
+		},
+		Auth: AuthConfig{
+			Enabled:             l.bool("auth.enabled", "AUTH_ENABLED", false),
+			Issuer:              l.string("auth.issuer", "AUTH_ISSUER", ""),
+			Audience:            l.list("auth.audience", "AUTH_AUDIENCE", nil),
+			Algorithms:          l.list("auth.algorithms", "AUTH_ALGORITHMS", []string{"RS256", "ES256"}),
+			JWKSURL:             l.string("auth.jwks_url", "AUTH_JWKS_URL", ""),
+			JWKSFile:            l.string("auth.jwks_file", "AUTH_JWKS_FILE", ""),
+			HMACSecret:          l.secret("auth.hmac_secret", "AUTH_HMAC_SECRET", ""),
+			JWKSRefreshInterval: l.duration("auth.jwks_refresh_interval", "AUTH_JWKS_REFRESH_INTERVAL", time.Hour),
+			Leeway:              l.duration("auth.leeway", "AUTH_LEEWAY", 30*time.Second),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:        l.bool("rate_limit.enabled", "RATE_LIMIT_ENABLED", true),
+			Backend:        l.string("rate_limit.backend", "RATE_LIMIT_BACKEND", RateLimitBackendMemory),
+			Default:        l.string("rate_limit.default", "RATE_LIMIT_DEFAULT", "300/1m"),
+			Routes:         l.list("rate_limit.routes", "RATE_LIMIT_ROUTES", []string{"POST /api=60/1m", "PUT /api=60/1m", "DELETE /api=60/1m", "/graphql=120/1m", "POST /product.v1.ProductService/CreateProduct=60/1m", "POST /product.v1.ProductService/UpdateProduct=60/1m", "POST /product.v1.ProductService/DeleteProduct=60/1m"}),
+			PerAddress:     l.string("rate_limit.per_address", "RATE_LIMIT_PER_ADDRESS", "600/1m"),
+			TrustForwarded: l.bool("rate_limit.trust_forwarded", "RATE_LIMIT_TRUST_FORWARDED", false),
+			RedisAddr:      l.string("rate_limit.redis_addr", "REDIS_ADDR", "localhost:6379"),
+			RedisPassword:  l.secret("rate_limit.redis_password", "REDIS_PASSWORD", ""),
+			RedisDB:        l.int("rate_limit.redis_db", "REDIS_DB", 0),
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled:         l.bool("idempotency.enabled", "IDEMPOTENCY_ENABLED", true),
+			TTL:             l.duration("idempotency.ttl", "IDEMPOTENCY_TTL", 24*time.Hour),
+			CleanupInterval: l.duration("idempotency.cleanup_interval", "IDEMPOTENCY_CLEANUP_INTERVAL", time.Hour),
+		},
+		Tenancy: TenancyConfig{
+			Enabled:    l.bool("tenancy.enabled", "TENANCY_ENABLED", false),
+			Header:     l.string("tenancy.header", "TENANT_HEADER", "X-Tenant-ID"),
+			BaseDomain: l.string("tenancy.base_domain", "TENANT_BASE_DOMAIN", ""),
+			Claim:      l.string("tenancy.claim", "TENANT_CLAIM", "tenant_id"),
+			Default:    l.string("tenancy.default", "TENANT_DEFAULT", "default"),
+
+			AllowUnbound: l.bool("tenancy.allow_unbound", "TENANT_ALLOW_UNBOUND", false),
+		},
+		Locale: LocaleConfig{
+			Default: l.string("locale.default", "DEFAULT_LOCALE", "en"),
+			Param:   l.string("locale.param", "LOCALE_PARAM", "locale"),
+		},
+		Tax: TaxConfig{
+			PriceDisplay: l.string("tax.price_display", "TAX_PRICE_DISPLAY", "exclusive"),
+			Rounding:     l.string("tax.rounding", "TAX_ROUNDING", "line"),
+		},
 	}
-	return defaultValue
 }
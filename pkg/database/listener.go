@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"microservice/pkg/logger"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ChangeOperation is the statement that changed a row
+type ChangeOperation string
+
+const (
+	OperationInsert ChangeOperation = "INSERT"
+	OperationUpdate ChangeOperation = "UPDATE"
+	OperationDelete ChangeOperation = "DELETE"
+)
+
+// ChangeEvent describes a row change published by a notify trigger
+type ChangeEvent struct {
+	Channel   string          `json:"-"`
+	Table     string          `json:"table"`
+	Operation ChangeOperation `json:"operation"`
+	ID        uuid.UUID       `json:"id"`
+	// Tenant is the tenant of the row, empty for untenanted tables
+	Tenant string `json:"tenant_id,omitempty"`
+}
+
+// ChangeHandler handles a change event
+type ChangeHandler func(ctx context.Context, event ChangeEvent)
+
+// ListenerConfig holds the listener settings
+type ListenerConfig struct {
+	DSN      string
+	Channels []string
+	// MinBackoff and MaxBackoff bound the delay between reconnection attempts
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Listener holds a dedicated connection that LISTENs on the configured channels
+// and dispatches the decoded change events to the handlers registered for their
+// table. The connection is re-established with exponential backoff when lost.
+type Listener struct {
+	cfg    ListenerConfig
+	logger logger.Logger
+
+	mu          sync.RWMutex
+	handlers    map[string][]ChangeHandler
+	onReconnect []func(ctx context.Context)
+}
+
+func NewListener(cfg ListenerConfig, logger logger.Logger) *Listener {
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 500 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+
+	return &Listener{
+		cfg:      cfg,
+		logger:   logger,
+		handlers: make(map[string][]ChangeHandler),
+	}
+}
+
+// Handle registers a handler for changes to table
+func (l *Listener) Handle(table string, handler ChangeHandler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.handlers[table] = append(l.handlers[table], handler)
+}
+
+// OnReconnect registers a callback run after the connection is re-established.
+// Notifications sent while disconnected are lost, so callers should discard any
+// state that relies on them, e.g. flush caches.
+func (l *Listener) OnReconnect(fn func(ctx context.Context)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.onReconnect = append(l.onReconnect, fn)
+}
+
+// Run listens until ctx is cancelled
+func (l *Listener) Run(ctx context.Context) {
+	backoff := l.cfg.MinBackoff
+	connected := false
+
+	for {
+		err := l.listen(ctx, func() {
+			if connected {
+				l.reconnected(ctx)
+			}
+			connected = true
+			backoff = l.cfg.MinBackoff
+		})
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		l.logger.Warn("Database listener disconnected, reconnecting in %s: %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > l.cfg.MaxBackoff {
+			backoff = l.cfg.MaxBackoff
+		}
+	}
+}
+
+// listen connects, subscribes to the channels and dispatches notifications until the connection fails
+func (l *Listener) listen(ctx context.Context, onListening func()) error {
+	conn, err := pgx.Connect(ctx, l.cfg.DSN)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	for _, channel := range l.cfg.Channels {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", channel, err)
+		}
+	}
+
+	l.logger.Info("Database listener listening on %v", l.cfg.Channels)
+	onListening()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event ChangeEvent
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			l.logger.Warn("Ignoring malformed notification on %s: %v", notification.Channel, err)
+			continue
+		}
+		event.Channel = notification.Channel
+
+		l.dispatch(ctx, event)
+	}
+}
+
+func (l *Listener) dispatch(ctx context.Context, event ChangeEvent) {
+	l.mu.RLock()
+	handlers := l.handlers[event.Table]
+	l.mu.RUnlock()
+
+	for _, handler := range handlers {
+		l.safely(func() { handler(ctx, event) })
+	}
+}
+
+func (l *Listener) reconnected(ctx context.Context) {
+	l.mu.RLock()
+	callbacks := l.onReconnect
+	l.mu.RUnlock()
+
+	for _, fn := range callbacks {
+		l.safely(func() { fn(ctx) })
+	}
+}
+
+// safely runs fn, logging instead of crashing the listener if it panics
+func (l *Listener) safely(fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			l.logger.Error("Database listener handler panicked: %v", r)
+		}
+	}()
+
+	fn()
+}
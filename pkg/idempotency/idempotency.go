@@ -0,0 +1,69 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Header is the header clients send the idempotency key of a request in
+const Header = "Idempotency-Key"
+
+// ReplayedHeader marks a response replayed from an earlier request
+const ReplayedHeader = "Idempotent-Replayed"
+
+// MaxKeyLength is the longest key accepted
+const MaxKeyLength = 255
+
+var (
+	ErrInvalidKey = errors.New("invalid idempotency key")
+	// ErrInProgress is returned for a request whose key is held by a request still running
+	ErrInProgress = errors.New("a request with this idempotency key is in progress")
+	// ErrMismatch is returned for a request reusing the key of a different request
+	ErrMismatch = errors.New("idempotency key was used for a different request")
+	// ErrLockLost is returned when completing a key whose lock timed out and was
+	// taken over by a retry
+	ErrLockLost = errors.New("idempotency key lock was lost")
+)
+
+// Response is the stored response of a request
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Record is the state of a key claimed by an earlier request
+type Record struct {
+	Fingerprint []byte
+	// Completed is false while the earlier request is running
+	Completed bool
+	Response  Response
+}
+
+// Store keeps the keys of the requests and their responses. Keys are namespaced by
+// scope, the client that sent them, so clients cannot replay each other's responses.
+type Store interface {
+	// Begin claims key for a request with fingerprint until lockTimeout passes and
+	// keeps it for ttl. It returns the token of the lock if the caller claimed the
+	// key, and must then Complete or Release it with the token, or the record of the
+	// request that holds the key.
+	Begin(ctx context.Context, scope, key string, fingerprint []byte, lockTimeout, ttl time.Duration) (lock string, record *Record, err error)
+	// Complete stores the response of the request holding the lock of key. It fails
+	// with ErrLockLost if the lock was taken over.
+	Complete(ctx context.Context, scope, key, lock string, response Response) error
+	// Release frees a key whose request did not complete, so it can be retried. It
+	// does nothing if the lock was taken over.
+	Release(ctx context.Context, scope, key, lock string) error
+}
+
+// Fingerprint identifies a request by its method, URL and body, so a key reused for a
+// different request can be told apart from a retry
+func Fingerprint(r *http.Request, body []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return h.Sum(nil)
+}
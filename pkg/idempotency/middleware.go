@@ -0,0 +1,180 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"maps"
+	"microservice/pkg/auth"
+	"microservice/pkg/tenant"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// storeTimeout bounds the writes made after the handler returns, which must not be
+// cut short by the client giving up on the request
+const storeTimeout = 5 * time.Second
+
+// Options holds the settings of the middleware
+type Options struct {
+	// TTL is how long keys and their responses are kept
+	TTL time.Duration
+	// LockTimeout is how long a request may hold its key before a retry may take it
+	// over; it should exceed the longest a request can run
+	LockTimeout time.Duration
+	// Methods are the methods the middleware applies to; POST if empty
+	Methods []string
+	// Scope returns the client a request belongs to; the principal subject if nil
+	Scope func(r *http.Request) string
+}
+
+// ErrorHandler writes the response of a request that cannot be processed
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Middleware makes requests carrying an Idempotency-Key safe to retry. The response of
+// the first request with a key is stored and replayed to retries with the same key.
+// onError handles ErrInvalidKey, ErrInProgress, ErrMismatch and store failures.
+//
+// Responses that a retry could change, server errors, 401 and 429, are not stored
+// and neither are responses marked Cache-Control: no-store, which may carry secrets.
+func Middleware(store Store, opts Options, onError ErrorHandler) func(http.Handler) http.Handler {
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodPost}
+	}
+	if opts.Scope == nil {
+		opts.Scope = principalScope
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(Header)
+			if key == "" || !slices.Contains(opts.Methods, r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > MaxKeyLength {
+				onError(w, r, ErrInvalidKey)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				onError(w, r, err)
+				return
+			}
+			r.Body.Close()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := opts.Scope(r)
+			fingerprint := Fingerprint(r, body)
+
+			lock, record, err := store.Begin(r.Context(), scope, key, fingerprint, opts.LockTimeout, opts.TTL)
+			if err != nil {
+				onError(w, r, err)
+				return
+			}
+
+			if record != nil {
+				switch {
+				case !bytes.Equal(record.Fingerprint, fingerprint):
+					onError(w, r, ErrMismatch)
+				case !record.Completed:
+					onError(w, r, ErrInProgress)
+				default:
+					replay(w, record.Response)
+				}
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), storeTimeout)
+			defer cancel()
+
+			// Free the key if the handler panics so the request can be retried
+			completed := false
+			defer func() {
+				if !completed {
+					store.Release(ctx, scope, key, lock)
+				}
+			}()
+
+			before := w.Header().Clone()
+			rec := &recorder{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			if !storable(rec.statusCode, w.Header()) {
+				return
+			}
+
+			err = store.Complete(ctx, scope, key, lock, Response{
+				StatusCode: rec.statusCode,
+				Header:     addedHeaders(before, w.Header()),
+				Body:       rec.body.Bytes(),
+			})
+			// Left claimed, a key whose response was not stored would be rejected with
+			// ErrInProgress until its lock times out
+			completed = err == nil
+		})
+	}
+}
+
+// principalScope scopes keys to the tenant and authenticated principal
+func principalScope(r *http.Request) string {
+	tenantID, _ := tenant.FromContext(r.Context())
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		return tenantID + "/" + principal.Subject
+	}
+	return tenantID + "/"
+}
+
+func storable(statusCode int, header http.Header) bool {
+	if statusCode >= http.StatusInternalServerError ||
+		statusCode == http.StatusUnauthorized ||
+		statusCode == http.StatusTooManyRequests {
+		return false
+	}
+	return !strings.Contains(header.Get("Cache-Control"), "no-store")
+}
+
+// addedHeaders returns the headers set by the handler, leaving out those set before it
+// ran, such as the request ID, which belong to each request
+func addedHeaders(before, after http.Header) http.Header {
+	added := make(http.Header)
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			added[name] = values
+		}
+	}
+	return added
+}
+
+func replay(w http.ResponseWriter, response Response) {
+	maps.Copy(w.Header(), response.Header)
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(response.StatusCode)
+	w.Write(response.Body)
+}
+
+// recorder keeps a copy of the response written through it
+type recorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
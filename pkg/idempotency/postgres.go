@@ -0,0 +1,169 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"microservice/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PostgresStore keeps the keys in the idempotency_keys table, so they hold across
+// replicas and restarts
+type PostgresStore struct {
+	db     *pgxpool.Pool
+	logger logger.Logger
+	tracer trace.Tracer
+}
+
+func NewPostgresStore(db *pgxpool.Pool, logger logger.Logger, tracer trace.Tracer) *PostgresStore {
+	return &PostgresStore{
+		db:     db,
+		logger: logger,
+		tracer: tracer,
+	}
+}
+
+// Begin claims the key with an insert, locking it with a random token. An expired key,
+// or one whose request stopped without completing and whose lock timed out, is taken
+// over with a new token.
+func (s *PostgresStore) Begin(ctx context.Context, scope, key string, fingerprint []byte, lockTimeout, ttl time.Duration) (string, *Record, error) {
+	ctx, span := s.tracer.Start(ctx, "IdempotencyStore.Begin")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("idempotency.key", key))
+
+	lock := uuid.NewString()
+
+	// The key may expire between a failed claim and the read; claim it again then
+	for attempt := 0; attempt < 2; attempt++ {
+		tag, err := s.db.Exec(ctx,
+			`INSERT INTO idempotency_keys (scope, idempotency_key, fingerprint, lock_token, locked_until, expires_at)
+			VALUES ($1, $2, $3, $6, NOW() + $4 * INTERVAL '1 millisecond', NOW() + $5 * INTERVAL '1 millisecond')
+			ON CONFLICT (scope, idempotency_key) DO UPDATE
+			SET fingerprint = EXCLUDED.fingerprint, lock_token = EXCLUDED.lock_token, locked_until = EXCLUDED.locked_until,
+				expires_at = EXCLUDED.expires_at, status_code = NULL, headers = NULL, body = NULL, completed_at = NULL
+			WHERE idempotency_keys.expires_at < NOW()
+				OR (idempotency_keys.completed_at IS NULL AND idempotency_keys.locked_until < NOW())`,
+			scope, key, fingerprint, lockTimeout.Milliseconds(), ttl.Milliseconds(), lock,
+		)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return "", nil, err
+		}
+		if tag.RowsAffected() == 1 {
+			return lock, nil, nil
+		}
+
+		record, err := s.get(ctx, scope, key)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return "", nil, err
+		}
+
+		span.SetAttributes(attribute.Bool("idempotency.completed", record.Completed))
+		return "", record, nil
+	}
+
+	return "", nil, ErrInProgress
+}
+
+func (s *PostgresStore) get(ctx context.Context, scope, key string) (*Record, error) {
+	var (
+		record     Record
+		statusCode *int
+		headers    []byte
+	)
+
+	err := s.db.QueryRow(ctx,
+		`SELECT fingerprint, status_code, headers, body FROM idempotency_keys
+		WHERE scope = $1 AND idempotency_key = $2`,
+		scope, key,
+	).Scan(&record.Fingerprint, &statusCode, &headers, &record.Response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != nil {
+		record.Completed = true
+		record.Response.StatusCode = *statusCode
+		if err := json.Unmarshal(headers, &record.Response.Header); err != nil {
+			return nil, err
+		}
+	}
+
+	return &record, nil
+}
+
+// Complete stores the response if the key is still locked with lock and not completed
+func (s *PostgresStore) Complete(ctx context.Context, scope, key, lock string, response Response) error {
+	ctx, span := s.tracer.Start(ctx, "IdempotencyStore.Complete")
+	defer span.End()
+
+	headers, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	tag, err := s.db.Exec(ctx,
+		`UPDATE idempotency_keys
+		SET status_code = $4, headers = $5, body = $6, completed_at = NOW()
+		WHERE scope = $1 AND idempotency_key = $2 AND lock_token = $3 AND completed_at IS NULL`,
+		scope, key, lock, response.StatusCode, headers, response.Body,
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		span.SetStatus(codes.Error, ErrLockLost.Error())
+		return ErrLockLost
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) Release(ctx context.Context, scope, key, lock string) error {
+	_, err := s.db.Exec(ctx,
+		"DELETE FROM idempotency_keys WHERE scope = $1 AND idempotency_key = $2 AND lock_token = $3 AND completed_at IS NULL",
+		scope, key, lock,
+	)
+	return err
+}
+
+// Run deletes expired keys every interval until ctx is cancelled
+func (s *PostgresStore) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			tag, err := s.db.Exec(ctx, "DELETE FROM idempotency_keys WHERE expires_at < NOW()")
+			if err != nil {
+				if ctx.Err() == nil {
+					s.logger.Warn("Failed to delete expired idempotency keys: %v", err)
+				}
+				continue
+			}
+			if n := tag.RowsAffected(); n > 0 {
+				s.logger.Debug("Deleted %d expired idempotency keys", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"io"
+	"microservice/pkg/logger"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// keysSchema is the idempotency_keys table as created by the service migrations
+const keysSchema = `
+CREATE TABLE idempotency_keys (
+    scope VARCHAR(255) NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint BYTEA NOT NULL,
+    status_code INT,
+    headers JSONB,
+    body BYTEA,
+    locked_until TIMESTAMPTZ NOT NULL,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    lock_token UUID,
+    PRIMARY KEY (scope, idempotency_key)
+)`
+
+// testStore connects to the database named by TEST_DATABASE_URL, in a schema of its
+// own dropped after the test. Tests needing it are skipped when the variable is unset.
+func testStore(t *testing.T) *PostgresStore {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	schema := "idempotency_test_" + uuid.New().String()[:8]
+	admin, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer admin.Close(ctx)
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		conn, err := pgx.Connect(context.Background(), url)
+		if err != nil {
+			return
+		}
+		defer conn.Close(context.Background())
+		conn.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	})
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("connect pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	if _, err := pool.Exec(ctx, keysSchema); err != nil {
+		t.Fatalf("create idempotency_keys: %v", err)
+	}
+	return NewPostgresStore(pool, logger.NewLogger(logger.Fatal, io.Discard, false), noop.NewTracerProvider().Tracer(""))
+}
+
+func TestPostgresStoreRejectsCompleteAfterTakeover(t *testing.T) {
+	store := testStore(t)
+	ctx := context.Background()
+	fingerprint := []byte("POST /products")
+
+	slow, record, err := store.Begin(ctx, "tenant/user", "key", fingerprint, time.Millisecond, time.Hour)
+	if err != nil || record != nil {
+		t.Fatalf("Begin = %v, %v, want the key claimed", record, err)
+	}
+
+	// The slow request outlives its lock and a retry takes the key over
+	time.Sleep(10 * time.Millisecond)
+	retry, record, err := store.Begin(ctx, "tenant/user", "key", fingerprint, time.Hour, time.Hour)
+	if err != nil || record != nil {
+		t.Fatalf("Begin of the retry = %v, %v, want the key taken over", record, err)
+	}
+
+	if err := store.Complete(ctx, "tenant/user", "key", slow, Response{StatusCode: http.StatusCreated, Body: []byte("slow")}); !errors.Is(err, ErrLockLost) {
+		t.Errorf("Complete with the lost lock error = %v, want ErrLockLost", err)
+	}
+	if err := store.Release(ctx, "tenant/user", "key", slow); err != nil {
+		t.Fatalf("Release with the lost lock: %v", err)
+	}
+
+	if err := store.Complete(ctx, "tenant/user", "key", retry, Response{StatusCode: http.StatusCreated, Body: []byte("retry")}); err != nil {
+		t.Fatalf("Complete of the retry: %v", err)
+	}
+	// Completing twice is refused too
+	if err := store.Complete(ctx, "tenant/user", "key", retry, Response{StatusCode: http.StatusCreated}); !errors.Is(err, ErrLockLost) {
+		t.Errorf("second Complete error = %v, want ErrLockLost", err)
+	}
+
+	_, record, err = store.Begin(ctx, "tenant/user", "key", fingerprint, time.Hour, time.Hour)
+	if err != nil || record == nil || !record.Completed {
+		t.Fatalf("Begin after completion = %+v, %v, want the completed record", record, err)
+	}
+	if string(record.Response.Body) != "retry" {
+		t.Errorf("stored body = %q, want the retry's", record.Response.Body)
+	}
+}
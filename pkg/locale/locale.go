@@ -0,0 +1,60 @@
+package locale
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+var ErrInvalidLocale = errors.New("invalid locale")
+
+// maxLength bounds the length of a locale, as stored by the services
+const maxLength = 35
+
+type contextKey struct{}
+
+// WithChain returns a copy of ctx carrying the locales content is looked up in, most
+// preferred first
+func WithChain(ctx context.Context, chain []string) context.Context {
+	return context.WithValue(ctx, contextKey{}, chain)
+}
+
+// FromContext returns the locale chain of ctx and whether there is one
+func FromContext(ctx context.Context) ([]string, bool) {
+	chain, ok := ctx.Value(contextKey{}).([]string)
+	return chain, ok && len(chain) > 0
+}
+
+// Canonical returns the canonical form of a BCP 47 language tag, as de-AT for de_at
+func Canonical(tag string) (string, error) {
+	if tag == "" || len(tag) > maxLength {
+		return "", ErrInvalidLocale
+	}
+
+	t, err := language.Parse(strings.ReplaceAll(tag, "_", "-"))
+	if err != nil || t == language.Und {
+		return "", ErrInvalidLocale
+	}
+
+	return t.String(), nil
+}
+
+// Fallbacks returns a canonical tag followed by its less specific forms, dropping one
+// subtag at a time, as de-AT then de, or zh-Hant-TW, zh-Hant then zh
+func Fallbacks(tag string) []string {
+	fallbacks := []string{tag}
+	for {
+		i := strings.LastIndexByte(tag, '-')
+		if i < 0 {
+			return fallbacks
+		}
+		tag = tag[:i]
+		// Singletons such as the x of private use subtags only introduce the next subtag
+		if i := strings.LastIndexByte(tag, '-'); i >= 0 && len(tag)-i == 2 {
+			tag = tag[:i]
+		}
+		fallbacks = append(fallbacks, tag)
+	}
+}
@@ -0,0 +1,109 @@
+package locale
+
+import (
+	"net/http"
+	"slices"
+
+	"golang.org/x/text/language"
+)
+
+// wildcard is the tag of * in Accept-Language headers
+var wildcard = language.Make("mul")
+
+// Config holds the ways a request selects its locale
+type Config struct {
+	// Param names the query parameter selecting the locale, as locale for ?locale=de-AT;
+	// not read if empty
+	Param string
+	// Default is the locale of the untranslated content, ending every chain
+	Default string
+}
+
+// Resolver determines the locales a request accepts content in. The query parameter
+// takes precedence over the Accept-Language header.
+type Resolver struct {
+	cfg Config
+}
+
+func NewResolver(cfg Config) *Resolver {
+	if canonical, err := Canonical(cfg.Default); err == nil {
+		cfg.Default = canonical
+	}
+	return &Resolver{cfg: cfg}
+}
+
+// Default returns the locale of the untranslated content
+func (r *Resolver) Default() string {
+	return r.cfg.Default
+}
+
+// Param returns the name of the query parameter selecting the locale
+func (r *Resolver) Param() string {
+	return r.cfg.Param
+}
+
+// Resolve returns the fallback chain of a request naming requested in the locale
+// parameter and sending acceptLanguage: each locale followed by its less specific
+// forms, as de-AT then de, ending with the default locale. Locales after the default
+// are dropped since the untranslated content is in it.
+//
+// An invalid parameter is an error while an invalid Accept-Language header is ignored.
+func (r *Resolver) Resolve(requested, acceptLanguage string) ([]string, error) {
+	var preferred []string
+
+	if r.cfg.Param != "" && requested != "" {
+		canonical, err := Canonical(requested)
+		if err != nil {
+			return nil, err
+		}
+		preferred = []string{canonical}
+	} else if tags, _, err := language.ParseAcceptLanguage(acceptLanguage); err == nil {
+		// Tags are sorted by quality and those with q=0 are left out. The * wildcard
+		// accepts any locale, which the default chain ending already covers.
+		for _, tag := range tags {
+			if tag != language.Und && tag != wildcard {
+				preferred = append(preferred, tag.String())
+			}
+		}
+	}
+
+	var chain []string
+	for _, tag := range preferred {
+		for _, fallback := range Fallbacks(tag) {
+			if fallback == r.cfg.Default {
+				return append(chain, fallback), nil
+			}
+			if !slices.Contains(chain, fallback) {
+				chain = append(chain, fallback)
+			}
+		}
+	}
+
+	return append(chain, r.cfg.Default), nil
+}
+
+// ErrorHandler writes the response of a request whose locale cannot be resolved
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Middleware adds the locale chain of each request to its context
+func (r *Resolver) Middleware(onError ErrorHandler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var requested string
+			if r.cfg.Param != "" {
+				requested = req.URL.Query().Get(r.cfg.Param)
+			}
+
+			chain, err := r.Resolve(requested, req.Header.Get("Accept-Language"))
+			if err != nil {
+				onError(w, req, err)
+				return
+			}
+
+			// Translated responses depend on the Accept-Language header
+			w.Header().Add("Vary", "Accept-Language")
+
+			next.ServeHTTP(w, req.WithContext(WithChain(req.Context(), chain)))
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package messaging
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// BackoffFunc returns the delay before the next delivery after the given failed attempt (starting at 1)
+type BackoffFunc func(attempt int) time.Duration
+
+// ConstantBackoff always waits the same delay
+func ConstantBackoff(delay time.Duration) BackoffFunc {
+	return func(int) time.Duration {
+		return delay
+	}
+}
+
+// ExponentialBackoff doubles the delay after every attempt up to max, with up to 20% jitter
+func ExponentialBackoff(initial, max time.Duration) BackoffFunc {
+	return func(attempt int) time.Duration {
+		delay := initial
+		for i := 1; i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+		if delay > max {
+			delay = max
+		}
+
+		jitter := time.Duration(rand.Int64N(int64(delay)/5 + 1))
+		return delay - jitter
+	}
+}
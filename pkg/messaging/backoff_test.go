@@ -0,0 +1,41 @@
+package messaging
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConstantBackoff(t *testing.T) {
+	backoff := ConstantBackoff(time.Second)
+	for attempt := 1; attempt <= 3; attempt++ {
+		if d := backoff(attempt); d != time.Second {
+			t.Errorf("attempt %d: delay %v, want 1s", attempt, d)
+		}
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(100*time.Millisecond, time.Second)
+
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{20, time.Second},
+	}
+
+	for _, tt := range tests {
+		// Up to 20% jitter is taken off the delay
+		for range 50 {
+			d := backoff(tt.attempt)
+			if d > tt.base || d < tt.base-tt.base/5 {
+				t.Fatalf("attempt %d: delay %v, want between %v and %v", tt.attempt, d, tt.base-tt.base/5, tt.base)
+			}
+		}
+	}
+}
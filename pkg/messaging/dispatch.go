@@ -0,0 +1,43 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+)
+
+// dispatch runs handler for msg and settles it. Failed messages are nacked with the
+// configured backoff until they exhaust their deliveries or fail permanently, at
+// which point they are published to the dead-letter topic and terminated.
+func dispatch(ctx context.Context, system string, dlq Publisher, opts SubscribeOptions, handler Handler, msg *Message, terminate func() error) error {
+	ctx, span := startConsumeSpan(ctx, system, msg, opts.Group)
+	defer span.End()
+
+	err := handler(ctx, msg)
+	if err == nil {
+		return msg.Ack()
+	}
+
+	recordSpanError(span, err)
+
+	if msg.done {
+		// The handler settled the message itself
+		return nil
+	}
+
+	if !IsPermanent(err) && msg.Attempt < opts.MaxDeliveries {
+		return msg.Nack(opts.Backoff(msg.Attempt))
+	}
+
+	if pubErr := dlq.Publish(ctx, opts.DeadLetterTopic, deadLetterEnvelope(msg.Envelope, msg.Topic, msg.Attempt, err)); pubErr != nil {
+		recordSpanError(span, pubErr)
+		// Keep the message so it is not lost; it is redelivered and dead-lettered again
+		return msg.Nack(opts.Backoff(msg.Attempt))
+	}
+
+	msg.done = true
+	if err := terminate(); err != nil {
+		return fmt.Errorf("failed to terminate dead-lettered message %s: %w", msg.ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// recordingPublisher records published envelopes, failing with err if set
+type recordingPublisher struct {
+	err       error
+	published map[string][]Envelope
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, topic string, env Envelope) error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.published == nil {
+		p.published = make(map[string][]Envelope)
+	}
+	p.published[topic] = append(p.published[topic], env)
+	return nil
+}
+
+func (p *recordingPublisher) Close() error { return nil }
+
+// settlement records how dispatch settled a message
+type settlement struct {
+	acked      bool
+	nacked     bool
+	delay      time.Duration
+	terminated bool
+}
+
+func testMessage(s *settlement, attempt int) *Message {
+	env := NewEnvelope("product.created", []byte(`{}`))
+	env.Headers["X-Tenant-ID"] = "acme"
+	return &Message{
+		Envelope: env,
+		Topic:    "catalog.products",
+		Attempt:  attempt,
+		ack: func() error {
+			s.acked = true
+			return nil
+		},
+		nack: func(delay time.Duration) error {
+			s.nacked, s.delay = true, delay
+			return nil
+		},
+	}
+}
+
+func testOptions(t *testing.T) SubscribeOptions {
+	t.Helper()
+	opts, err := newSubscribeOptions("catalog.products", []SubscribeOption{
+		WithMaxDeliveries(3),
+		WithBackoff(func(attempt int) time.Duration { return time.Duration(attempt) * time.Second }),
+	})
+	if err != nil {
+		t.Fatalf("newSubscribeOptions: %v", err)
+	}
+	return opts
+}
+
+func TestDispatch(t *testing.T) {
+	failure := errors.New("database unavailable")
+
+	tests := []struct {
+		name       string
+		attempt    int
+		err        error
+		dlqErr     error
+		want       settlement
+		deadLetter bool
+	}{
+		{
+			name:    "success is acked",
+			attempt: 1,
+			want:    settlement{acked: true},
+		},
+		{
+			name:    "failure is nacked with backoff",
+			attempt: 2,
+			err:     failure,
+			want:    settlement{nacked: true, delay: 2 * time.Second},
+		},
+		{
+			name:       "last attempt is dead-lettered",
+			attempt:    3,
+			err:        failure,
+			want:       settlement{terminated: true},
+			deadLetter: true,
+		},
+		{
+			name:       "permanent failure is dead-lettered at once",
+			attempt:    1,
+			err:        Permanent(failure),
+			want:       settlement{terminated: true},
+			deadLetter: true,
+		},
+		{
+			name:    "failed dead-lettering keeps the message",
+			attempt: 3,
+			err:     failure,
+			dlqErr:  errors.New("broker unavailable"),
+			want:    settlement{nacked: true, delay: 3 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got settlement
+			dlq := &recordingPublisher{err: tt.dlqErr}
+			msg := testMessage(&got, tt.attempt)
+			handler := func(ctx context.Context, msg *Message) error { return tt.err }
+			terminate := func() error {
+				got.terminated = true
+				return nil
+			}
+
+			if err := dispatch(context.Background(), memorySystem, dlq, testOptions(t), handler, msg, terminate); err != nil {
+				t.Fatalf("dispatch: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("settlement = %+v, want %+v", got, tt.want)
+			}
+
+			dead := dlq.published["catalog.products"+DeadLetterSuffix]
+			if !tt.deadLetter {
+				if len(dead) != 0 {
+					t.Errorf("dead-lettered %d messages, want none", len(dead))
+				}
+				return
+			}
+			if len(dead) != 1 {
+				t.Fatalf("dead-lettered %d messages, want 1", len(dead))
+			}
+
+			headers := dead[0].Headers
+			if headers[HeaderOriginalTopic] != "catalog.products" ||
+				headers[HeaderDeliveryCount] != strconv.Itoa(tt.attempt) ||
+				headers[HeaderDeadLetterReason] != failure.Error() ||
+				headers["X-Tenant-ID"] != "acme" {
+				t.Errorf("dead-letter headers = %v", headers)
+			}
+			if dead[0].ID != msg.ID {
+				t.Errorf("dead-letter ID = %s, want %s", dead[0].ID, msg.ID)
+			}
+		})
+	}
+}
+
+func TestDispatchLeavesMessagesSettledByHandler(t *testing.T) {
+	var got settlement
+	msg := testMessage(&got, 1)
+	handler := func(ctx context.Context, msg *Message) error {
+		_ = msg.Nack(time.Minute)
+		return errors.New("retry later")
+	}
+
+	if err := dispatch(context.Background(), memorySystem, &recordingPublisher{}, testOptions(t), handler, msg, func() error { return nil }); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	if want := (settlement{nacked: true, delay: time.Minute}); got != want {
+		t.Errorf("settlement = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,294 @@
+package messaging
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const memorySystem = "memory"
+
+// memoryQueueSize is the number of undelivered messages buffered per subscription
+const memoryQueueSize = 1024
+
+// MemoryBus is an in-process Bus for tests and single-instance development.
+// Delivery is asynchronous and messages are lost when the process exits.
+// AckWait is not enforced because handlers settle messages synchronously.
+type MemoryBus struct {
+	mu     sync.Mutex
+	topics map[string]map[string]*memoryGroup
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type memoryGroup struct {
+	subs []*memorySubscription
+	next int
+}
+
+type memoryDelivery struct {
+	env     Envelope
+	topic   string
+	attempt int
+}
+
+type memorySubscription struct {
+	bus     *MemoryBus
+	topic   string
+	group   string
+	handler Handler
+	opts    SubscribeOptions
+	queue   chan memoryDelivery
+	ctx     context.Context
+	cancel  context.CancelFunc
+	once    sync.Once
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		topics: make(map[string]map[string]*memoryGroup),
+	}
+}
+
+// Publish delivers env to one subscriber of every consumer group of topic
+func (b *MemoryBus) Publish(ctx context.Context, topic string, env Envelope) error {
+	if topic == "" {
+		return ErrInvalidTopic
+	}
+
+	env = cloneEnvelope(env)
+	if env.ID == "" {
+		env.ID = uuid.New().String()
+	}
+	env.PublishedAt = time.Now().UTC()
+
+	ctx, span := startPublishSpan(ctx, memorySystem, topic, &env)
+	defer span.End()
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+
+	var targets []*memorySubscription
+	for _, g := range b.topics[topic] {
+		if sub := g.pick(); sub != nil {
+			targets = append(targets, sub)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, sub := range targets {
+		if err := sub.enqueue(ctx, memoryDelivery{env: cloneEnvelope(env), topic: topic, attempt: 1}); err != nil {
+			recordSpanError(span, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Subscribe registers handler for topic until ctx is cancelled or the subscription is removed
+func (b *MemoryBus) Subscribe(ctx context.Context, topic string, handler Handler, opts ...SubscribeOption) (Subscription, error) {
+	o, err := newSubscribeOptions(topic, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	group := o.Group
+	if group == "" {
+		group = "_" + uuid.New().String()
+	}
+
+	subCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	sub := &memorySubscription{
+		bus:     b,
+		topic:   topic,
+		group:   group,
+		handler: handler,
+		opts:    o,
+		queue:   make(chan memoryDelivery, memoryQueueSize),
+		ctx:     subCtx,
+		cancel:  cancel,
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		cancel()
+		return nil, ErrClosed
+	}
+
+	groups, ok := b.topics[topic]
+	if !ok {
+		groups = make(map[string]*memoryGroup)
+		b.topics[topic] = groups
+	}
+	g, ok := groups[group]
+	if !ok {
+		g = &memoryGroup{}
+		groups[group] = g
+	}
+	g.subs = append(g.subs, sub)
+	b.mu.Unlock()
+
+	b.wg.Add(1)
+	go sub.run()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = sub.Unsubscribe()
+		case <-subCtx.Done():
+		}
+	}()
+
+	return sub, nil
+}
+
+// Close stops every subscription and waits for in-flight handlers to return
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+
+	var subs []*memorySubscription
+	for _, groups := range b.topics {
+		for _, g := range groups {
+			subs = append(subs, g.subs...)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.cancel()
+	}
+	b.wg.Wait()
+
+	return nil
+}
+
+// redeliver hands a nacked message to the group again after delay
+func (b *MemoryBus) redeliver(topic, group string, d memoryDelivery, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return
+		}
+		var sub *memorySubscription
+		if g, ok := b.topics[topic][group]; ok {
+			sub = g.pick()
+		}
+		b.mu.Unlock()
+
+		if sub != nil {
+			_ = sub.enqueue(context.Background(), d)
+		}
+	})
+}
+
+// pick returns the next subscriber of the group in round-robin order; the caller holds the bus lock
+func (g *memoryGroup) pick() *memorySubscription {
+	if len(g.subs) == 0 {
+		return nil
+	}
+	sub := g.subs[g.next%len(g.subs)]
+	g.next++
+	return sub
+}
+
+func (s *memorySubscription) enqueue(ctx context.Context, d memoryDelivery) error {
+	if s.ctx.Err() != nil {
+		s.bus.redeliver(s.topic, s.group, d, 0)
+		return nil
+	}
+
+	select {
+	case s.queue <- d:
+		return nil
+	case <-s.ctx.Done():
+		// The subscription went away; let the rest of the group take the message
+		s.bus.redeliver(s.topic, s.group, d, 0)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *memorySubscription) run() {
+	defer s.bus.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			s.drain()
+			return
+		case d := <-s.queue:
+			s.handle(d)
+		}
+	}
+}
+
+func (s *memorySubscription) handle(d memoryDelivery) {
+	msg := &Message{
+		Envelope: d.env,
+		Topic:    d.topic,
+		Attempt:  d.attempt,
+		ack:      func() error { return nil },
+		nack: func(delay time.Duration) error {
+			s.bus.redeliver(s.topic, s.group, memoryDelivery{env: d.env, topic: d.topic, attempt: d.attempt + 1}, delay)
+			return nil
+		},
+	}
+
+	_ = dispatch(s.ctx, memorySystem, s.bus, s.opts, s.handler, msg, func() error { return nil })
+}
+
+// drain hands queued messages back to the remaining members of the group
+func (s *memorySubscription) drain() {
+	for {
+		select {
+		case d := <-s.queue:
+			s.bus.redeliver(s.topic, s.group, d, 0)
+		default:
+			return
+		}
+	}
+}
+
+// Unsubscribe removes the subscription from its consumer group
+func (s *memorySubscription) Unsubscribe() error {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		if g, ok := s.bus.topics[s.topic][s.group]; ok {
+			g.subs = slices.DeleteFunc(g.subs, func(other *memorySubscription) bool { return other == s })
+			if len(g.subs) == 0 {
+				delete(s.bus.topics[s.topic], s.group)
+			}
+		}
+		s.bus.mu.Unlock()
+
+		s.cancel()
+	})
+
+	return nil
+}
+
+func cloneEnvelope(env Envelope) Envelope {
+	env.Payload = slices.Clone(env.Payload)
+	env.Headers = maps.Clone(env.Headers)
+	env.TraceContext = maps.Clone(env.TraceContext)
+	if env.Headers == nil {
+		env.Headers = make(map[string]string)
+	}
+	return env
+}
@@ -0,0 +1,210 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testTimeout = 5 * time.Second
+
+func newTestBus(t *testing.T) *MemoryBus {
+	bus := NewMemoryBus()
+	t.Cleanup(func() { bus.Close() })
+	return bus
+}
+
+// receive waits for a delivery on ch
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for a delivery")
+	}
+	panic("unreachable")
+}
+
+// expectNone fails if anything arrives on ch shortly
+func expectNone[T any](t *testing.T, ch <-chan T) {
+	t.Helper()
+	select {
+	case v := <-ch:
+		t.Fatalf("unexpected delivery %v", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMemoryBusRedeliversFailedMessages(t *testing.T) {
+	bus := newTestBus(t)
+	attempts := make(chan int, 10)
+
+	_, err := bus.Subscribe(context.Background(), "catalog.products", func(ctx context.Context, msg *Message) error {
+		attempts <- msg.Attempt
+		if msg.Attempt < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}, WithMaxDeliveries(5), WithBackoff(ConstantBackoff(time.Millisecond)))
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if err := bus.Publish(context.Background(), "catalog.products", NewEnvelope("product.created", nil)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	for want := 1; want <= 3; want++ {
+		if got := receive(t, attempts); got != want {
+			t.Fatalf("attempt %d, want %d", got, want)
+		}
+	}
+	expectNone(t, attempts)
+}
+
+func TestMemoryBusDeadLettersAfterMaxDeliveries(t *testing.T) {
+	bus := newTestBus(t)
+	attempts := make(chan int, 10)
+	dead := make(chan *Message, 1)
+
+	_, err := bus.Subscribe(context.Background(), "catalog.products", func(ctx context.Context, msg *Message) error {
+		attempts <- msg.Attempt
+		return errors.New("handler failed")
+	}, WithMaxDeliveries(3), WithBackoff(ConstantBackoff(time.Millisecond)))
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	_, err = bus.Subscribe(context.Background(), "catalog.products"+DeadLetterSuffix, func(ctx context.Context, msg *Message) error {
+		dead <- msg
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe to dead letters: %v", err)
+	}
+
+	env := NewEnvelope("product.created", []byte(`{"id":1}`))
+	if err := bus.Publish(context.Background(), "catalog.products", env); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	msg := receive(t, dead)
+	if msg.ID != env.ID || string(msg.Payload) != `{"id":1}` {
+		t.Errorf("dead letter = %s %s, want %s", msg.ID, msg.Payload, env.ID)
+	}
+	if msg.Headers[HeaderDeliveryCount] != "3" || msg.Headers[HeaderDeadLetterReason] != "handler failed" || msg.Headers[HeaderOriginalTopic] != "catalog.products" {
+		t.Errorf("dead letter headers = %v", msg.Headers)
+	}
+
+	for want := 1; want <= 3; want++ {
+		if got := receive(t, attempts); got != want {
+			t.Fatalf("attempt %d, want %d", got, want)
+		}
+	}
+	expectNone(t, attempts)
+}
+
+func TestMemoryBusFansOutToConsumerGroups(t *testing.T) {
+	bus := newTestBus(t)
+	const messages = 10
+
+	var mu sync.Mutex
+	received := make(map[string]map[string]int) // by group, messages by subscriber
+	var wg sync.WaitGroup
+	// Each of the two groups receives every message once
+	wg.Add(2 * messages)
+
+	subscribe := func(group, name string) {
+		_, err := bus.Subscribe(context.Background(), "catalog.products", func(ctx context.Context, msg *Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if received[group] == nil {
+				received[group] = make(map[string]int)
+			}
+			received[group][name]++
+			wg.Done()
+			return nil
+		}, WithGroup(group))
+		if err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+	}
+	subscribe("search", "search-1")
+	subscribe("search", "search-2")
+	subscribe("audit", "audit-1")
+
+	for range messages {
+		if err := bus.Publish(context.Background(), "catalog.products", NewEnvelope("product.updated", nil)); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	receive(t, done)
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The members of a group split its messages
+	if received["search"]["search-1"] != messages/2 || received["search"]["search-2"] != messages/2 {
+		t.Errorf("search group received %v, want %d each", received["search"], messages/2)
+	}
+	if received["audit"]["audit-1"] != messages {
+		t.Errorf("audit group received %v, want %d", received["audit"], messages)
+	}
+}
+
+func TestMemoryBusRedeliversToRemainingGroupMembers(t *testing.T) {
+	bus := newTestBus(t)
+	first := make(chan int, 10)
+	second := make(chan int, 10)
+
+	failing, err := bus.Subscribe(context.Background(), "catalog.products", func(ctx context.Context, msg *Message) error {
+		first <- msg.Attempt
+		return errors.New("failed")
+	}, WithGroup("search"), WithBackoff(ConstantBackoff(20*time.Millisecond)))
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if err := bus.Publish(context.Background(), "catalog.products", NewEnvelope("product.created", nil)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	receive(t, first)
+
+	// The failed subscriber leaves before the redelivery, which goes to the member joining
+	if err := failing.Unsubscribe(); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	_, err = bus.Subscribe(context.Background(), "catalog.products", func(ctx context.Context, msg *Message) error {
+		second <- msg.Attempt
+		return nil
+	}, WithGroup("search"))
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if got := receive(t, second); got != 2 {
+		t.Errorf("redelivered attempt %d, want 2", got)
+	}
+	expectNone(t, first)
+}
+
+func TestMemoryBusClose(t *testing.T) {
+	bus := NewMemoryBus()
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if err := bus.Publish(context.Background(), "catalog.products", NewEnvelope("product.created", nil)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Publish error = %v, want ErrClosed", err)
+	}
+	if _, err := bus.Subscribe(context.Background(), "catalog.products", func(context.Context, *Message) error { return nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("Subscribe error = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,210 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrClosed       = errors.New("message bus is closed")
+	ErrInvalidTopic = errors.New("invalid topic")
+)
+
+// Header names added to dead-lettered messages
+const (
+	HeaderDeadLetterReason = "X-Dead-Letter-Reason"
+	HeaderOriginalTopic    = "X-Original-Topic"
+	HeaderDeliveryCount    = "X-Delivery-Count"
+)
+
+// DeadLetterSuffix is appended to a topic to build its default dead-letter topic
+const DeadLetterSuffix = ".dlq"
+
+// Envelope wraps a message payload with the metadata carried across the bus
+type Envelope struct {
+	ID           string
+	Type         string
+	Payload      []byte
+	Headers      map[string]string
+	TraceContext map[string]string
+	PublishedAt  time.Time
+}
+
+// NewEnvelope creates an envelope with a fresh ID
+func NewEnvelope(messageType string, payload []byte) Envelope {
+	return Envelope{
+		ID:      uuid.New().String(),
+		Type:    messageType,
+		Payload: payload,
+		Headers: make(map[string]string),
+	}
+}
+
+// Message is a delivered envelope. Handlers may settle it explicitly with Ack or
+// Nack; otherwise it is acked when the handler returns nil and nacked when it
+// returns an error.
+type Message struct {
+	Envelope
+	Topic   string
+	Attempt int
+
+	ack  func() error
+	nack func(delay time.Duration) error
+	done bool
+}
+
+// Ack confirms the message has been processed
+func (m *Message) Ack() error {
+	if m.done {
+		return nil
+	}
+	m.done = true
+	return m.ack()
+}
+
+// Nack asks for the message to be redelivered after delay
+func (m *Message) Nack(delay time.Duration) error {
+	if m.done {
+		return nil
+	}
+	m.done = true
+	return m.nack(delay)
+}
+
+// Handler processes a delivered message
+type Handler func(ctx context.Context, msg *Message) error
+
+// Publisher publishes envelopes to a topic
+type Publisher interface {
+	Publish(ctx context.Context, topic string, env Envelope) error
+	Close() error
+}
+
+// Subscription is an active subscription to a topic
+type Subscription interface {
+	Unsubscribe() error
+}
+
+// Subscriber delivers messages published to a topic to a handler
+type Subscriber interface {
+	Subscribe(ctx context.Context, topic string, handler Handler, opts ...SubscribeOption) (Subscription, error)
+	Close() error
+}
+
+// Bus is both a Publisher and a Subscriber
+type Bus interface {
+	Publisher
+	Subscriber
+}
+
+// permanentError marks a handler error that must not be retried
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the message is dead-lettered without further retries
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped with Permanent
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// SubscribeOptions holds the settings of a subscription
+type SubscribeOptions struct {
+	// Group is the consumer group. Subscribers sharing a group split the messages
+	// of a topic between them; every group receives each message once.
+	// An empty group receives every message.
+	Group string
+	// MaxDeliveries is the number of attempts before a message is dead-lettered
+	MaxDeliveries int
+	// Backoff returns the delay before redelivering a message after a failed attempt
+	Backoff BackoffFunc
+	// DeadLetterTopic receives messages that exhausted their deliveries.
+	// It defaults to the topic with DeadLetterSuffix appended.
+	DeadLetterTopic string
+	// AckWait is how long the bus waits for a settlement before redelivering
+	AckWait time.Duration
+}
+
+// SubscribeOption configures a subscription
+type SubscribeOption func(*SubscribeOptions)
+
+// WithGroup sets the consumer group
+func WithGroup(group string) SubscribeOption {
+	return func(o *SubscribeOptions) { o.Group = group }
+}
+
+// WithMaxDeliveries sets the number of attempts before dead-lettering
+func WithMaxDeliveries(n int) SubscribeOption {
+	return func(o *SubscribeOptions) { o.MaxDeliveries = n }
+}
+
+// WithBackoff sets the redelivery backoff
+func WithBackoff(b BackoffFunc) SubscribeOption {
+	return func(o *SubscribeOptions) { o.Backoff = b }
+}
+
+// WithDeadLetterTopic overrides the dead-letter topic
+func WithDeadLetterTopic(topic string) SubscribeOption {
+	return func(o *SubscribeOptions) { o.DeadLetterTopic = topic }
+}
+
+// WithAckWait sets how long to wait for a settlement before redelivering
+func WithAckWait(d time.Duration) SubscribeOption {
+	return func(o *SubscribeOptions) { o.AckWait = d }
+}
+
+func newSubscribeOptions(topic string, opts []SubscribeOption) (SubscribeOptions, error) {
+	if topic == "" {
+		return SubscribeOptions{}, ErrInvalidTopic
+	}
+
+	o := SubscribeOptions{
+		MaxDeliveries: 5,
+		Backoff:       ExponentialBackoff(100*time.Millisecond, 30*time.Second),
+		AckWait:       30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.MaxDeliveries < 1 {
+		return SubscribeOptions{}, fmt.Errorf("max deliveries must be at least 1, got %d", o.MaxDeliveries)
+	}
+	if o.DeadLetterTopic == "" {
+		o.DeadLetterTopic = topic + DeadLetterSuffix
+	}
+
+	return o, nil
+}
+
+// deadLetterEnvelope copies env with headers describing why it was dead-lettered
+func deadLetterEnvelope(env Envelope, topic string, attempt int, reason error) Envelope {
+	dl := env
+	dl.Headers = make(map[string]string, len(env.Headers)+3)
+	for k, v := range env.Headers {
+		dl.Headers[k] = v
+	}
+
+	dl.Headers[HeaderOriginalTopic] = topic
+	dl.Headers[HeaderDeliveryCount] = fmt.Sprintf("%d", attempt)
+	if reason != nil {
+		dl.Headers[HeaderDeadLetterReason] = reason.Error()
+	}
+
+	return dl
+}
@@ -0,0 +1,273 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel"
+)
+
+const natsSystem = "nats"
+
+// Envelope fields carried in NATS headers
+const (
+	natsHeaderType        = "X-Message-Type"
+	natsHeaderPublishedAt = "X-Published-At"
+)
+
+// NATSConfig holds the settings of the NATS JetStream bus
+type NATSConfig struct {
+	URL string
+	// Name identifies the connection on the server
+	Name string
+	// Stream is created or updated on startup to capture Subjects
+	Stream string
+	// Subjects captured by the stream. They must also match the dead-letter topics,
+	// e.g. "catalog.>" covers "catalog.product.created.dlq".
+	Subjects []string
+	// MaxAge is how long the stream keeps messages; zero keeps them forever
+	MaxAge time.Duration
+}
+
+// NATSBus is a Bus backed by NATS JetStream. Consumer groups map to durable
+// consumers, so members of a group may run in different processes.
+type NATSBus struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	stream string
+
+	mu     sync.Mutex
+	subs   []*natsSubscription
+	closed bool
+}
+
+type natsSubscription struct {
+	bus     *NATSBus
+	consume jetstream.ConsumeContext
+	once    sync.Once
+}
+
+// NewNATSBus connects to the server and makes sure the stream exists
+func NewNATSBus(ctx context.Context, cfg NATSConfig) (*NATSBus, error) {
+	conn, err := nats.Connect(cfg.URL,
+		nats.Name(cfg.Name),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create jetstream context: %w", err)
+	}
+
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     cfg.Stream,
+		Subjects: cfg.Subjects,
+		MaxAge:   cfg.MaxAge,
+		Storage:  jetstream.FileStorage,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create stream %s: %w", cfg.Stream, err)
+	}
+
+	return &NATSBus{
+		conn:   conn,
+		js:     js,
+		stream: cfg.Stream,
+	}, nil
+}
+
+// Publish stores env in the stream. The envelope ID is used for JetStream
+// de-duplication, so republishing the same envelope is safe.
+func (b *NATSBus) Publish(ctx context.Context, topic string, env Envelope) error {
+	if topic == "" {
+		return ErrInvalidTopic
+	}
+
+	if env.ID == "" {
+		env.ID = uuid.New().String()
+	}
+	env.PublishedAt = time.Now().UTC()
+
+	ctx, span := startPublishSpan(ctx, natsSystem, topic, &env)
+	defer span.End()
+
+	_, err := b.js.PublishMsg(ctx, &nats.Msg{
+		Subject: topic,
+		Data:    env.Payload,
+		Header:  encodeNATSHeaders(env),
+	}, jetstream.WithMsgID(env.ID))
+	if err != nil {
+		recordSpanError(span, err)
+		return fmt.Errorf("failed to publish to %s: %w", topic, err)
+	}
+
+	return nil
+}
+
+// Subscribe consumes topic through a durable consumer named after the group,
+// or an ephemeral consumer when no group is given
+func (b *NATSBus) Subscribe(ctx context.Context, topic string, handler Handler, opts ...SubscribeOption) (Subscription, error) {
+	o, err := newSubscribeOptions(topic, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+	if closed {
+		return nil, ErrClosed
+	}
+
+	cfg := jetstream.ConsumerConfig{
+		FilterSubject: topic,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       o.AckWait,
+		MaxDeliver:    o.MaxDeliveries,
+		DeliverPolicy: jetstream.DeliverNewPolicy,
+	}
+	if o.Group != "" {
+		cfg.Durable = consumerName(o.Group, topic)
+		cfg.DeliverPolicy = jetstream.DeliverAllPolicy
+	}
+
+	consumer, err := b.js.CreateOrUpdateConsumer(ctx, b.stream, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer for %s: %w", topic, err)
+	}
+
+	handlerCtx := context.WithoutCancel(ctx)
+	consume, err := consumer.Consume(func(m jetstream.Msg) {
+		b.handle(handlerCtx, o, handler, m)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume %s: %w", topic, err)
+	}
+
+	sub := &natsSubscription{bus: b, consume: consume}
+
+	b.mu.Lock()
+	b.subs = append(b.subs, sub)
+	b.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = sub.Unsubscribe()
+		case <-consume.Closed():
+		}
+	}()
+
+	return sub, nil
+}
+
+// Close stops every subscription and drains the connection
+func (b *NATSBus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	subs := b.subs
+	b.subs = nil
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.consume.Drain()
+	}
+
+	return b.conn.Drain()
+}
+
+func (b *NATSBus) handle(ctx context.Context, o SubscribeOptions, handler Handler, m jetstream.Msg) {
+	attempt := 1
+	if meta, err := m.Metadata(); err == nil {
+		attempt = int(meta.NumDelivered)
+	}
+
+	msg := &Message{
+		Envelope: decodeNATSHeaders(m.Headers(), m.Data()),
+		Topic:    m.Subject(),
+		Attempt:  attempt,
+		ack:      m.Ack,
+		nack:     m.NakWithDelay,
+	}
+
+	_ = dispatch(ctx, natsSystem, b, o, handler, msg, m.Term)
+}
+
+// Unsubscribe stops consuming; the durable consumer keeps its position on the server
+func (s *natsSubscription) Unsubscribe() error {
+	s.once.Do(func() {
+		s.consume.Stop()
+
+		s.bus.mu.Lock()
+		s.bus.subs = slices.DeleteFunc(s.bus.subs, func(other *natsSubscription) bool { return other == s })
+		s.bus.mu.Unlock()
+	})
+
+	return nil
+}
+
+// consumerName builds a durable name, which may not contain '.', '*' or '>'
+func consumerName(group, topic string) string {
+	return strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_").Replace(group + "-" + topic)
+}
+
+func encodeNATSHeaders(env Envelope) nats.Header {
+	h := nats.Header{}
+	for k, v := range env.Headers {
+		h.Set(k, v)
+	}
+	for k, v := range env.TraceContext {
+		h.Set(k, v)
+	}
+
+	h.Set(natsHeaderType, env.Type)
+	h.Set(natsHeaderPublishedAt, env.PublishedAt.Format(time.RFC3339Nano))
+
+	return h
+}
+
+func decodeNATSHeaders(h nats.Header, data []byte) Envelope {
+	env := Envelope{
+		ID:           h.Get(nats.MsgIdHdr),
+		Type:         h.Get(natsHeaderType),
+		Payload:      data,
+		Headers:      make(map[string]string),
+		TraceContext: make(map[string]string),
+	}
+
+	if publishedAt, err := time.Parse(time.RFC3339Nano, h.Get(natsHeaderPublishedAt)); err == nil {
+		env.PublishedAt = publishedAt
+	}
+
+	traceFields := otel.GetTextMapPropagator().Fields()
+	for k, values := range h {
+		if len(values) == 0 || strings.HasPrefix(k, "Nats-") || k == natsHeaderType || k == natsHeaderPublishedAt {
+			continue
+		}
+
+		if slices.Contains(traceFields, strings.ToLower(k)) {
+			env.TraceContext[strings.ToLower(k)] = values[0]
+			continue
+		}
+
+		env.Headers[k] = values[0]
+	}
+
+	return env
+}
@@ -0,0 +1,62 @@
+package messaging
+
+import (
+	"context"
+	"microservice/pkg/telemetry"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startPublishSpan starts a producer span and records its context in env. When env
+// already carries a trace context, e.g. one captured by the outbox in the original
+// request, the span continues that trace instead of the one in ctx.
+func startPublishSpan(ctx context.Context, system, topic string, env *Envelope) (context.Context, trace.Span) {
+	if len(env.TraceContext) > 0 {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(env.TraceContext))
+	}
+
+	ctx, span := telemetry.Tracer().Start(ctx, topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", system),
+			attribute.String("messaging.destination.name", topic),
+			attribute.String("messaging.message.id", env.ID),
+			attribute.String("messaging.message.type", env.Type),
+		),
+	)
+
+	env.TraceContext = make(map[string]string)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(env.TraceContext))
+
+	return ctx, span
+}
+
+// startConsumeSpan continues the trace carried by msg with a consumer span
+func startConsumeSpan(ctx context.Context, system string, msg *Message, group string) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.TraceContext))
+
+	return telemetry.Tracer().Start(ctx, msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", system),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.String("messaging.consumer.group.name", group),
+			attribute.String("messaging.message.id", msg.ID),
+			attribute.String("messaging.message.type", msg.Type),
+			attribute.Int("messaging.delivery.attempt", msg.Attempt),
+		),
+	)
+}
+
+// recordSpanError marks the span as failed
+func recordSpanError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,49 @@
+package outbox
+
+import (
+	"context"
+	"microservice/pkg/messaging"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+// Headers added to envelopes relayed from the outbox
+const (
+	HeaderAggregateType = "X-Aggregate-Type"
+	HeaderAggregateID   = "X-Aggregate-ID"
+	HeaderOccurredAt    = "X-Occurred-At"
+)
+
+// NewBusPublisher returns a publisher that relays outbox messages to the bus on
+// the topic "<topicPrefix>.<event type>". The envelope reuses the event ID, so
+// redelivered messages can be de-duplicated, and continues the trace of the
+// request that raised the event. The tenant of the event is passed on in the
+// TenantHeader header.
+func NewBusPublisher(bus messaging.Publisher, topicPrefix string) Publisher {
+	return PublisherFunc(func(ctx context.Context, msg Message) error {
+		env := messaging.Envelope{
+			ID:           msg.EventID.String(),
+			Type:         msg.EventType,
+			Payload:      msg.Payload,
+			TraceContext: make(map[string]string),
+			Headers: map[string]string{
+				HeaderAggregateType: msg.AggregateType,
+				HeaderAggregateID:   msg.AggregateID.String(),
+				HeaderOccurredAt:    msg.OccurredAt.Format(time.RFC3339Nano),
+			},
+		}
+
+		// The trace context is replaced when publishing, so only trace keys go there
+		for _, field := range otel.GetTextMapPropagator().Fields() {
+			if value, ok := msg.Headers[field]; ok {
+				env.TraceContext[field] = value
+			}
+		}
+		if tenantID, ok := msg.Headers[TenantHeader]; ok {
+			env.Headers[TenantHeader] = tenantID
+		}
+
+		return bus.Publish(ctx, topicPrefix+"."+msg.EventType, env)
+	})
+}
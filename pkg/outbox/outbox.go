@@ -0,0 +1,77 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"microservice/pkg/tenant"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// TenantHeader carries the tenant of the event to consumers
+const TenantHeader = "tenant-id"
+
+// Message is an event stored in the outbox table waiting to be published
+type Message struct {
+	ID            int64
+	EventID       uuid.UUID
+	AggregateType string
+	AggregateID   uuid.UUID
+	EventType     string
+	Payload       []byte
+	Headers       map[string]string
+	OccurredAt    time.Time
+	Attempts      int
+}
+
+// NewMessage builds an outbox message, marshalling the payload to JSON and
+// capturing the trace context and tenant of ctx in the headers
+func NewMessage(ctx context.Context, eventID uuid.UUID, aggregateType string, aggregateID uuid.UUID, eventType string, occurredAt time.Time, payload any) (Message, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to marshal %s payload: %w", eventType, err)
+	}
+
+	headers := make(map[string]string)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+	if tenantID, ok := tenant.FromContext(ctx); ok {
+		headers[TenantHeader] = tenantID
+	}
+
+	return Message{
+		EventID:       eventID,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       data,
+		Headers:       headers,
+		OccurredAt:    occurredAt,
+	}, nil
+}
+
+// Insert writes messages to the outbox table using the caller's transaction,
+// so they are only published if the surrounding change commits
+func Insert(ctx context.Context, tx pgx.Tx, messages ...Message) error {
+	for _, msg := range messages {
+		headers, err := json.Marshal(msg.Headers)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO outbox (event_id, aggregate_type, aggregate_id, event_type, payload, headers, occurred_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			msg.EventID, msg.AggregateType, msg.AggregateID, msg.EventType, msg.Payload, headers, msg.OccurredAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert outbox message %s: %w", msg.EventID, err)
+		}
+	}
+
+	return nil
+}
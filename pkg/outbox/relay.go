@@ -0,0 +1,260 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"microservice/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// relayLockKey is the advisory lock that makes sure only one replica relays at a time,
+// which keeps events of the same aggregate in order
+const relayLockKey int64 = 0x6f7574626f78 // "outbox"
+
+// Publisher delivers outbox messages to the message bus
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// PublisherFunc adapts a function to the Publisher interface
+type PublisherFunc func(ctx context.Context, msg Message) error
+
+func (f PublisherFunc) Publish(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// RelayConfig holds the relay settings
+type RelayConfig struct {
+	PollInterval    time.Duration
+	BatchSize       int
+	Retention       time.Duration
+	CleanupInterval time.Duration
+	// MaxAttempts is the number of failed publications after which a message is parked
+	MaxAttempts int
+}
+
+// DefaultRelayConfig returns the default relay settings
+func DefaultRelayConfig() RelayConfig {
+	return RelayConfig{
+		PollInterval:    time.Second,
+		BatchSize:       100,
+		Retention:       24 * time.Hour,
+		CleanupInterval: time.Hour,
+		MaxAttempts:     10,
+	}
+}
+
+// Relay publishes pending outbox messages with at-least-once delivery.
+// Messages are published in insertion order; when one fails, later messages of
+// the same aggregate are held back until it succeeds, while the messages of other
+// aggregates go on. A message failing MaxAttempts times is parked: it is kept
+// unpublished and stops holding back its aggregate.
+type Relay struct {
+	db        *pgxpool.Pool
+	publisher Publisher
+	cfg       RelayConfig
+	logger    logger.Logger
+	tracer    trace.Tracer
+}
+
+func NewRelay(db *pgxpool.Pool, publisher Publisher, cfg RelayConfig, logger logger.Logger, tracer trace.Tracer) *Relay {
+	defaults := DefaultRelayConfig()
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaults.PollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaults.Retention
+	}
+	if cfg.CleanupInterval <= 0 {
+		cfg.CleanupInterval = defaults.CleanupInterval
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaults.MaxAttempts
+	}
+
+	return &Relay{
+		db:        db,
+		publisher: publisher,
+		cfg:       cfg,
+		logger:    logger,
+		tracer:    tracer,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	poll := time.NewTicker(r.cfg.PollInterval)
+	defer poll.Stop()
+
+	cleanup := time.NewTicker(r.cfg.CleanupInterval)
+	defer cleanup.Stop()
+
+	r.logger.Info("Outbox relay started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Outbox relay stopped")
+			return
+		case <-poll.C:
+			if _, err := r.RelayBatch(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				r.logger.Error("Outbox relay failed: %v", err)
+			}
+		case <-cleanup.C:
+			deleted, err := r.Cleanup(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				r.logger.Error("Outbox cleanup failed: %v", err)
+			} else if deleted > 0 {
+				r.logger.Debug("Outbox cleanup removed %d published messages", deleted)
+			}
+		}
+	}
+}
+
+// RelayBatch publishes one batch of pending messages and returns how many were published
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	ctx, span := r.tracer.Start(ctx, "OutboxRelay.RelayBatch")
+	defer span.End()
+
+	published := 0
+	err := pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var locked bool
+		if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", relayLockKey).Scan(&locked); err != nil {
+			return err
+		}
+		if !locked {
+			// Another replica is relaying
+			return nil
+		}
+
+		messages, err := pending(ctx, tx, r.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		blocked := make(map[uuid.UUID]bool)
+		for _, msg := range messages {
+			if blocked[msg.AggregateID] {
+				continue
+			}
+
+			if pubErr := r.publisher.Publish(ctx, msg); pubErr != nil {
+				if msg.Attempts+1 >= r.cfg.MaxAttempts {
+					r.logger.Error("Parked outbox message %d (%s) after %d attempts: %v", msg.ID, msg.EventType, msg.Attempts+1, pubErr)
+					_, err = tx.Exec(ctx, "UPDATE outbox SET attempts = attempts + 1, last_error = $2, parked_at = NOW() WHERE id = $1", msg.ID, pubErr.Error())
+					if err != nil {
+						return err
+					}
+					continue
+				}
+
+				blocked[msg.AggregateID] = true
+				r.logger.Warn("Failed to publish outbox message %d (%s): %v", msg.ID, msg.EventType, pubErr)
+
+				_, err = tx.Exec(ctx, "UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1", msg.ID, pubErr.Error())
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			_, err = tx.Exec(ctx, "UPDATE outbox SET attempts = attempts + 1, published_at = NOW(), last_error = NULL WHERE id = $1", msg.ID)
+			if err != nil {
+				return err
+			}
+			published++
+		}
+
+		return nil
+	})
+
+	span.SetAttributes(attribute.Int("outbox.published", published))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return 0, err
+	}
+
+	return published, nil
+}
+
+// Cleanup deletes messages that were published longer ago than the retention period
+func (r *Relay) Cleanup(ctx context.Context) (int64, error) {
+	tag, err := r.db.Exec(ctx,
+		"DELETE FROM outbox WHERE published_at IS NOT NULL AND published_at < $1",
+		time.Now().Add(-r.cfg.Retention),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// pending returns the oldest messages to publish. Messages held back by an earlier
+// failed message of their aggregate are left out, so the messages of an aggregate
+// failing to publish cannot fill the batch and stall the others.
+func pending(ctx context.Context, tx pgx.Tx, limit int) ([]Message, error) {
+	rows, err := tx.Query(ctx,
+		`SELECT id, event_id, aggregate_type, aggregate_id, event_type, payload, headers, occurred_at, attempts
+		FROM outbox o
+		WHERE published_at IS NULL AND parked_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox failed
+				WHERE failed.aggregate_id = o.aggregate_id
+					AND failed.id < o.id
+					AND failed.published_at IS NULL AND failed.parked_at IS NULL
+					AND failed.attempts > 0
+			)
+		ORDER BY id
+		LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []Message
+	for rows.Next() {
+		var (
+			msg     Message
+			headers []byte
+		)
+
+		err := rows.Scan(
+			&msg.ID,
+			&msg.EventID,
+			&msg.AggregateType,
+			&msg.AggregateID,
+			&msg.EventType,
+			&msg.Payload,
+			&headers,
+			&msg.OccurredAt,
+			&msg.Attempts,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(headers, &msg.Headers); err != nil {
+			return nil, fmt.Errorf("invalid headers on outbox message %d: %w", msg.ID, err)
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
@@ -0,0 +1,173 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"microservice/pkg/logger"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// outboxSchema is the outbox table as created by the service migrations
+const outboxSchema = `
+CREATE TABLE outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}'::jsonb,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    parked_at TIMESTAMPTZ
+)`
+
+// testPool connects to the database named by TEST_DATABASE_URL, in a schema of its
+// own dropped after the test. Tests needing it are skipped when the variable is unset.
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+
+	schema := "outbox_test_" + uuid.New().String()[:8]
+	admin, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer admin.Close(ctx)
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		conn, err := pgx.Connect(context.Background(), url)
+		if err != nil {
+			return
+		}
+		defer conn.Close(context.Background())
+		conn.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+	})
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("connect pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	if _, err := pool.Exec(ctx, outboxSchema); err != nil {
+		t.Fatalf("create outbox: %v", err)
+	}
+	return pool
+}
+
+// recordingPublisher records published messages, failing the ones of the aggregates in fail
+type recordingPublisher struct {
+	mu        sync.Mutex
+	fail      map[uuid.UUID]bool
+	published []Message
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fail[msg.AggregateID] {
+		return errors.New("broker rejected the message")
+	}
+	p.published = append(p.published, msg)
+	return nil
+}
+
+func insertMessages(t *testing.T, pool *pgxpool.Pool, aggregateID uuid.UUID, n int) {
+	t.Helper()
+	ctx := context.Background()
+
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		for i := range n {
+			msg, err := NewMessage(ctx, uuid.New(), "product", aggregateID, fmt.Sprintf("product.updated.%d", i), time.Now(), map[string]int{"seq": i})
+			if err != nil {
+				return err
+			}
+			if err := Insert(ctx, tx, msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("insert messages: %v", err)
+	}
+}
+
+func TestRelayDoesNotStallOnPoisonedAggregate(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+
+	poisoned, healthy := uuid.New(), uuid.New()
+	// The poisoned aggregate's messages come first and fill a whole batch
+	insertMessages(t, pool, poisoned, 3)
+	insertMessages(t, pool, healthy, 2)
+
+	publisher := &recordingPublisher{fail: map[uuid.UUID]bool{poisoned: true}}
+	relay := NewRelay(pool, publisher, RelayConfig{BatchSize: 2, MaxAttempts: 3}, logger.NewLogger(logger.Fatal, io.Discard, false), noop.NewTracerProvider().Tracer(""))
+
+	for range 4 {
+		if _, err := relay.RelayBatch(ctx); err != nil {
+			t.Fatalf("RelayBatch: %v", err)
+		}
+	}
+
+	if len(publisher.published) != 2 {
+		t.Fatalf("published %d messages, want the 2 of the healthy aggregate", len(publisher.published))
+	}
+	for i, msg := range publisher.published {
+		if msg.AggregateID != healthy || msg.EventType != fmt.Sprintf("product.updated.%d", i) {
+			t.Errorf("published message %d = %s of %s, want product.updated.%d of %s", i, msg.EventType, msg.AggregateID, i, healthy)
+		}
+	}
+
+	// The first poisoned message is parked after its attempts, and the next one is
+	// tried in its place while the last waits behind it
+	rows, err := pool.Query(ctx, "SELECT attempts, last_error IS NOT NULL, parked_at IS NOT NULL FROM outbox WHERE aggregate_id = $1 ORDER BY id", poisoned)
+	if err != nil {
+		t.Fatalf("query poisoned messages: %v", err)
+	}
+	type state struct {
+		attempts int
+		failed   bool
+		parked   bool
+	}
+	got, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (state, error) {
+		var s state
+		err := row.Scan(&s.attempts, &s.failed, &s.parked)
+		return s, err
+	})
+	if err != nil {
+		t.Fatalf("read poisoned messages: %v", err)
+	}
+
+	want := []state{{3, true, true}, {1, true, false}, {0, false, false}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("poisoned messages = %+v, want %+v", got, want)
+	}
+}
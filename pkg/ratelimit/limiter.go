@@ -0,0 +1,259 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"microservice/pkg/auth"
+	"microservice/pkg/tenant"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule applies a limit to the requests whose path is Path or below it and, if Method
+// is set, whose method is Method
+type Rule struct {
+	Method string
+	Path   string
+	Limit  Limit
+}
+
+// ParseRule parses a rule written as [METHOD ]PATH=LIMIT, such as
+// "POST /api/products=60/1m" or "/graphql=120/1m"
+func ParseRule(s string) (Rule, error) {
+	route, limit, ok := strings.Cut(s, "=")
+	if !ok {
+		return Rule{}, fmt.Errorf("%w %q: expected [METHOD ]PATH=LIMIT", ErrInvalidLimit, s)
+	}
+
+	var rule Rule
+	fields := strings.Fields(route)
+	switch len(fields) {
+	case 1:
+		rule.Path = fields[0]
+	case 2:
+		rule.Method, rule.Path = strings.ToUpper(fields[0]), fields[1]
+	default:
+		return Rule{}, fmt.Errorf("%w %q: expected [METHOD ]PATH=LIMIT", ErrInvalidLimit, s)
+	}
+
+	if !strings.HasPrefix(rule.Path, "/") {
+		return Rule{}, fmt.Errorf("%w %q: path must start with /", ErrInvalidLimit, s)
+	}
+
+	l, err := ParseLimit(limit)
+	if err != nil {
+		return Rule{}, err
+	}
+	rule.Limit = l
+
+	return rule, nil
+}
+
+// ParseRules parses each rule with ParseRule
+func ParseRules(rules []string) ([]Rule, error) {
+	parsed := make([]Rule, 0, len(rules))
+	for _, s := range rules {
+		rule, err := ParseRule(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, rule)
+	}
+	return parsed, nil
+}
+
+func (r Rule) matches(method, path string) bool {
+	if r.Method != "" && r.Method != method {
+		return false
+	}
+
+	prefix := strings.TrimSuffix(r.Path, "/")
+	return path == r.Path || strings.HasPrefix(path, prefix+"/")
+}
+
+// name identifies the counters of the rule, so each rule has its own quota
+func (r Rule) name() string {
+	if r.Method == "" {
+		return r.Path
+	}
+	return r.Method + " " + r.Path
+}
+
+// Limiter applies the most specific matching rule to each request, or the default
+// limit when no rule matches. The address limit applies to every request of an
+// address before its client is authenticated, so guessing credentials is throttled.
+type Limiter struct {
+	store        Store
+	defaultLimit Limit
+	addressLimit Limit
+	rules        []Rule
+}
+
+func NewLimiter(store Store, defaultLimit, addressLimit Limit, rules []Rule) *Limiter {
+	return &Limiter{
+		store:        store,
+		defaultLimit: defaultLimit,
+		addressLimit: addressLimit,
+		rules:        rules,
+	}
+}
+
+// Allow counts the request of client against the limit of its route
+func (l *Limiter) Allow(ctx context.Context, r *http.Request, client string) (Result, error) {
+	return l.AllowRoute(ctx, r.Method, r.URL.Path, client)
+}
+
+// AllowRoute counts a request of client to method and path against the limit of the
+// route, for requests not served over plain HTTP such as gRPC calls
+func (l *Limiter) AllowRoute(ctx context.Context, method, path, client string) (Result, error) {
+	name, limit := "*", l.defaultLimit
+	if rule, ok := l.match(method, path); ok {
+		name, limit = rule.name(), rule.Limit
+	}
+
+	return l.store.Allow(ctx, name+"|"+client, limit)
+}
+
+// AllowAddress counts a request from address against the address limit. Its counters
+// are kept apart from the ones of the rules.
+func (l *Limiter) AllowAddress(ctx context.Context, address string) (Result, error) {
+	return l.store.Allow(ctx, "address|"+address, l.addressLimit)
+}
+
+// match returns the rule with the longest path matching the request, preferring
+// rules for the request method over rules for any method
+func (l *Limiter) match(method, path string) (Rule, bool) {
+	var best Rule
+	found := false
+	for _, rule := range l.rules {
+		if !rule.matches(method, path) {
+			continue
+		}
+		if !found || len(rule.Path) > len(best.Path) ||
+			(len(rule.Path) == len(best.Path) && best.Method == "" && rule.Method != "") {
+			best, found = rule, true
+		}
+	}
+	return best, found
+}
+
+// KeyFunc returns the client a request is counted for
+type KeyFunc func(r *http.Request) string
+
+// ClientKey counts authenticated requests for their principal, so API keys and users
+// keep their quota across addresses, and anonymous requests for their IP address.
+// With trustForwarded the address is the last hop of X-Forwarded-For, the one added
+// by the proxy in front of the service; only enable it behind a proxy that sets it.
+func ClientKey(trustForwarded bool) KeyFunc {
+	return func(r *http.Request) string {
+		return Client(r.Context(), r.RemoteAddr, forwardedFor(r, trustForwarded))
+	}
+}
+
+// AddressKey returns the IP address of a request, taken as ClientKey does
+func AddressKey(trustForwarded bool) KeyFunc {
+	return func(r *http.Request) string {
+		return Address(r.RemoteAddr, forwardedFor(r, trustForwarded))
+	}
+}
+
+func forwardedFor(r *http.Request, trusted bool) []string {
+	if !trusted {
+		return nil
+	}
+	return r.Header.Values("X-Forwarded-For")
+}
+
+// Client returns the client a request with the principal of ctx is counted for, as
+// ClientKey does. forwarded holds the X-Forwarded-For values of the request if they
+// are trusted. Principals are counted per tenant, as the same subject may exist in
+// several.
+func Client(ctx context.Context, remoteAddr string, forwarded []string) string {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		tenantID, _ := tenant.FromContext(ctx)
+		return "sub:" + tenantID + "/" + principal.Subject
+	}
+
+	return "ip:" + Address(remoteAddr, forwarded)
+}
+
+// Address returns the IP address of a request from remoteAddr: the last hop of
+// forwarded if given, or else the host of remoteAddr
+func Address(remoteAddr string, forwarded []string) string {
+	if len(forwarded) > 0 {
+		hops := strings.Split(forwarded[len(forwarded)-1], ",")
+		if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+			return ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return host
+}
+
+// LimitHandler writes the response of a request over its limit
+type LimitHandler func(w http.ResponseWriter, r *http.Request, result Result)
+
+// ErrorHandler is called when the store fails; the request is let through so an
+// unavailable store does not take the service down with it
+type ErrorHandler func(r *http.Request, err error)
+
+// Middleware counts each request against its limit, sets the RateLimit-* headers and
+// hands requests over their limit to onLimit after setting Retry-After
+func (l *Limiter) Middleware(key KeyFunc, onLimit LimitHandler, onError ErrorHandler) func(http.Handler) http.Handler {
+	return middleware(func(r *http.Request) (Result, error) {
+		return l.Allow(r.Context(), r, key(r))
+	}, onLimit, onError)
+}
+
+// AddressMiddleware counts each request against the address limit, as Middleware
+// does. It runs before authentication, so requests with wrong credentials count.
+func (l *Limiter) AddressMiddleware(address KeyFunc, onLimit LimitHandler, onError ErrorHandler) func(http.Handler) http.Handler {
+	return middleware(func(r *http.Request) (Result, error) {
+		return l.AllowAddress(r.Context(), address(r))
+	}, onLimit, onError)
+}
+
+func middleware(allow func(r *http.Request) (Result, error), onLimit LimitHandler, onError ErrorHandler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := allow(r)
+			if err != nil {
+				onError(r, err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			SetHeaders(w.Header(), result)
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(Seconds(result.RetryAfter)))
+				onLimit(w, r, result)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SetHeaders sets the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and
+// RateLimit-Policy headers of the IETF httpapi ratelimit headers draft
+func SetHeaders(h http.Header, result Result) {
+	h.Set("RateLimit-Limit", strconv.Itoa(result.Limit.Requests))
+	h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(Seconds(result.Reset)))
+	h.Set("RateLimit-Policy", result.Limit.Policy())
+}
+
+// Seconds rounds d up to whole seconds, as the headers carry no fractions
+func Seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
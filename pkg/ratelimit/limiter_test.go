@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"microservice/pkg/auth"
+	"microservice/pkg/tenant"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientCountsPrincipalsPerTenant(t *testing.T) {
+	principal := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "user-1"})
+	acme := Client(tenant.WithID(principal, "acme"), "10.0.0.1:1234", nil)
+	globex := Client(tenant.WithID(principal, "globex"), "10.0.0.2:1234", nil)
+
+	if acme == globex {
+		t.Errorf("subject in two tenants shares the key %q", acme)
+	}
+	if again := Client(tenant.WithID(principal, "acme"), "10.0.0.3:1234", nil); again != acme {
+		t.Errorf("key %q changed with the address, want %q", again, acme)
+	}
+}
+
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"remote address", "10.0.0.1:1234", nil, "10.0.0.1"},
+		{"without port", "10.0.0.1", nil, "10.0.0.1"},
+		{"last forwarded hop", "10.0.0.1:1234", []string{"203.0.113.9", "198.51.100.1, 198.51.100.2"}, "198.51.100.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Address(tt.remoteAddr, tt.forwarded); got != tt.want {
+				t.Errorf("Address = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddressMiddlewareCountsUnauthenticatedRequests(t *testing.T) {
+	store := NewMemoryStore()
+	limiter := NewLimiter(store, Limit{Requests: 100, Window: time.Minute}, Limit{Requests: 2, Window: time.Minute}, nil)
+
+	// Every request fails authentication, as a client guessing API keys would
+	unauthorized := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	limited := func(w http.ResponseWriter, r *http.Request, result Result) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}
+	h := limiter.AddressMiddleware(AddressKey(false), limited, func(*http.Request, error) {})(unauthorized)
+
+	var codes []int
+	for range 3 {
+		req := httptest.NewRequest(http.MethodGet, "/api/products", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-API-Key", "guess")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+	}
+
+	if codes[0] != http.StatusUnauthorized || codes[1] != http.StatusUnauthorized || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("status codes = %v, want 401, 401, 429", codes)
+	}
+
+	// The default limit of the address is counted apart
+	result, err := limiter.AllowRoute(context.Background(), http.MethodGet, "/api/products", "ip:10.0.0.1")
+	if err != nil {
+		t.Fatalf("AllowRoute: %v", err)
+	}
+	if !result.Allowed || result.Remaining != 99 {
+		t.Errorf("default limit result = %+v, want 99 remaining", result)
+	}
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore drops the keys of clients whose quota is full
+const sweepInterval = time.Minute
+
+// MemoryStore keeps the counters in process, so each replica enforces its own limits
+type MemoryStore struct {
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tats:      make(map[string]time.Time),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) > sweepInterval {
+		s.sweep(now)
+	}
+
+	// tat is the theoretical arrival time: when the client's bucket is full again
+	tat := s.tats[key]
+	if tat.Before(now) {
+		tat = now
+	}
+
+	next := tat.Add(limit.interval())
+	allowAt := next.Add(-limit.Window)
+	if now.Before(allowAt) {
+		return newResult(limit, false, tat.Sub(now), allowAt.Sub(now)), nil
+	}
+
+	s.tats[key] = next
+	return newResult(limit, true, next.Sub(now), 0), nil
+}
+
+// Len returns the number of keys tracked
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.tats)
+}
+
+// sweep removes the keys whose bucket is full again; the caller holds the lock
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, tat := range s.tats {
+		if !tat.After(now) {
+			delete(s.tats, key)
+		}
+	}
+	s.lastSweep = now
+}
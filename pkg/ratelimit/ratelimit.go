@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// Limit allows Requests per Window. Quota is replenished continuously, one request
+// every Window/Requests, and a client that was idle may burst up to Requests at once.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Result is the outcome of counting a request against a limit
+type Result struct {
+	Allowed bool
+	Limit   Limit
+	// Remaining is how many more requests are allowed right now
+	Remaining int
+	// Reset is how long until the full quota is available again
+	Reset time.Duration
+	// RetryAfter is how long a denied client has to wait before its next request
+	RetryAfter time.Duration
+}
+
+// Store counts requests per key. Stores implement the generic cell rate algorithm,
+// a token bucket that only needs to keep one timestamp per key.
+type Store interface {
+	// Allow counts a request for key against limit
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// ParseLimit parses a limit written as requests/window, such as 100/1m or 10/s
+func ParseLimit(s string) (Limit, error) {
+	requests, window, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("%w %q: expected requests/window", ErrInvalidLimit, s)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil {
+		return Limit{}, fmt.Errorf("%w %q: %v", ErrInvalidLimit, s, err)
+	}
+
+	d, err := time.ParseDuration(window)
+	if err != nil {
+		// Allow a bare unit, as in 10/s
+		d, err = time.ParseDuration("1" + window)
+		if err != nil {
+			return Limit{}, fmt.Errorf("%w %q: %v", ErrInvalidLimit, s, err)
+		}
+	}
+
+	limit := Limit{Requests: n, Window: d}
+	if err := limit.Validate(); err != nil {
+		return Limit{}, fmt.Errorf("%w %q", err, s)
+	}
+
+	return limit, nil
+}
+
+// Validate checks that the limit allows at least one request per positive window
+func (l Limit) Validate() error {
+	if l.Requests <= 0 || l.Window <= 0 || l.interval() <= 0 {
+		return ErrInvalidLimit
+	}
+	return nil
+}
+
+// Policy returns the limit in the format of the RateLimit-Policy header
+func (l Limit) Policy() string {
+	return fmt.Sprintf("%d;w=%d", l.Requests, int(l.Window.Seconds()))
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Window)
+}
+
+// interval is the time it takes to replenish the quota of one request
+func (l Limit) interval() time.Duration {
+	return l.Window / time.Duration(l.Requests)
+}
+
+// newResult builds the result of a request given how long until the bucket is full
+// again and, for denied requests, how long until the next one is allowed
+func newResult(limit Limit, allowed bool, reset, retryAfter time.Duration) Result {
+	remaining := int((limit.Window - reset) / limit.interval())
+	if remaining < 0 || !allowed {
+		remaining = 0
+	}
+
+	return Result{
+		Allowed:    allowed,
+		Limit:      limit,
+		Remaining:  remaining,
+		Reset:      reset,
+		RetryAfter: retryAfter,
+	}
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// allowScript runs the cell rate algorithm atomically on the Redis clock, so replicas
+// with skewed clocks still agree. Times are in microseconds.
+var allowScript = redis.NewScript(`
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local interval = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+
+local next = tat + interval
+local allow_at = next - window
+if now < allow_at then
+	return {0, tat - now, allow_at - now}
+end
+
+redis.call('SET', KEYS[1], next, 'PX', math.ceil((next - now) / 1000))
+return {1, next - now, 0}
+`)
+
+// RedisConfig holds the Redis connection settings
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+	// Prefix namespaces the keys so the limits can share a database with other data
+	Prefix string
+}
+
+// RedisStore keeps the counters in Redis so limits hold across every replica
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(cfg RedisConfig) *RedisStore {
+	return &RedisStore{
+		client: redis.NewClient(&redis.Options{
+			Addr:     cfg.Addr,
+			Password: cfg.Password,
+			DB:       cfg.DB,
+		}),
+		prefix: cfg.Prefix,
+	}
+}
+
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	values, err := allowScript.Run(ctx, s.client, []string{s.prefix + key},
+		limit.interval().Microseconds(), limit.Window.Microseconds()).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	reset := time.Duration(values[1]) * time.Microsecond
+	retryAfter := time.Duration(values[2]) * time.Microsecond
+
+	return newResult(limit, values[0] == 1, reset, retryAfter), nil
+}
+
+// Ping checks the connection to Redis
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// Close closes the connection pool
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
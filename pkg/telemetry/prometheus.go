@@ -48,6 +48,34 @@ var (
 		},
 	)
 
+	// Cache lookups by cache name and result (hit, miss or negative_hit)
+	cacheRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_requests_total",
+			Help: "Total number of cache lookups",
+		},
+		[]string{"cache", "result"},
+	)
+
+	// gRPC request metrics
+	grpcRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_requests_total",
+			Help: "Total number of gRPC requests",
+		},
+		[]string{"method", "code"},
+	)
+
+	// gRPC request duration metrics
+	grpcRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "grpc_request_duration_seconds",
+			Help:    "gRPC request latency in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method"},
+	)
+
 	// We don't need to define go_goroutines as it's already provided by the Prometheus client
 
 	// Memory stats
@@ -66,6 +94,9 @@ func init() {
 	prometheus.MustRegister(httpRequestDuration)
 	prometheus.MustRegister(activeRequests)
 	prometheus.MustRegister(memoryAllocBytes)
+	prometheus.MustRegister(cacheRequestsTotal)
+	prometheus.MustRegister(grpcRequestsTotal)
+	prometheus.MustRegister(grpcRequestDuration)
 
 	// Start a goroutine to update runtime metrics
 	go updateRuntimeMetrics()
@@ -93,6 +124,12 @@ func RecordHTTPRequest(method, endpoint, status string, duration time.Duration)
 	httpRequestDuration.WithLabelValues(method, endpoint).Observe(duration.Seconds())
 }
 
+// RecordGRPCRequest records metrics for a gRPC request
+func RecordGRPCRequest(method, code string, duration time.Duration) {
+	grpcRequestsTotal.WithLabelValues(method, code).Inc()
+	grpcRequestDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
 // IncreaseActiveRequests increases the active requests counter
 func IncreaseActiveRequests() {
 	activeRequests.Inc()
@@ -103,6 +140,18 @@ func DecreaseActiveRequests() {
 	activeRequests.Dec()
 }
 
+// Cache lookup results
+const (
+	CacheHit         = "hit"
+	CacheMiss        = "miss"
+	CacheNegativeHit = "negative_hit"
+)
+
+// RecordCacheLookup records the result of a cache lookup
+func RecordCacheLookup(cache, result string) {
+	cacheRequestsTotal.WithLabelValues(cache, result).Inc()
+}
+
 // setupPrometheusMetrics initializes the Prometheus meter provider
 func setupPrometheusMetrics(ctx context.Context, cfg Config) (metric.MeterProvider, func(context.Context) error, error) {
 	if !cfg.MetricsEnabled {
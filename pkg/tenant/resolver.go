@@ -0,0 +1,131 @@
+package tenant
+
+import (
+	"context"
+	"microservice/pkg/auth"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Config holds the ways a request selects its tenant
+type Config struct {
+	// Header names the header carrying the tenant; not read if empty
+	Header string
+	// BaseDomain resolves the tenant from the subdomain of hosts below it, as acme for
+	// acme.shop.example.com; not used if empty
+	BaseDomain string
+	// Default is the tenant of requests naming none; they are rejected if empty
+	Default string
+	// AllowUnbound lets principals bound to no tenant, as operators working across
+	// tenants, act in the tenant the request names. Otherwise they are rejected.
+	AllowUnbound bool
+}
+
+// Resolver determines the tenant of a request. The tenant of an authenticated
+// principal is authoritative: a request naming another one is rejected, as are
+// principals bound to none unless allowed. Otherwise the header takes precedence
+// over the subdomain.
+type Resolver struct {
+	cfg Config
+}
+
+func NewResolver(cfg Config) *Resolver {
+	cfg.BaseDomain = strings.ToLower(strings.Trim(cfg.BaseDomain, "."))
+	return &Resolver{cfg: cfg}
+}
+
+// Header returns the name of the header carrying the tenant
+func (r *Resolver) Header() string {
+	return r.cfg.Header
+}
+
+// Resolve returns the tenant of a request with the principal of ctx, sent to host and
+// naming requested in the tenant header
+func (r *Resolver) Resolve(ctx context.Context, requested, host string) (string, error) {
+	if r.cfg.Header == "" {
+		requested = ""
+	}
+	if requested == "" {
+		sub, err := r.subdomain(host)
+		if err != nil {
+			return "", err
+		}
+		requested = sub
+	}
+
+	if requested != "" && !Valid(requested) {
+		return "", ErrInvalidTenant
+	}
+
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		if principal.Tenant == "" && !r.cfg.AllowUnbound {
+			return "", ErrUnboundCredentials
+		}
+		if principal.Tenant != "" {
+			if requested != "" && requested != principal.Tenant {
+				return "", ErrTenantMismatch
+			}
+			return principal.Tenant, nil
+		}
+	}
+
+	if requested != "" {
+		return requested, nil
+	}
+
+	if r.cfg.Default != "" {
+		return r.cfg.Default, nil
+	}
+
+	return "", ErrMissingTenant
+}
+
+// subdomain returns the label of host below the base domain, or "" if host is not
+// below it
+func (r *Resolver) subdomain(host string) (string, error) {
+	if r.cfg.BaseDomain == "" || host == "" {
+		return "", nil
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	label, ok := strings.CutSuffix(host, "."+r.cfg.BaseDomain)
+	if !ok {
+		return "", nil
+	}
+	if strings.Contains(label, ".") {
+		return "", ErrInvalidTenant
+	}
+
+	return label, nil
+}
+
+// ErrorHandler writes the response of a request whose tenant cannot be resolved
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// Middleware adds the tenant of each request to its context. It must run after the
+// principal is added. Responses vary on the tenant header, so shared caches never
+// serve the response of one tenant to another.
+func (r *Resolver) Middleware(onError ErrorHandler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var requested string
+			if r.cfg.Header != "" {
+				w.Header().Add("Vary", r.cfg.Header)
+				requested = req.Header.Get(r.cfg.Header)
+			}
+
+			id, err := r.Resolve(req.Context(), requested, req.Host)
+			if err != nil {
+				onError(w, req, err)
+				return
+			}
+
+			next.ServeHTTP(w, req.WithContext(WithID(req.Context(), id)))
+		})
+	}
+}
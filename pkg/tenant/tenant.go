@@ -0,0 +1,46 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrMissingTenant = errors.New("tenant is required")
+	ErrInvalidTenant = errors.New("invalid tenant")
+	// ErrTenantMismatch is returned when a request names another tenant than the one
+	// its credentials were issued for
+	ErrTenantMismatch = errors.New("credentials do not grant access to the tenant")
+	// ErrUnboundCredentials is returned when credentials bound to no tenant are used
+	// while only bound ones are accepted
+	ErrUnboundCredentials = errors.New("credentials are not bound to a tenant")
+)
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx carrying the tenant
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant of ctx and whether there is one
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// Valid reports whether id can name a tenant. Tenant IDs are DNS labels, lowercase
+// letters, digits and inner hyphens of at most 63 characters, so they can be subdomains.
+func Valid(id string) bool {
+	if len(id) == 0 || len(id) > 63 || id[0] == '-' || id[len(id)-1] == '-' {
+		return false
+	}
+
+	for _, c := range id {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -1,15 +1,32 @@
 
 -- scripts/db/seed/products.sql
-INSERT INTO products (id, name, description, price, sku, category_id, created_at, updated_at) 
+INSERT INTO products (id, tenant_id, name, description, price, sku, category_id, created_at, updated_at) 
 VALUES 
-(gen_random_uuid(), 'Smartphone X', 'Latest smartphone with advanced features', 999.99, 'PHONE-001', '550e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
-(gen_random_uuid(), 'Laptop Pro', 'High-performance laptop for professionals', 1499.99, 'LAPTOP-001', '550e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
-(gen_random_uuid(), 'Wireless Earbuds', 'Premium wireless earbuds with noise cancellation', 199.99, 'AUDIO-001', '550e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
-(gen_random_uuid(), 'Smart Watch', 'Fitness and health tracking smartwatch', 299.99, 'WATCH-001', '550e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
-(gen_random_uuid(), 'Tablet Ultra', 'Lightweight tablet with high-resolution display', 699.99, 'TABLET-001', '550e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
-(gen_random_uuid(), 'T-Shirt', 'Cotton t-shirt', 29.99, 'SHIRT-001', '650e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
-(gen_random_uuid(), 'Jeans', 'Denim jeans', 59.99, 'PANTS-001', '650e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
-(gen_random_uuid(), 'Novel', 'Bestselling fiction novel', 14.99, 'BOOK-001', '750e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
-(gen_random_uuid(), 'Cookbook', 'Recipe collection', 24.99, 'BOOK-002', '750e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
-(gen_random_uuid(), 'Coffee Maker', 'Automatic coffee machine', 89.99, 'KITCHEN-001', '850e8400-e29b-41d4-a716-446655440000', NOW(), NOW());
+(gen_random_uuid(), 'default', 'Smartphone X', 'Latest smartphone with advanced features', 999.99, 'PHONE-001', '550e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
+(gen_random_uuid(), 'default', 'Laptop Pro', 'High-performance laptop for professionals', 1499.99, 'LAPTOP-001', '550e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
+(gen_random_uuid(), 'default', 'Wireless Earbuds', 'Premium wireless earbuds with noise cancellation', 199.99, 'AUDIO-001', '550e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
+(gen_random_uuid(), 'default', 'Smart Watch', 'Fitness and health tracking smartwatch', 299.99, 'WATCH-001', '550e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
+(gen_random_uuid(), 'default', 'Tablet Ultra', 'Lightweight tablet with high-resolution display', 699.99, 'TABLET-001', '550e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
+(gen_random_uuid(), 'default', 'T-Shirt', 'Cotton t-shirt', 29.99, 'SHIRT-001', '650e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
+(gen_random_uuid(), 'default', 'Jeans', 'Denim jeans', 59.99, 'PANTS-001', '650e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
+(gen_random_uuid(), 'default', 'Novel', 'Bestselling fiction novel', 14.99, 'BOOK-001', '750e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
+(gen_random_uuid(), 'default', 'Cookbook', 'Recipe collection', 24.99, 'BOOK-002', '750e8400-e29b-41d4-a716-446655440000', NOW(), NOW()),
+(gen_random_uuid(), 'default', 'Coffee Maker', 'Automatic coffee machine', 89.99, 'KITCHEN-001', '850e8400-e29b-41d4-a716-446655440000', NOW(), NOW());
 
+-- Give the seeded products the create entries their history is replayed from
+INSERT INTO product_audit_log (id, tenant_id, product_id, action, actor, request_id, changes, created_at)
+SELECT gen_random_uuid(), p.tenant_id, p.id, 'create', 'system', 'seed',
+    jsonb_build_array(
+        jsonb_build_object('field', 'name', 'old', NULL, 'new', to_jsonb(p.name)),
+        jsonb_build_object('field', 'description', 'old', NULL, 'new', to_jsonb(COALESCE(p.description, ''))),
+        jsonb_build_object('field', 'price', 'old', NULL, 'new', to_jsonb(p.price::float8)),
+        jsonb_build_object('field', 'sku', 'old', NULL, 'new', to_jsonb(p.sku)),
+        jsonb_build_object('field', 'category_id', 'old', NULL, 'new', to_jsonb(p.category_id::text)),
+        jsonb_build_object('field', 'status', 'old', NULL, 'new', to_jsonb(p.status)),
+        jsonb_build_object('field', 'type', 'old', NULL, 'new', to_jsonb(p.type))),
+    p.created_at
+FROM products p
+WHERE NOT EXISTS (
+    SELECT 1 FROM product_audit_log a
+    WHERE a.tenant_id = p.tenant_id AND a.product_id = p.id AND a.action = 'create'
+);
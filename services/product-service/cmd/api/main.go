@@ -2,15 +2,29 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
+	"microservice/pkg/auth"
+	"microservice/pkg/cache"
 	"microservice/pkg/config"
 	"microservice/pkg/database"
+	"microservice/pkg/idempotency"
+	"microservice/pkg/locale"
 	"microservice/pkg/logger"
+	"microservice/pkg/messaging"
+	"microservice/pkg/outbox"
+	"microservice/pkg/ratelimit"
 	"microservice/pkg/telemetry"
+	"microservice/pkg/tenant"
 	"microservice/services/product-service/internal/application"
+	"microservice/services/product-service/internal/domain"
 	"microservice/services/product-service/internal/infrastructure/api"
+	"microservice/services/product-service/internal/infrastructure/graphqlapi"
+	"microservice/services/product-service/internal/infrastructure/grpcapi"
+	"microservice/services/product-service/internal/infrastructure/persistence/cached"
 	"microservice/services/product-service/internal/infrastructure/persistence/postgres"
-	"microservice/services/product-service/internal/infrastructure/validator"
+	"microservice/services/product-service/internal/interfaces"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -26,7 +40,11 @@ import (
 
 // @title Product Service API
 // @version 1.0
-// @description This is the product service API for the microservice architecture
+// @description This is the product service API for the microservice architecture.
+// @description Catalogs are scoped to a tenant, selected by the X-Tenant-ID header or the subdomain when TENANCY_ENABLED is true; credentials bound to a tenant can only access that tenant.
+// @description Errors are returned as application/problem+json (RFC 9457): type identifies the problem, instance is the request ID and errors lists the invalid fields of validation problems.
+// @description Each invalid field has a stable code, as required or max, with the params of its message; messages are in the first supported language (en, de, fr or es) of the locale selected by the ?locale= parameter or Accept-Language.
+// @description Product names and descriptions are translated into the locale selected by the ?locale= parameter or Accept-Language, falling back to less specific locales (de-AT, then de) and then the default locale; Content-Language names the locale served.
 // @termsOfService http://swagger.io/terms/
 
 // @contact.name API Support
@@ -39,12 +57,30 @@ import (
 // @host localhost:8080
 // @BasePath /api
 // @schemes http
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT bearer token, as "Bearer <token>". Reads are public; writes require a token with the catalog_editor or admin role when AUTH_ENABLED is true.
+
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key of a machine client, issued through /admin/api-keys
 func main() {
-	// Load configuration
-	appCfg, err := config.LoadConfig("services/product-service")
+	// Load configuration from the defaults, the config file, the environment and the flags
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	printConfig := flags.Bool("print-config", false, "print each setting with its source and exit")
+	appCfg, err := config.LoadConfig(flags, os.Args[1:])
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if *printConfig {
+		if err := appCfg.WriteSources(os.Stdout); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
+		}
+		return
+	}
 
 	// Initialize telemetry first
 	telemetryCfg := telemetry.Config{
@@ -76,14 +112,240 @@ func main() {
 	// Now use the tracer after it's been initialized
 	tr := telemetry.Tracer()
 	lg := logger.GetDefaultLogger()
-	productRepo := postgres.NewProductRepository(dbpool, tr)
-	productService := application.NewProductService(productRepo, tr)
-	productHandler := api.NewProductHandler(productService, validator.New(), lg)
+	var productRepo interfaces.ProductRepository = postgres.NewProductRepository(dbpool, tr)
+
+	var cachedRepo *cached.ProductRepository
+	if appCfg.Cache.Enabled {
+		cachedRepo = cached.NewProductRepository(productRepo, newCache(appCfg), cached.Options{
+			TTL:         appCfg.Cache.TTL,
+			NegativeTTL: appCfg.Cache.NegativeTTL,
+		}, tr)
+		productRepo = cachedRepo
+	}
+
+	verifier, err := newVerifier(appCfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize authentication: %v", err)
+	}
+
+	policy := application.NewProductPolicy()
+	if verifier == nil {
+		lg.Warn("Authentication is disabled, the API is open to anyone")
+		policy = auth.AllowAll()
+	}
+
+	auditRepo := postgres.NewAuditRepository(dbpool, tr)
+	translationRepo := postgres.NewTranslationRepository(dbpool, tr)
+	bundleRepo := postgres.NewBundleRepository(dbpool, tr)
+	locales := locale.NewResolver(locale.Config{
+		Param:   appCfg.Locale.Param,
+		Default: appCfg.Locale.Default,
+	})
+	productService := application.NewProductService(productRepo, auditRepo, translationRepo, bundleRepo, policy, tr)
+	categoryService := application.NewCategoryService(postgres.NewCategoryRepository(dbpool, tr), tr)
+	productHandler := api.NewProductHandler(productService, policy, lg, api.CacheControlConfig{
+		Product:     appCfg.Server.ProductCacheControl,
+		ProductList: appCfg.Server.ProductListCacheControl,
+	})
+	apiKeyService := application.NewAPIKeyService(postgres.NewAPIKeyRepository(dbpool, tr), policy, tr)
+	apiKeyHandler := api.NewAPIKeyHandler(apiKeyService, policy, lg)
+	translationService := application.NewTranslationService(translationRepo, productRepo, policy, locales.Default(), tr)
+	translationHandler := api.NewTranslationHandler(translationService, policy, lg)
+	relationService := application.NewRelationService(postgres.NewRelationRepository(dbpool, tr), productRepo, translationRepo, bundleRepo, policy, tr)
+	relationHandler := api.NewRelationHandler(relationService, policy, lg)
+	bundleService := application.NewBundleService(bundleRepo, productRepo, policy, tr)
+	bundleHandler := api.NewBundleHandler(bundleService, policy, lg)
+	promotionService := application.NewPromotionService(postgres.NewPromotionRepository(dbpool, tr), productRepo, bundleRepo, policy, tr)
+	promotionHandler := api.NewPromotionHandler(promotionService, policy, lg)
+	taxService := application.NewTaxService(postgres.NewTaxRepository(dbpool, tr), productRepo, bundleRepo, policy,
+		domain.PriceDisplay(appCfg.Tax.PriceDisplay), domain.TaxRounding(appCfg.Tax.Rounding), tr)
+	taxHandler := api.NewTaxHandler(taxService, policy, lg)
+
+	bus, err := newMessageBus(appCfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize message bus: %v", err)
+	}
+	defer bus.Close()
+
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	if appCfg.Outbox.Enabled {
+		relay := outbox.NewRelay(dbpool, outbox.NewBusPublisher(bus, appCfg.Messaging.TopicPrefix), outbox.RelayConfig{
+			PollInterval: appCfg.Outbox.PollInterval,
+			BatchSize:    appCfg.Outbox.BatchSize,
+			Retention:    appCfg.Outbox.Retention,
+			MaxAttempts:  appCfg.Outbox.MaxAttempts,
+		}, lg, tr)
+		go relay.Run(bgCtx)
+	}
+
+	listener := database.NewListener(database.ListenerConfig{
+		DSN:      appCfg.DB.GetDSN(),
+		Channels: []string{postgres.CatalogChangesChannel},
+	}, lg)
+	listener.Handle(postgres.ProductsTable, func(ctx context.Context, event database.ChangeEvent) {
+		lg.Debug("Product %s changed: %s", event.ID, event.Operation)
+		if cachedRepo != nil {
+			if err := cachedRepo.Invalidate(tenant.WithID(ctx, event.Tenant), event.ID); err != nil {
+				lg.Warn("Failed to invalidate cached product %s: %v", event.ID, err)
+			}
+		}
+	})
+	listener.Handle(postgres.CategoriesTable, func(ctx context.Context, event database.ChangeEvent) {
+		lg.Debug("Category %s changed: %s", event.ID, event.Operation)
+	})
+	listener.OnReconnect(func(ctx context.Context) {
+		// Changes made while disconnected were missed
+		if cachedRepo != nil {
+			if err := cachedRepo.InvalidateAll(ctx); err != nil {
+				lg.Warn("Failed to clear product cache: %v", err)
+			}
+		}
+	})
+	go listener.Run(bgCtx)
+
+	limiter, err := newRateLimiter(appCfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize rate limiting: %v", err)
+	}
+
+	tenants := newTenantResolver(appCfg)
+	grpcServer := grpcapi.NewServer(productService, verifier, apiKeyService, tenants, locales, limiter, appCfg.RateLimit.TrustForwarded, lg)
+
+	var graphqlHandler http.Handler
+	if appCfg.GraphQL.Enabled {
+		graphqlHandler = graphqlapi.NewHandler(productService, categoryService, graphqlapi.Options{
+			MaxDepth:      appCfg.GraphQL.MaxDepth,
+			MaxComplexity: appCfg.GraphQL.MaxComplexity,
+			Introspection: appCfg.GraphQL.Introspection,
+		}, lg)
+	}
+
+	var idempotencyStore idempotency.Store
+	if appCfg.Idempotency.Enabled {
+		store := idempotency.NewPostgresStore(dbpool, lg, tr)
+		go store.Run(bgCtx, appCfg.Idempotency.CleanupInterval)
+		idempotencyStore = store
+	}
+
+	runServer(appCfg, productHandler, apiKeyHandler, translationHandler, relationHandler, bundleHandler, promotionHandler, taxHandler, graphqlHandler, grpcServer, verifier, apiKeyService, tenants, locales, limiter, idempotencyStore, lg)
+}
+
+// newVerifier returns the verifier of bearer tokens, or nil when authentication is disabled
+func newVerifier(cfg *config.Config) (*auth.Verifier, error) {
+	if !cfg.Auth.Enabled {
+		return nil, nil
+	}
+
+	var keys auth.KeySet
+	switch {
+	case cfg.Auth.JWKSURL != "":
+		keys = auth.NewRemoteKeySet(cfg.Auth.JWKSURL, auth.RemoteKeySetOptions{
+			RefreshInterval: cfg.Auth.JWKSRefreshInterval,
+		})
+	case cfg.Auth.JWKSFile != "":
+		fileKeys, err := auth.NewFileKeySet(cfg.Auth.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = fileKeys
+	default:
+		keys = auth.NewHMACKeySet([]byte(cfg.Auth.HMACSecret))
+	}
+
+	verifierCfg := auth.VerifierConfig{
+		Issuer:     cfg.Auth.Issuer,
+		Audience:   cfg.Auth.Audience,
+		Algorithms: cfg.Auth.Algorithms,
+		Leeway:     cfg.Auth.Leeway,
+	}
+	if cfg.Tenancy.Enabled {
+		verifierCfg.TenantClaim = cfg.Tenancy.Claim
+	}
 
-	runServer(appCfg, productHandler, lg)
+	return auth.NewVerifier(keys, verifierCfg), nil
 }
 
-func runServer(cfg *config.Config, productHandler *api.ProductHandler, logger logger.Logger) {
+// newTenantResolver returns the resolver of the tenant of requests. With tenancy
+// disabled every request belongs to the default tenant, whatever its credentials.
+func newTenantResolver(cfg *config.Config) *tenant.Resolver {
+	if !cfg.Tenancy.Enabled {
+		return tenant.NewResolver(tenant.Config{Default: cfg.Tenancy.Default, AllowUnbound: true})
+	}
+
+	return tenant.NewResolver(tenant.Config{
+		Header:       cfg.Tenancy.Header,
+		BaseDomain:   cfg.Tenancy.BaseDomain,
+		Default:      cfg.Tenancy.Default,
+		AllowUnbound: cfg.Tenancy.AllowUnbound,
+	})
+}
+
+// newRateLimiter returns the rate limiter of the API, or nil when rate limiting is disabled
+func newRateLimiter(cfg *config.Config) (*ratelimit.Limiter, error) {
+	if !cfg.RateLimit.Enabled {
+		return nil, nil
+	}
+
+	defaultLimit, err := ratelimit.ParseLimit(cfg.RateLimit.Default)
+	if err != nil {
+		return nil, err
+	}
+
+	addressLimit, err := ratelimit.ParseLimit(cfg.RateLimit.PerAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := ratelimit.ParseRules(cfg.RateLimit.Routes)
+	if err != nil {
+		return nil, err
+	}
+
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Backend == config.RateLimitBackendRedis {
+		store = ratelimit.NewRedisStore(ratelimit.RedisConfig{
+			Addr:     cfg.RateLimit.RedisAddr,
+			Password: cfg.RateLimit.RedisPassword,
+			DB:       cfg.RateLimit.RedisDB,
+			Prefix:   cfg.Telemetry.ServiceName + ":ratelimit:",
+		})
+	}
+
+	return ratelimit.NewLimiter(store, defaultLimit, addressLimit, rules), nil
+}
+
+func newCache(cfg *config.Config) cache.Cache {
+	if cfg.Cache.Backend == config.CacheBackendRedis {
+		// Clearing the cache deletes its whole namespace, which must not hold the
+		// rate limit buckets sharing the database
+		return cache.NewRedisCache(cache.RedisConfig{
+			Addr:       cfg.Cache.RedisAddr,
+			Password:   cfg.Cache.RedisPassword,
+			DB:         cfg.Cache.RedisDB,
+			Prefix:     cfg.Telemetry.ServiceName + ":cache:",
+			DefaultTTL: cfg.Cache.TTL,
+		})
+	}
+
+	return cache.NewMemoryCache(cfg.Cache.MaxEntries, cfg.Cache.TTL)
+}
+
+func newMessageBus(cfg *config.Config) (messaging.Bus, error) {
+	if cfg.Messaging.Driver == config.MessagingDriverNATS {
+		return messaging.NewNATSBus(context.Background(), messaging.NATSConfig{
+			URL:      cfg.Messaging.NATSURL,
+			Name:     cfg.Telemetry.ServiceName,
+			Stream:   cfg.Messaging.NATSStream,
+			Subjects: []string{cfg.Messaging.TopicPrefix + ".>"},
+		})
+	}
+
+	return messaging.NewMemoryBus(), nil
+}
+
+func runServer(cfg *config.Config, productHandler *api.ProductHandler, apiKeyHandler *api.APIKeyHandler, translationHandler *api.TranslationHandler, relationHandler *api.RelationHandler, bundleHandler *api.BundleHandler, promotionHandler *api.PromotionHandler, taxHandler *api.TaxHandler, graphqlHandler http.Handler, grpcServer *grpcapi.Server, verifier *auth.Verifier, apiKeys auth.APIKeyAuthenticator, tenants *tenant.Resolver, locales *locale.Resolver, limiter *ratelimit.Limiter, idempotencyStore idempotency.Store, logger logger.Logger) {
 	shutdown := make(chan os.Signal, 1)
 	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
 
@@ -101,10 +363,50 @@ func runServer(cfg *config.Config, productHandler *api.ProductHandler, logger lo
 		httpSwagger.URL("/swagger/doc.json"), // The URL pointing to API definition
 	))
 
+	// Routes below identify callers by API key or, when enabled, bearer token, run
+	// in the tenant and locale of the request and are rate limited per address,
+	// counting failed authentications, and per caller
+	authenticated := func(r chi.Router) {
+		if limiter != nil {
+			r.Use(api.AddressRateLimit(limiter, cfg.RateLimit.TrustForwarded, logger))
+		}
+		r.Use(api.Authenticate(verifier, apiKeys, logger))
+		r.Use(api.Tenant(tenants, logger))
+		r.Use(api.Locale(locales, logger))
+		if limiter != nil {
+			r.Use(api.RateLimit(limiter, cfg.RateLimit.TrustForwarded, logger))
+		}
+		r.Use(api.Audit)
+	}
+
 	r.Route("/api", func(r chi.Router) {
 		r.Use(api.ContentTypeJson)
+		authenticated(r)
+		if idempotencyStore != nil {
+			r.Use(api.Idempotency(idempotencyStore, idempotency.Options{
+				TTL: cfg.Idempotency.TTL,
+				// A request holding a key is cut off by the server timeout
+				LockTimeout: time.Duration(cfg.Server.Timeout)*time.Second + 5*time.Second,
+			}, logger))
+		}
 		productHandler.RegisterRoutes(r)
+		apiKeyHandler.RegisterRoutes(r)
+		translationHandler.RegisterRoutes(r)
+		relationHandler.RegisterRoutes(r)
+		bundleHandler.RegisterRoutes(r)
+		promotionHandler.RegisterRoutes(r)
+		taxHandler.RegisterRoutes(r)
 	})
+
+	if graphqlHandler != nil {
+		r.Group(func(r chi.Router) {
+			authenticated(r)
+			r.Handle("/graphql", graphqlHandler)
+		})
+		if cfg.GraphQL.Playground {
+			r.Handle("/graphql/playground", graphqlapi.PlaygroundHandler("/graphql"))
+		}
+	}
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		// Get hostname to identify which container is responding
@@ -132,6 +434,18 @@ func runServer(cfg *config.Config, productHandler *api.ProductHandler, logger lo
 		}
 	}()
 
+	lis, err := net.Listen("tcp", cfg.Server.GetGRPCAddr())
+	if err != nil {
+		logger.Fatal("Failed to listen for gRPC: %v", err)
+	}
+
+	go func() {
+		logger.Info("gRPC server started on localhost" + cfg.Server.GetGRPCAddr())
+		if err := grpcServer.Serve(lis); err != nil {
+			logger.Fatal("Failed to start gRPC server: %v", err)
+		}
+	}()
+
 	<-shutdown
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -139,7 +453,9 @@ func runServer(cfg *config.Config, productHandler *api.ProductHandler, logger lo
 
 	logger.Info("Shutting down server...")
 
-	err := server.Shutdown(ctx)
+	grpcServer.Shutdown(ctx)
+
+	err = server.Shutdown(ctx)
 	if err != nil {
 		logger.Fatal("Failed to shutdown server: %v", err)
 	}
@@ -0,0 +1,148 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"microservice/pkg/auth"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/interfaces"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// lastUsedResolution limits how often the last-used time of a key is written, so a
+// busy client does not cost a write per request
+const lastUsedResolution = time.Minute
+
+type APIKeyService struct {
+	repo   interfaces.APIKeyRepository
+	policy *auth.Policy
+	tracer trace.Tracer
+}
+
+func NewAPIKeyService(repo interfaces.APIKeyRepository, policy *auth.Policy, tracer trace.Tracer) *APIKeyService {
+	return &APIKeyService{
+		repo:   repo,
+		policy: policy,
+		tracer: tracer,
+	}
+}
+
+// Issue creates a key with the given scopes, recording the caller as its creator.
+// The returned secret is not stored and cannot be retrieved again.
+func (s *APIKeyService) Issue(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*domain.APIKey, string, error) {
+	ctx, span := s.tracer.Start(ctx, "APIKeyService.Issue")
+	defer span.End()
+
+	if err := s.policy.Authorize(ctx, domain.PermissionManageAPIKeys); err != nil {
+		span.RecordError(err)
+		return nil, "", err
+	}
+
+	for _, scope := range scopes {
+		if !domain.ValidAPIKeyScope(scope) {
+			return nil, "", domain.ErrInvalidScope
+		}
+	}
+
+	secret, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, "", err
+	}
+
+	key := &domain.APIKey{
+		ID:        uuid.New(),
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hash,
+		Scopes:    scopes,
+		CreatedBy: domain.AuditContextFromContext(ctx).Actor,
+		ExpiresAt: expiresAt,
+	}
+
+	span.SetAttributes(attribute.String("api_key.id", key.ID.String()))
+
+	if err := s.repo.Create(ctx, key); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, "", err
+	}
+
+	return key, secret, nil
+}
+
+// List returns a page of keys, newest first
+func (s *APIKeyService) List(ctx context.Context, limit, offset int) ([]*domain.APIKey, int, error) {
+	if err := s.policy.Authorize(ctx, domain.PermissionManageAPIKeys); err != nil {
+		return nil, 0, err
+	}
+
+	return s.repo.List(ctx, limit, offset)
+}
+
+// Revoke stops the key from authenticating any further request
+func (s *APIKeyService) Revoke(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	if err := s.policy.Authorize(ctx, domain.PermissionManageAPIKeys); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Revoke(ctx, id)
+}
+
+// AuthenticateAPIKey returns the principal of an active key. Unknown, revoked and
+// expired keys all fail with auth.ErrInvalidAPIKey.
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, secret string) (*auth.Principal, error) {
+	ctx, span := s.tracer.Start(ctx, "APIKeyService.AuthenticateAPIKey")
+	defer span.End()
+
+	prefix, err := auth.ParseAPIKey(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.repo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, domain.ErrAPIKeyNotFound) {
+			return nil, auth.ErrInvalidAPIKey
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	now := time.Now()
+	if !auth.APIKeyMatches(secret, key.Hash) || !key.Active(now) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	span.SetAttributes(attribute.String("api_key.id", key.ID.String()))
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedResolution {
+		// The request is authenticated either way; a failed write only leaves the time stale
+		if err := s.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			span.RecordError(err)
+		}
+	}
+
+	principal := &auth.Principal{
+		Subject: key.Subject(),
+		Tenant:  key.TenantID,
+		Scopes:  key.Scopes,
+		Claims: map[string]any{
+			"api_key_id":   key.ID.String(),
+			"api_key_name": key.Name,
+		},
+	}
+	if key.ExpiresAt != nil {
+		principal.ExpiresAt = *key.ExpiresAt
+	}
+
+	return principal, nil
+}
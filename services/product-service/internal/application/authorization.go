@@ -0,0 +1,34 @@
+package application
+
+import (
+	"microservice/pkg/auth"
+	"microservice/services/product-service/internal/domain"
+)
+
+// NewProductPolicy returns the policy of the product catalog: catalog editors may
+// create, update and review unpublished products and manage promotions, only admins
+// may delete products or manage API keys and taxes, and the public may read published
+// products and price carts only
+func NewProductPolicy() *auth.Policy {
+	editors := auth.Rule{
+		Roles:  []string{domain.RoleCatalogEditor, domain.RoleAdmin},
+		Scopes: []string{domain.ScopeProductsWrite, domain.ScopeProductsAdmin},
+	}
+	admins := auth.Rule{
+		Roles:  []string{domain.RoleAdmin},
+		Scopes: []string{domain.ScopeProductsAdmin},
+	}
+
+	return auth.NewPolicy(map[string]auth.Rule{
+		domain.PermissionCreateProduct:    editors,
+		domain.PermissionUpdateProduct:    editors,
+		domain.PermissionReadUnpublished:  editors,
+		domain.PermissionReadHistory:      editors,
+		domain.PermissionManagePromotions: editors,
+		domain.PermissionRedeemPromotions: editors,
+		domain.PermissionDeleteProduct:    admins,
+		domain.PermissionManageTax:        admins,
+		// API keys cannot issue keys themselves, so this is granted by role only
+		domain.PermissionManageAPIKeys: {Roles: []string{domain.RoleAdmin}},
+	})
+}
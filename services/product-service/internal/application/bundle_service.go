@@ -0,0 +1,118 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"microservice/pkg/auth"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/interfaces"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BundleService manages the components of bundle products
+type BundleService struct {
+	repo     interfaces.BundleRepository
+	products interfaces.ProductRepository
+	policy   *auth.Policy
+	tracer   trace.Tracer
+}
+
+func NewBundleService(repo interfaces.BundleRepository, products interfaces.ProductRepository, policy *auth.Policy, tracer trace.Tracer) *BundleService {
+	return &BundleService{
+		repo:     repo,
+		products: products,
+		policy:   policy,
+		tracer:   tracer,
+	}
+}
+
+// Get returns the bundle of a bundle product with the price and availability of its
+// components
+func (s *BundleService) Get(ctx context.Context, productID uuid.UUID) (*domain.Bundle, error) {
+	ctx, span := s.tracer.Start(ctx, "BundleService.Get")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()))
+
+	product, err := visibleProduct(ctx, s.products, s.policy, productID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	if product.Type != domain.TypeBundle {
+		return nil, domain.ErrBundleNotFound
+	}
+
+	bundle, err := s.repo.Get(ctx, productID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+// Save creates or replaces the bundle of a bundle product and loads back the price
+// and availability of its components. Components must be simple products that are
+// not archived.
+func (s *BundleService) Save(ctx context.Context, bundle *domain.Bundle) error {
+	ctx, span := s.tracer.Start(ctx, "BundleService.Save")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", bundle.ProductID.String()))
+
+	if err := s.policy.Authorize(ctx, domain.PermissionUpdateProduct); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if err := bundle.Validate(); err != nil {
+		return err
+	}
+
+	product, err := s.products.GetByID(ctx, bundle.ProductID)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if product.Type != domain.TypeBundle {
+		return domain.ErrInvalidBundle
+	}
+
+	for _, c := range bundle.Components {
+		component, err := s.products.GetByID(ctx, c.ProductID)
+		if errors.Is(err, domain.ErrProductNotFound) {
+			return domain.ErrInvalidBundle
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+
+		// Types never change, so a component cannot become a bundle afterwards
+		if component.Type != domain.TypeSimple || component.Status == domain.StatusArchived {
+			return domain.ErrInvalidBundle
+		}
+	}
+
+	if err := s.repo.Save(ctx, bundle); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	saved, err := s.repo.Get(ctx, bundle.ProductID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	*bundle = *saved
+
+	return nil
+}
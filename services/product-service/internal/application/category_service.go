@@ -0,0 +1,49 @@
+package application
+
+import (
+	"context"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/interfaces"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type CategoryService struct {
+	repo   interfaces.CategoryRepository
+	tracer trace.Tracer
+}
+
+func NewCategoryService(repo interfaces.CategoryRepository, tracer trace.Tracer) *CategoryService {
+	return &CategoryService{
+		repo:   repo,
+		tracer: tracer,
+	}
+}
+
+func (s *CategoryService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	ctx, span := s.tracer.Start(ctx, "CategoryService.GetByID")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("category.id", id.String()))
+
+	category, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// GetByIDs returns the categories with the given IDs; unknown IDs are skipped
+func (s *CategoryService) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Category, error) {
+	return s.repo.GetByIDs(ctx, ids)
+}
+
+func (s *CategoryService) GetAll(ctx context.Context, limit, offset int) ([]*domain.Category, int, error) {
+	return s.repo.GetAll(ctx, limit, offset)
+}
@@ -2,8 +2,12 @@ package application
 
 import (
 	"context"
+	"microservice/pkg/auth"
+	"microservice/pkg/locale"
 	"microservice/services/product-service/internal/domain"
 	"microservice/services/product-service/internal/interfaces"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/attribute"
@@ -12,14 +16,22 @@ import (
 )
 
 type ProductService struct {
-	repo   interfaces.ProductRepository
-	tracer trace.Tracer
+	repo         interfaces.ProductRepository
+	auditRepo    interfaces.AuditRepository
+	translations interfaces.TranslationRepository
+	bundles      interfaces.BundleRepository
+	policy       *auth.Policy
+	tracer       trace.Tracer
 }
 
-func NewProductService(repo interfaces.ProductRepository, tracer trace.Tracer) *ProductService {
+func NewProductService(repo interfaces.ProductRepository, auditRepo interfaces.AuditRepository, translations interfaces.TranslationRepository, bundles interfaces.BundleRepository, policy *auth.Policy, tracer trace.Tracer) *ProductService {
 	return &ProductService{
-		repo:   repo,
-		tracer: tracer,
+		repo:         repo,
+		auditRepo:    auditRepo,
+		translations: translations,
+		bundles:      bundles,
+		policy:       policy,
+		tracer:       tracer,
 	}
 }
 
@@ -42,41 +54,329 @@ func (s *ProductService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Pro
 		return nil, err
 	}
 
+	// Unpublished products do not exist for callers who may not see them
+	if !product.IsPublished() && !s.policy.Allows(ctx, domain.PermissionReadUnpublished) {
+		return nil, domain.ErrProductNotFound
+	}
+
+	if err := s.complete(ctx, product); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
 	return product, nil
 }
 
+// GetBySlug returns the product having or having had slug, compared ignoring case
+func (s *ProductService) GetBySlug(ctx context.Context, slug string) (*domain.Product, error) {
+	ctx, span := s.tracer.Start(ctx, "ProductService.GetBySlug")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.slug", slug))
+
+	id, err := s.repo.ResolveSlug(ctx, strings.ToLower(slug))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// Loaded by ID to share the product cache
+	return s.GetByID(ctx, id)
+}
+
 func (s *ProductService) CategoryExists(ctx context.Context, id uuid.UUID) (bool, error) {
 	return s.repo.CategoryExists(ctx, id)
 }
 
 func (s *ProductService) GetAll(ctx context.Context, limit, offset int) ([]*domain.Product, int, error) {
-	return s.repo.GetAll(ctx, limit, offset)
+	products, total, err := s.repo.GetAll(ctx, s.visibleTo(ctx), limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := s.complete(ctx, products...); err != nil {
+		return nil, 0, err
+	}
+
+	return products, total, nil
 }
 
+// Create adds the product to the catalog, published unless another status is given
 func (s *ProductService) Create(ctx context.Context, product *domain.Product) error {
+	if err := s.policy.Authorize(ctx, domain.PermissionCreateProduct); err != nil {
+		return err
+	}
+
 	if product.Name == "" {
 		return domain.ErrInvalidProduct
 	}
 
+	if product.Status == "" {
+		product.Status = domain.StatusPublished
+	}
+	if !product.Status.Valid() {
+		return domain.ErrInvalidStatus
+	}
+
+	if product.Type == "" {
+		product.Type = domain.TypeSimple
+	}
+	if !product.Type.Valid() {
+		return domain.ErrInvalidType
+	}
+
 	if product.ID == uuid.Nil {
 		product.ID = uuid.New()
 	}
 
+	product.MarkCreated()
+
 	return s.repo.Create(ctx, product)
 }
 
+// Update applies the details, price and, if set, status of product to the stored
+// product, raising the matching domain events, and copies the saved state back into
+// product. The type of a product cannot change.
 func (s *ProductService) Update(ctx context.Context, product *domain.Product) error {
-	return s.repo.Update(ctx, product)
+	ctx, span := s.tracer.Start(ctx, "ProductService.Update")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", product.ID.String()))
+
+	if err := s.policy.Authorize(ctx, domain.PermissionUpdateProduct); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	// The changes apply to the stored product as locked by the update, so the events
+	// describe what actually changed
+	updated, err := s.repo.Update(ctx, product.ID, func(current *domain.Product) error {
+		if product.Type != "" && product.Type != current.Type {
+			return domain.ErrInvalidType
+		}
+
+		if err := current.UpdateDetails(product.Name, product.Description, product.SKU, product.CategoryID); err != nil {
+			return err
+		}
+
+		if err := current.UpdatePrice(product.Price); err != nil {
+			return err
+		}
+
+		if product.Status != "" {
+			return current.ChangeStatus(product.Status)
+		}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	*product = *updated
+
+	if err := composeBundles(ctx, s.bundles, product); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	return nil
 }
 
 func (s *ProductService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.policy.Authorize(ctx, domain.PermissionDeleteProduct); err != nil {
+		return err
+	}
+
 	return s.repo.Delete(ctx, id)
 }
 
 func (s *ProductService) GetByCategory(ctx context.Context, categoryID uuid.UUID) ([]*domain.Product, error) {
-	return s.repo.GetByCategory(ctx, categoryID)
+	products, err := s.repo.GetByCategory(ctx, categoryID, s.visibleTo(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.complete(ctx, products...); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// GetByCategoryIDs returns the products of several categories at once
+func (s *ProductService) GetByCategoryIDs(ctx context.Context, categoryIDs []uuid.UUID) ([]*domain.Product, error) {
+	products, err := s.repo.GetByCategoryIDs(ctx, categoryIDs, s.visibleTo(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.complete(ctx, products...); err != nil {
+		return nil, err
+	}
+
+	return products, nil
 }
 
+// Search matches query against the untranslated content of the products and their
+// translations in the locales of ctx
 func (s *ProductService) Search(ctx context.Context, query string) ([]*domain.Product, error) {
-	return s.repo.Search(ctx, query)
+	products, err := s.repo.Search(ctx, query, translationLocales(ctx), s.visibleTo(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.complete(ctx, products...); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// complete attaches their bundle to bundle products and translates products into the
+// locales of ctx
+func (s *ProductService) complete(ctx context.Context, products ...*domain.Product) error {
+	return completeProducts(ctx, s.bundles, s.translations, products...)
+}
+
+// completeProducts is complete for the services loading products themselves
+func completeProducts(ctx context.Context, bundles interfaces.BundleRepository, translations interfaces.TranslationRepository, products ...*domain.Product) error {
+	if err := composeBundles(ctx, bundles, products...); err != nil {
+		return err
+	}
+	return translateProducts(ctx, translations, products...)
+}
+
+// composeBundles attaches their bundle to the bundle products, deriving their price
+// and availability from their components
+func composeBundles(ctx context.Context, bundles interfaces.BundleRepository, products ...*domain.Product) error {
+	var ids []uuid.UUID
+	for _, p := range products {
+		if p.Type == domain.TypeBundle {
+			ids = append(ids, p.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	found, err := bundles.FindByProducts(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range products {
+		if b, ok := found[p.ID]; ok {
+			p.SetBundle(b)
+		}
+	}
+
+	return nil
+}
+
+// translateProducts replaces the name and description of products with their
+// translation in the first locale of ctx they have one in
+func translateProducts(ctx context.Context, translations interfaces.TranslationRepository, products ...*domain.Product) error {
+	locales := translationLocales(ctx)
+	if len(locales) == 0 || len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+
+	found, err := translations.FindBest(ctx, ids, locales)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range products {
+		if t, ok := found[p.ID]; ok {
+			p.Translate(t)
+		}
+	}
+
+	return nil
+}
+
+// translationLocales returns the locales of ctx products are translated into. The
+// chain ends with the default locale, that of the untranslated content.
+func translationLocales(ctx context.Context) []string {
+	chain, ok := locale.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return chain[:len(chain)-1]
+}
+
+// visibleTo returns the filter limiting lists to the products the caller may see
+func (s *ProductService) visibleTo(ctx context.Context) domain.ProductFilter {
+	return visibilityFilter(ctx, s.policy)
+}
+
+// visibilityFilter is visibleTo for the services loading products themselves
+func visibilityFilter(ctx context.Context, policy *auth.Policy) domain.ProductFilter {
+	if policy.Allows(ctx, domain.PermissionReadUnpublished) {
+		return domain.ProductFilter{}
+	}
+	return domain.ProductFilter{Statuses: []domain.ProductStatus{domain.StatusPublished}}
+}
+
+// GetHistory returns a page of the product's audit trail, newest first
+func (s *ProductService) GetHistory(ctx context.Context, id uuid.UUID, limit, offset int) ([]*domain.AuditEntry, int, error) {
+	ctx, span := s.tracer.Start(ctx, "ProductService.GetHistory")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", id.String()))
+
+	if err := s.policy.Authorize(ctx, domain.PermissionReadHistory); err != nil {
+		span.RecordError(err)
+		return nil, 0, err
+	}
+
+	entries, total, err := s.auditRepo.ListByProduct(ctx, id, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// GetAsOf reconstructs the product as it was at the given point in time
+func (s *ProductService) GetAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*domain.Product, error) {
+	ctx, span := s.tracer.Start(ctx, "ProductService.GetAsOf")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("product.id", id.String()),
+		attribute.String("audit.as_of", asOf.Format(time.RFC3339)),
+	)
+
+	if err := s.policy.Authorize(ctx, domain.PermissionReadHistory); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	entries, err := s.auditRepo.ListUntil(ctx, id, asOf)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	product, err := domain.ReplayAuditEntries(entries)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return product, nil
 }
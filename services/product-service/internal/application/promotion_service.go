@@ -0,0 +1,197 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"microservice/pkg/auth"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/interfaces"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PromotionService manages promotions and prices carts with them
+type PromotionService struct {
+	repo     interfaces.PromotionRepository
+	products interfaces.ProductRepository
+	bundles  interfaces.BundleRepository
+	policy   *auth.Policy
+	tracer   trace.Tracer
+}
+
+func NewPromotionService(repo interfaces.PromotionRepository, products interfaces.ProductRepository, bundles interfaces.BundleRepository, policy *auth.Policy, tracer trace.Tracer) *PromotionService {
+	return &PromotionService{
+		repo:     repo,
+		products: products,
+		bundles:  bundles,
+		policy:   policy,
+		tracer:   tracer,
+	}
+}
+
+// List returns a page of promotions, oldest first
+func (s *PromotionService) List(ctx context.Context, limit, offset int) ([]*domain.Promotion, int, error) {
+	if err := s.policy.Authorize(ctx, domain.PermissionManagePromotions); err != nil {
+		return nil, 0, err
+	}
+
+	return s.repo.List(ctx, limit, offset)
+}
+
+func (s *PromotionService) Get(ctx context.Context, id uuid.UUID) (*domain.Promotion, error) {
+	if err := s.policy.Authorize(ctx, domain.PermissionManagePromotions); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *PromotionService) Create(ctx context.Context, promotion *domain.Promotion) error {
+	ctx, span := s.tracer.Start(ctx, "PromotionService.Create")
+	defer span.End()
+
+	if err := s.policy.Authorize(ctx, domain.PermissionManagePromotions); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	promotion.ID = uuid.New()
+	promotion.CouponCode = domain.NormalizeCoupon(promotion.CouponCode)
+	if err := promotion.Validate(); err != nil {
+		return err
+	}
+
+	span.SetAttributes(attribute.String("promotion.id", promotion.ID.String()))
+
+	if err := s.repo.Create(ctx, promotion); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// Update replaces the rules of a promotion. Its usage count is kept.
+func (s *PromotionService) Update(ctx context.Context, promotion *domain.Promotion) error {
+	ctx, span := s.tracer.Start(ctx, "PromotionService.Update")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("promotion.id", promotion.ID.String()))
+
+	if err := s.policy.Authorize(ctx, domain.PermissionManagePromotions); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	promotion.CouponCode = domain.NormalizeCoupon(promotion.CouponCode)
+	if err := promotion.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(ctx, promotion); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *PromotionService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.policy.Authorize(ctx, domain.PermissionManagePromotions); err != nil {
+		return err
+	}
+
+	return s.repo.Delete(ctx, id)
+}
+
+// Evaluate prices the items at the current price of their product and applies the
+// promotions running now. Every product must be available. With redeem, a use of
+// each applied promotion is counted, which fails with domain.ErrCouponExhausted if
+// one of them reached its usage limit meanwhile.
+func (s *PromotionService) Evaluate(ctx context.Context, items []domain.CartItem, coupons []string, redeem bool) (*domain.Quote, error) {
+	ctx, span := s.tracer.Start(ctx, "PromotionService.Evaluate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("cart.items", len(items)),
+		attribute.Int("cart.coupons", len(coupons)),
+		attribute.Bool("cart.redeem", redeem),
+	)
+
+	if redeem {
+		if err := s.policy.Authorize(ctx, domain.PermissionRedeemPromotions); err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+	}
+
+	products := make([]*domain.Product, len(items))
+	for i, item := range items {
+		product, err := visibleProduct(ctx, s.products, s.policy, item.ProductID)
+		if errors.Is(err, domain.ErrProductNotFound) {
+			return nil, fmt.Errorf("%w: product %s not found", domain.ErrInvalidCart, item.ProductID)
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+		products[i] = product
+	}
+
+	// Bundles priced at a discount take their price from their components
+	if err := composeBundles(ctx, s.bundles, products...); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	cart := domain.Cart{Lines: make([]domain.CartLine, len(items)), Coupons: coupons}
+	for i, product := range products {
+		if !product.IsAvailable() {
+			return nil, fmt.Errorf("%w: product %s is not available", domain.ErrInvalidCart, product.ID)
+		}
+
+		cart.Lines[i] = domain.CartLine{
+			ProductID:  product.ID,
+			SKU:        product.SKU,
+			CategoryID: product.CategoryID,
+			UnitPrice:  product.Price,
+			Quantity:   items[i].Quantity,
+		}
+	}
+
+	normalized := make([]string, len(coupons))
+	for i, code := range coupons {
+		normalized[i] = domain.NormalizeCoupon(code)
+	}
+
+	now := time.Now()
+	promotions, err := s.repo.FindRunning(ctx, normalized, now)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	quote := domain.Evaluate(cart, promotions, now)
+
+	if redeem && len(quote.Applied) > 0 {
+		ids := make([]uuid.UUID, len(quote.Applied))
+		for i, p := range quote.Applied {
+			ids[i] = p.ID
+		}
+
+		if err := s.repo.Redeem(ctx, ids); err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+	}
+
+	return quote, nil
+}
@@ -0,0 +1,200 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"microservice/pkg/auth"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/interfaces"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RelationService manages the curated lists of related products of products
+type RelationService struct {
+	repo         interfaces.RelationRepository
+	products     interfaces.ProductRepository
+	translations interfaces.TranslationRepository
+	bundles      interfaces.BundleRepository
+	policy       *auth.Policy
+	tracer       trace.Tracer
+}
+
+func NewRelationService(repo interfaces.RelationRepository, products interfaces.ProductRepository, translations interfaces.TranslationRepository, bundles interfaces.BundleRepository, policy *auth.Policy, tracer trace.Tracer) *RelationService {
+	return &RelationService{
+		repo:         repo,
+		products:     products,
+		translations: translations,
+		bundles:      bundles,
+		policy:       policy,
+		tracer:       tracer,
+	}
+}
+
+// List returns the relations of a product of relType, or of any type if empty, in list
+// order with their translated related product. Relations to products the caller may
+// not see are left out.
+func (s *RelationService) List(ctx context.Context, productID uuid.UUID, relType domain.RelationType) ([]*domain.ProductRelation, error) {
+	ctx, span := s.tracer.Start(ctx, "RelationService.List")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()), attribute.String("relation.type", string(relType)))
+
+	if relType != "" && !relType.Valid() {
+		return nil, domain.ErrInvalidRelation
+	}
+
+	if _, err := visibleProduct(ctx, s.products, s.policy, productID); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	relations, err := s.repo.List(ctx, productID, relType, visibilityFilter(ctx, s.policy))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	related := make([]*domain.Product, len(relations))
+	for i, rel := range relations {
+		related[i] = rel.Related
+	}
+	if err := completeProducts(ctx, s.bundles, s.translations, related...); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return relations, nil
+}
+
+// Get returns a relation of a product with its translated related product
+func (s *RelationService) Get(ctx context.Context, productID uuid.UUID, relType domain.RelationType, relatedID uuid.UUID) (*domain.ProductRelation, error) {
+	ctx, span := s.tracer.Start(ctx, "RelationService.Get")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()), attribute.String("relation.type", string(relType)))
+
+	if _, err := visibleProduct(ctx, s.products, s.policy, productID); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	relation, err := s.repo.Get(ctx, productID, relType, relatedID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// Relations to products the caller may not see do not exist for them
+	relation.Related, err = visibleProduct(ctx, s.products, s.policy, relatedID)
+	if errors.Is(err, domain.ErrProductNotFound) {
+		return nil, domain.ErrRelationNotFound
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	if err := completeProducts(ctx, s.bundles, s.translations, relation.Related); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return relation, nil
+}
+
+// Create adds a relation between two products. Archived products cannot be related.
+func (s *RelationService) Create(ctx context.Context, relation *domain.ProductRelation) error {
+	ctx, span := s.tracer.Start(ctx, "RelationService.Create")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", relation.ProductID.String()), attribute.String("relation.type", string(relation.Type)))
+
+	if err := s.policy.Authorize(ctx, domain.PermissionUpdateProduct); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if err := relation.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.products.GetByID(ctx, relation.ProductID); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	related, err := s.products.GetByID(ctx, relation.RelatedID)
+	if errors.Is(err, domain.ErrProductNotFound) {
+		return domain.ErrInvalidRelation
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	if related.Status == domain.StatusArchived {
+		return domain.ErrInvalidRelation
+	}
+
+	if err := s.repo.Create(ctx, relation); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// Update moves a relation within its list and makes it bidirectional or not
+func (s *RelationService) Update(ctx context.Context, relation *domain.ProductRelation) error {
+	ctx, span := s.tracer.Start(ctx, "RelationService.Update")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", relation.ProductID.String()), attribute.String("relation.type", string(relation.Type)))
+
+	if err := s.policy.Authorize(ctx, domain.PermissionUpdateProduct); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if err := relation.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(ctx, relation); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *RelationService) Delete(ctx context.Context, productID uuid.UUID, relType domain.RelationType, relatedID uuid.UUID) error {
+	if err := s.policy.Authorize(ctx, domain.PermissionUpdateProduct); err != nil {
+		return err
+	}
+
+	return s.repo.Delete(ctx, productID, relType, relatedID)
+}
+
+// visibleProduct returns the product, or ErrProductNotFound unless it exists and the
+// caller may see it
+func visibleProduct(ctx context.Context, products interfaces.ProductRepository, policy *auth.Policy, id uuid.UUID) (*domain.Product, error) {
+	product, err := products.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !product.IsPublished() && !policy.Allows(ctx, domain.PermissionReadUnpublished) {
+		return nil, domain.ErrProductNotFound
+	}
+
+	return product, nil
+}
@@ -0,0 +1,247 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"microservice/pkg/auth"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/interfaces"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TaxService manages tax rates and classes and taxes carts with them. Prices are
+// stored without tax.
+type TaxService struct {
+	repo     interfaces.TaxRepository
+	products interfaces.ProductRepository
+	bundles  interfaces.BundleRepository
+	policy   *auth.Policy
+	// display and rounding are the modes of calculations asking for none
+	display  domain.PriceDisplay
+	rounding domain.TaxRounding
+	tracer   trace.Tracer
+}
+
+func NewTaxService(repo interfaces.TaxRepository, products interfaces.ProductRepository, bundles interfaces.BundleRepository, policy *auth.Policy, display domain.PriceDisplay, rounding domain.TaxRounding, tracer trace.Tracer) *TaxService {
+	return &TaxService{
+		repo:     repo,
+		products: products,
+		bundles:  bundles,
+		policy:   policy,
+		display:  display,
+		rounding: rounding,
+		tracer:   tracer,
+	}
+}
+
+func (s *TaxService) ListRates(ctx context.Context) ([]domain.TaxRate, error) {
+	if err := s.policy.Authorize(ctx, domain.PermissionManageTax); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListRates(ctx)
+}
+
+// ReplaceRates replaces the whole rate table. Rates of a class and place must not
+// overlap.
+func (s *TaxService) ReplaceRates(ctx context.Context, rates []domain.TaxRate) error {
+	ctx, span := s.tracer.Start(ctx, "TaxService.ReplaceRates")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("tax.rates", len(rates)))
+
+	if err := s.policy.Authorize(ctx, domain.PermissionManageTax); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if err := domain.ValidateTaxRates(rates); err != nil {
+		return err
+	}
+	domain.SortTaxRates(rates)
+
+	if err := s.repo.ReplaceRates(ctx, rates); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// ProductTaxClass returns the tax class of a product: its own, else the one of its
+// category, else domain.TaxClassStandard
+func (s *TaxService) ProductTaxClass(ctx context.Context, productID uuid.UUID) (string, domain.TaxClassSource, error) {
+	ctx, span := s.tracer.Start(ctx, "TaxService.ProductTaxClass")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()))
+
+	product, err := visibleProduct(ctx, s.products, s.policy, productID)
+	if err != nil {
+		span.RecordError(err)
+		return "", "", err
+	}
+
+	products, categories, err := s.repo.TaxClasses(ctx, []uuid.UUID{product.ID}, []uuid.UUID{product.CategoryID})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return "", "", err
+	}
+
+	class, source := domain.ResolveTaxClass(products[product.ID], categories[product.CategoryID])
+	return class, source, nil
+}
+
+// SetProductTaxClass assigns a tax class to a product, overriding the one of its
+// category. An empty class removes the assignment.
+func (s *TaxService) SetProductTaxClass(ctx context.Context, productID uuid.UUID, class string) error {
+	ctx, span := s.tracer.Start(ctx, "TaxService.SetProductTaxClass")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()))
+
+	if err := s.policy.Authorize(ctx, domain.PermissionManageTax); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if class != "" && !domain.ValidTaxClass(class) {
+		return domain.ErrInvalidTaxClass
+	}
+
+	if _, err := s.products.GetByID(ctx, productID); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if err := s.repo.SetProductTaxClass(ctx, productID, class); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// SetCategoryTaxClass assigns a tax class to the products of a category having none
+// of their own. An empty class removes the assignment.
+func (s *TaxService) SetCategoryTaxClass(ctx context.Context, categoryID uuid.UUID, class string) error {
+	ctx, span := s.tracer.Start(ctx, "TaxService.SetCategoryTaxClass")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("category.id", categoryID.String()))
+
+	if err := s.policy.Authorize(ctx, domain.PermissionManageTax); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if class != "" && !domain.ValidTaxClass(class) {
+		return domain.ErrInvalidTaxClass
+	}
+
+	exists, err := s.products.CategoryExists(ctx, categoryID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	if !exists {
+		return domain.ErrCategoryNotFound
+	}
+
+	if err := s.repo.SetCategoryTaxClass(ctx, categoryID, class); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	return nil
+}
+
+// Calculate taxes the items at the current price of their product, at the rates
+// applying at destination on the given date, today if nil
+func (s *TaxService) Calculate(ctx context.Context, items []domain.CartItem, destination domain.TaxDestination, at *time.Time, display domain.PriceDisplay, rounding domain.TaxRounding) (*domain.TaxCalculation, error) {
+	ctx, span := s.tracer.Start(ctx, "TaxService.Calculate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("cart.items", len(items)),
+		attribute.String("tax.destination", destination.String()),
+	)
+
+	if display == "" {
+		display = s.display
+	}
+	if rounding == "" {
+		rounding = s.rounding
+	}
+	if err := destination.Validate(); err != nil {
+		return nil, err
+	}
+	if !display.Valid() || !rounding.Valid() {
+		return nil, domain.ErrInvalidTaxRequest
+	}
+
+	date := time.Now()
+	if at != nil {
+		date = *at
+	}
+
+	products := make([]*domain.Product, len(items))
+	productIDs := make([]uuid.UUID, len(items))
+	categoryIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		product, err := visibleProduct(ctx, s.products, s.policy, item.ProductID)
+		if errors.Is(err, domain.ErrProductNotFound) {
+			return nil, fmt.Errorf("%w: product %s not found", domain.ErrInvalidCart, item.ProductID)
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+		products[i] = product
+		productIDs[i] = product.ID
+		categoryIDs[i] = product.CategoryID
+	}
+
+	// Bundles priced at a discount take their price from their components
+	if err := composeBundles(ctx, s.bundles, products...); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	productClasses, categoryClasses, err := s.repo.TaxClasses(ctx, productIDs, categoryIDs)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	rates, err := s.repo.FindRates(ctx, destination.Country)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	lines := make([]domain.TaxableLine, len(items))
+	for i, product := range products {
+		class, _ := domain.ResolveTaxClass(productClasses[product.ID], categoryClasses[product.CategoryID])
+		lines[i] = domain.TaxableLine{
+			ProductID: product.ID,
+			Class:     class,
+			UnitPrice: product.Price,
+			Quantity:  items[i].Quantity,
+		}
+	}
+
+	return domain.CalculateTax(lines, rates, destination, date, display, rounding)
+}
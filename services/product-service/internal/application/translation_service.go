@@ -0,0 +1,139 @@
+package application
+
+import (
+	"context"
+	"microservice/pkg/auth"
+	"microservice/pkg/locale"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/interfaces"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TranslationService manages the translations of the name and description of products
+type TranslationService struct {
+	repo     interfaces.TranslationRepository
+	products interfaces.ProductRepository
+	policy   *auth.Policy
+	// defaultLocale is the locale of the untranslated content of products
+	defaultLocale string
+	tracer        trace.Tracer
+}
+
+func NewTranslationService(repo interfaces.TranslationRepository, products interfaces.ProductRepository, policy *auth.Policy, defaultLocale string, tracer trace.Tracer) *TranslationService {
+	return &TranslationService{
+		repo:          repo,
+		products:      products,
+		policy:        policy,
+		defaultLocale: defaultLocale,
+		tracer:        tracer,
+	}
+}
+
+// List returns the translations of a product the caller may see
+func (s *TranslationService) List(ctx context.Context, productID uuid.UUID) ([]*domain.ProductTranslation, error) {
+	ctx, span := s.tracer.Start(ctx, "TranslationService.List")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()))
+
+	if err := s.checkVisible(ctx, productID); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	translations, err := s.repo.ListByProduct(ctx, productID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return translations, nil
+}
+
+// Get returns the translation of a product the caller may see in a locale
+func (s *TranslationService) Get(ctx context.Context, productID uuid.UUID, tag string) (*domain.ProductTranslation, error) {
+	ctx, span := s.tracer.Start(ctx, "TranslationService.Get")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()), attribute.String("translation.locale", tag))
+
+	canonical, err := locale.Canonical(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkVisible(ctx, productID); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return s.repo.Get(ctx, productID, canonical)
+}
+
+// Save creates or replaces the translation of a product and reports whether it was
+// created. Its locale is canonicalized.
+func (s *TranslationService) Save(ctx context.Context, translation *domain.ProductTranslation) (bool, error) {
+	ctx, span := s.tracer.Start(ctx, "TranslationService.Save")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", translation.ProductID.String()), attribute.String("translation.locale", translation.Locale))
+
+	if err := s.policy.Authorize(ctx, domain.PermissionUpdateProduct); err != nil {
+		span.RecordError(err)
+		return false, err
+	}
+
+	canonical, err := locale.Canonical(translation.Locale)
+	if err != nil {
+		return false, err
+	}
+	if canonical == s.defaultLocale {
+		return false, domain.ErrDefaultLocale
+	}
+	translation.Locale = canonical
+
+	if translation.Name == "" {
+		return false, domain.ErrInvalidTranslation
+	}
+
+	created, err := s.repo.Save(ctx, translation)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, err
+	}
+
+	return created, nil
+}
+
+func (s *TranslationService) Delete(ctx context.Context, productID uuid.UUID, tag string) error {
+	if err := s.policy.Authorize(ctx, domain.PermissionUpdateProduct); err != nil {
+		return err
+	}
+
+	canonical, err := locale.Canonical(tag)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Delete(ctx, productID, canonical)
+}
+
+// checkVisible returns ErrProductNotFound unless the product exists and the caller may see it
+func (s *TranslationService) checkVisible(ctx context.Context, productID uuid.UUID) error {
+	product, err := s.products.GetByID(ctx, productID)
+	if err != nil {
+		return err
+	}
+
+	if !product.IsPublished() && !s.policy.Allows(ctx, domain.PermissionReadUnpublished) {
+		return domain.ErrProductNotFound
+	}
+
+	return nil
+}
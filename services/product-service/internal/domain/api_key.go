@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidScope   = errors.New("invalid api key scope")
+)
+
+// APIKeyScopes lists the scopes an API key may be issued with
+var APIKeyScopes = []string{ScopeProductsWrite, ScopeProductsAdmin}
+
+// APIKey identifies a machine client. Only the hash of the secret is kept; the key
+// itself is shown once, when it is issued.
+type APIKey struct {
+	ID         uuid.UUID
+	TenantID   string
+	Name       string
+	Prefix     string
+	Hash       []byte
+	Scopes     []string
+	CreatedBy  string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
+
+// ValidAPIKeyScope reports whether an API key may be issued with scope
+func ValidAPIKeyScope(scope string) bool {
+	return slices.Contains(APIKeyScopes, scope)
+}
+
+// Active reports whether the key can be used at the given time
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// Subject is the principal subject of requests made with the key, recorded as their actor
+func (k *APIKey) Subject() string {
+	return "api-key:" + k.ID.String()
+}
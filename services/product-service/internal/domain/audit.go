@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidAuditChange = errors.New("invalid audit change")
+)
+
+// AuditAction is the kind of change recorded in the audit trail
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	AuditActionDelete AuditAction = "delete"
+)
+
+// AnonymousActor is recorded when a change is made without a known actor
+const AnonymousActor = "anonymous"
+
+// FieldChange holds the before and after value of a single product field.
+// Old is nil for creates and New is nil for deletes.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// AuditEntry is an immutable record of a change made to a product
+type AuditEntry struct {
+	ID        uuid.UUID
+	ProductID uuid.UUID
+	Action    AuditAction
+	Actor     string
+	RequestID string
+	Changes   []FieldChange
+	CreatedAt time.Time
+}
+
+// AuditContext carries who made a change and in which request
+type AuditContext struct {
+	Actor     string
+	RequestID string
+}
+
+type auditContextKey struct{}
+
+// WithAuditContext returns a copy of ctx carrying the audit context
+func WithAuditContext(ctx context.Context, ac AuditContext) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, ac)
+}
+
+// AuditContextFromContext returns the audit context stored in ctx,
+// falling back to the anonymous actor
+func AuditContextFromContext(ctx context.Context) AuditContext {
+	ac, _ := ctx.Value(auditContextKey{}).(AuditContext)
+	if ac.Actor == "" {
+		ac.Actor = AnonymousActor
+	}
+	return ac
+}
+
+// NewAuditEntry builds an audit entry for a change of a product from before to after.
+// before is nil for creates and after is nil for deletes.
+func NewAuditEntry(ctx context.Context, action AuditAction, before, after *Product) *AuditEntry {
+	ac := AuditContextFromContext(ctx)
+
+	productID := uuid.Nil
+	if after != nil {
+		productID = after.ID
+	} else if before != nil {
+		productID = before.ID
+	}
+
+	return &AuditEntry{
+		ID:        uuid.New(),
+		ProductID: productID,
+		Action:    action,
+		Actor:     ac.Actor,
+		RequestID: ac.RequestID,
+		Changes:   DiffProducts(before, after),
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// auditedFields returns the audited fields of a product in a stable order
+func auditedFields(p *Product) []FieldChange {
+	return []FieldChange{
+		{Field: "name", New: p.Name},
+		{Field: "description", New: p.Description},
+		{Field: "price", New: float64(p.Price)},
+		{Field: "sku", New: p.SKU},
+		{Field: "category_id", New: p.CategoryID.String()},
+		{Field: "status", New: string(p.Status)},
+		{Field: "type", New: string(p.Type)},
+	}
+}
+
+// DiffProducts returns the field-level differences between two versions of a product
+func DiffProducts(before, after *Product) []FieldChange {
+	var changes []FieldChange
+
+	switch {
+	case before == nil && after == nil:
+		return changes
+	case before == nil:
+		return auditedFields(after)
+	case after == nil:
+		for _, f := range auditedFields(before) {
+			changes = append(changes, FieldChange{Field: f.Field, Old: f.New})
+		}
+		return changes
+	}
+
+	afterFields := auditedFields(after)
+	for i, f := range auditedFields(before) {
+		if f.New != afterFields[i].New {
+			changes = append(changes, FieldChange{Field: f.Field, Old: f.New, New: afterFields[i].New})
+		}
+	}
+
+	return changes
+}
+
+// applyChange sets the field named in the change to its new value
+func applyChange(p *Product, c FieldChange) error {
+	if c.New == nil {
+		return nil
+	}
+
+	switch c.Field {
+	case "name":
+		s, ok := c.New.(string)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrInvalidAuditChange, c.Field)
+		}
+		p.Name = s
+	case "description":
+		s, ok := c.New.(string)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrInvalidAuditChange, c.Field)
+		}
+		p.Description = s
+	case "price":
+		f, ok := c.New.(float64)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrInvalidAuditChange, c.Field)
+		}
+		p.Price = Money(f)
+	case "sku":
+		s, ok := c.New.(string)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrInvalidAuditChange, c.Field)
+		}
+		p.SKU = s
+	case "category_id":
+		s, ok := c.New.(string)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrInvalidAuditChange, c.Field)
+		}
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAuditChange, c.Field)
+		}
+		p.CategoryID = id
+	case "status":
+		s, ok := c.New.(string)
+		if !ok || !ProductStatus(s).Valid() {
+			return fmt.Errorf("%w: %s", ErrInvalidAuditChange, c.Field)
+		}
+		p.Status = ProductStatus(s)
+	case "type":
+		s, ok := c.New.(string)
+		if !ok || !ProductType(s).Valid() {
+			return fmt.Errorf("%w: %s", ErrInvalidAuditChange, c.Field)
+		}
+		p.Type = ProductType(s)
+	}
+
+	return nil
+}
+
+// ReplayAuditEntries reconstructs a product from its audit entries, which must be
+// ordered from oldest to newest. It returns ErrProductNotFound if the product
+// did not exist after the last entry. Products predating the audit log get their
+// create entry from a backfill migration.
+func ReplayAuditEntries(entries []*AuditEntry) (*Product, error) {
+	var product *Product
+
+	for _, e := range entries {
+		switch e.Action {
+		case AuditActionCreate:
+			// Products created before statuses and types existed were published and simple
+			product = &Product{ID: e.ProductID, Status: StatusPublished, Type: TypeSimple, CreatedAt: e.CreatedAt}
+		case AuditActionDelete:
+			product = nil
+			continue
+		}
+
+		if product == nil {
+			continue
+		}
+
+		for _, c := range e.Changes {
+			if err := applyChange(product, c); err != nil {
+				return nil, err
+			}
+		}
+		product.UpdatedAt = e.CreatedAt
+	}
+
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+
+	return product, nil
+}
@@ -0,0 +1,29 @@
+package domain
+
+// Roles granted to callers by the identity provider
+const (
+	RoleAdmin         = "admin"
+	RoleCatalogEditor = "catalog_editor"
+)
+
+// Scopes granted to API clients
+const (
+	ScopeProductsWrite = "products:write"
+	ScopeProductsAdmin = "products:admin"
+)
+
+// Permissions checked before acting on products
+const (
+	PermissionCreateProduct = "product.create"
+	PermissionUpdateProduct = "product.update"
+	PermissionDeleteProduct = "product.delete"
+	// PermissionReadUnpublished allows reading draft and archived products
+	PermissionReadUnpublished  = "product.read_unpublished"
+	PermissionReadHistory      = "product.read_history"
+	PermissionManageAPIKeys    = "api_key.manage"
+	PermissionManagePromotions = "promotion.manage"
+	// PermissionRedeemPromotions allows counting the use of the promotions applied to a cart
+	PermissionRedeemPromotions = "promotion.redeem"
+	// PermissionManageTax allows changing tax rates and the tax classes of products and categories
+	PermissionManageTax = "tax.manage"
+)
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrBundleNotFound = errors.New("bundle not found")
+	ErrInvalidBundle  = errors.New("invalid bundle")
+	ErrComponentInUse = errors.New("the product is a component of an active bundle")
+)
+
+// BundlePricing is how the price of a bundle is set
+type BundlePricing string
+
+const (
+	// BundlePricingFixed sells the bundle at the price of the bundle product
+	BundlePricingFixed BundlePricing = "fixed"
+	// BundlePricingDiscount sells the bundle at a discount on the summed price of its components
+	BundlePricingDiscount BundlePricing = "discount"
+)
+
+// Valid reports whether p is a known pricing
+func (p BundlePricing) Valid() bool {
+	return p == BundlePricingFixed || p == BundlePricingDiscount
+}
+
+// BundleComponent is a quantity of a product sold as part of a bundle
+type BundleComponent struct {
+	ProductID uuid.UUID
+	Quantity  int
+	// Price, Available and UpdatedAt are those of the component product, when loaded
+	// with the bundle
+	Price     Money
+	Available bool
+	UpdatedAt time.Time
+}
+
+// Bundle is the composition of a bundle product
+type Bundle struct {
+	ProductID uuid.UUID
+	Pricing   BundlePricing
+	// DiscountPercent is taken off the summed price of the components of a bundle
+	// priced at a discount
+	DiscountPercent float64
+	Components      []BundleComponent
+	UpdatedAt       time.Time
+}
+
+// Validate checks that the bundle is made of at least one other product, each listed
+// once with a positive quantity, and that only a discount priced bundle has a discount
+func (b *Bundle) Validate() error {
+	if !b.Pricing.Valid() || len(b.Components) == 0 {
+		return ErrInvalidBundle
+	}
+
+	if b.DiscountPercent < 0 || b.DiscountPercent > 100 {
+		return ErrInvalidBundle
+	}
+	if b.Pricing == BundlePricingFixed && b.DiscountPercent != 0 {
+		return ErrInvalidBundle
+	}
+
+	seen := make(map[uuid.UUID]bool, len(b.Components))
+	for _, c := range b.Components {
+		if c.Quantity < 1 || c.ProductID == b.ProductID || seen[c.ProductID] {
+			return ErrInvalidBundle
+		}
+		seen[c.ProductID] = true
+	}
+
+	return nil
+}
+
+// DiscountedPrice returns the summed price of the components, times their quantity,
+// less the discount, rounded to the cent
+func (b *Bundle) DiscountedPrice() Money {
+	var total int64
+	for _, c := range b.Components {
+		total += toCents(c.Price) * int64(c.Quantity)
+	}
+
+	price := new(big.Rat).SetInt64(total)
+	return fromCents(roundHalfUp(price.Sub(price, percentOf(total, b.DiscountPercent))))
+}
+
+// Available reports whether the bundle can be sold, which takes all of its components
+func (b *Bundle) Available() bool {
+	if len(b.Components) == 0 {
+		return false
+	}
+
+	for _, c := range b.Components {
+		if !c.Available {
+			return false
+		}
+	}
+	return true
+}
+
+// ChangedAt returns when the bundle or one of its components last changed
+func (b *Bundle) ChangedAt() time.Time {
+	changed := b.UpdatedAt
+	for _, c := range b.Components {
+		if c.UpdatedAt.After(changed) {
+			changed = c.UpdatedAt
+		}
+	}
+	return changed
+}
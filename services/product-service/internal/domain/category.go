@@ -8,7 +8,8 @@ import (
 )
 
 var (
-	ErrInvalidCategory = errors.New("invalid category")
+	ErrInvalidCategory  = errors.New("invalid category")
+	ErrCategoryNotFound = errors.New("category not found")
 )
 
 type Category struct {
@@ -17,4 +18,6 @@ type Category struct {
 	Description string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	// Slug identifies the category in storefront URLs, generated from the name
+	Slug string
 }
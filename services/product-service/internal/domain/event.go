@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductAggregate is the aggregate type recorded with product events
+const ProductAggregate = "product"
+
+// EventType identifies a domain event
+type EventType string
+
+const (
+	EventProductCreated EventType = "product.created"
+	EventProductUpdated EventType = "product.updated"
+	EventPriceChanged   EventType = "product.price_changed"
+	EventProductDeleted EventType = "product.deleted"
+)
+
+// Event is a domain event raised by an aggregate
+type Event struct {
+	ID          uuid.UUID
+	Type        EventType
+	AggregateID uuid.UUID
+	OccurredAt  time.Time
+	Payload     any
+}
+
+// ProductCreated is raised when a product is added to the catalog
+type ProductCreated struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Price       float64   `json:"price"`
+	SKU         string    `json:"sku"`
+	CategoryID  uuid.UUID `json:"category_id"`
+	Status      string    `json:"status"`
+	Type        string    `json:"type"`
+}
+
+// ProductUpdated is raised when descriptive fields or the status of a product change
+type ProductUpdated struct {
+	ProductID uuid.UUID     `json:"product_id"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+// PriceChanged is raised when the price of a product changes
+type PriceChanged struct {
+	ProductID uuid.UUID `json:"product_id"`
+	OldPrice  float64   `json:"old_price"`
+	NewPrice  float64   `json:"new_price"`
+}
+
+// ProductDeleted is raised when a product is removed from the catalog
+type ProductDeleted struct {
+	ProductID uuid.UUID `json:"product_id"`
+	SKU       string    `json:"sku"`
+}
+
+func newEvent(eventType EventType, aggregateID uuid.UUID, payload any) Event {
+	return Event{
+		ID:          uuid.New(),
+		Type:        eventType,
+		AggregateID: aggregateID,
+		OccurredAt:  time.Now().UTC(),
+		Payload:     payload,
+	}
+}
@@ -0,0 +1,295 @@
+package domain
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCart = errors.New("invalid cart")
+)
+
+// Reasons a presented coupon did not apply
+const (
+	// CouponUnknown is reported for codes of no promotion running now
+	CouponUnknown = "unknown"
+	// CouponExhausted is reported when the promotion reached its usage limit
+	CouponExhausted = "usage_limit_reached"
+	// CouponMinSpend is reported when the targeted lines do not reach the minimum spend
+	CouponMinSpend = "min_spend_not_reached"
+	// CouponNoEligibleItems is reported when the promotion targets no line left to discount
+	CouponNoEligibleItems = "no_eligible_items"
+	// CouponExcluded is reported when an exclusive promotion kept the promotion from stacking
+	CouponExcluded = "excluded"
+)
+
+// CartItem is a quantity of a product a customer asks to price
+type CartItem struct {
+	ProductID uuid.UUID
+	Quantity  int
+}
+
+// CartLine is a quantity of a product to price
+type CartLine struct {
+	ProductID  uuid.UUID
+	SKU        string
+	CategoryID uuid.UUID
+	UnitPrice  Money
+	Quantity   int
+}
+
+// Cart is the input of the pricing engine
+type Cart struct {
+	Lines []CartLine
+	// Coupons are the coupon codes presented with the cart
+	Coupons []string
+}
+
+// Adjustment is the amount a promotion took off a line
+type Adjustment struct {
+	PromotionID uuid.UUID
+	CouponCode  string
+	Amount      Money
+	// Reason describes the discount to the customer
+	Reason string
+}
+
+// QuoteLine is a priced cart line
+type QuoteLine struct {
+	CartLine
+	Subtotal    Money
+	Adjustments []Adjustment
+	Total       Money
+}
+
+// CouponRejection tells why a presented coupon did not apply
+type CouponRejection struct {
+	Code   string
+	Reason string
+}
+
+// Quote is the priced cart
+type Quote struct {
+	Lines    []QuoteLine
+	Subtotal Money
+	Discount Money
+	Total    Money
+	// Applied lists the promotions that adjusted a line, in the order they applied
+	Applied         []*Promotion
+	RejectedCoupons []CouponRejection
+}
+
+// Evaluate prices the cart, applying the promotions running at now in priority order.
+// Promotions with a coupon code only apply if the cart presents it. Each promotion
+// discounts what the promotions applied before it left of the lines it targets, so
+// no line goes below zero. Amounts are computed exactly in cents and rounded half up.
+func Evaluate(cart Cart, promotions []*Promotion, now time.Time) *Quote {
+	quote := &Quote{Lines: make([]QuoteLine, len(cart.Lines))}
+	var subtotal int64
+	for i, line := range cart.Lines {
+		cents := toCents(line.UnitPrice) * int64(line.Quantity)
+		quote.Lines[i] = QuoteLine{CartLine: line, Subtotal: fromCents(cents), Total: fromCents(cents)}
+		subtotal += cents
+	}
+	quote.Subtotal = fromCents(subtotal)
+
+	presented := make(map[string]bool, len(cart.Coupons))
+	for _, code := range cart.Coupons {
+		presented[NormalizeCoupon(code)] = true
+	}
+
+	ordered := slices.Clone(promotions)
+	slices.SortStableFunc(ordered, func(a, b *Promotion) int {
+		return cmp.Or(cmp.Compare(b.Priority, a.Priority), a.CreatedAt.Compare(b.CreatedAt))
+	})
+
+	matched := make(map[string]bool, len(presented))
+	exclusive := false
+	for _, p := range ordered {
+		if !p.ActiveAt(now) || (p.CouponCode != "" && !presented[p.CouponCode]) {
+			continue
+		}
+		if p.CouponCode != "" {
+			matched[p.CouponCode] = true
+		}
+
+		reason := quote.apply(p, exclusive)
+		if reason != "" {
+			if p.CouponCode != "" {
+				quote.RejectedCoupons = append(quote.RejectedCoupons, CouponRejection{Code: p.CouponCode, Reason: reason})
+			}
+			continue
+		}
+
+		quote.Applied = append(quote.Applied, p)
+		exclusive = exclusive || p.Exclusive
+	}
+
+	for _, code := range cart.Coupons {
+		code = NormalizeCoupon(code)
+		if !matched[code] {
+			quote.RejectedCoupons = append(quote.RejectedCoupons, CouponRejection{Code: code, Reason: CouponUnknown})
+			// Report a code presented twice once
+			matched[code] = true
+		}
+	}
+
+	quote.Total = fromCents(subtotal - toCents(quote.Discount))
+	return quote
+}
+
+// apply adjusts the lines p discounts, or returns why p does not apply. exclusive
+// tells whether an exclusive promotion applied already.
+func (q *Quote) apply(p *Promotion, exclusive bool) string {
+	if exclusive || (p.Exclusive && len(q.Applied) > 0) {
+		return CouponExcluded
+	}
+	if p.Exhausted() {
+		return CouponExhausted
+	}
+
+	var (
+		eligible []int
+		spend    int64
+	)
+	for i, line := range q.Lines {
+		if line.Total > 0 && p.Targets(line.CartLine) {
+			eligible = append(eligible, i)
+			spend += toCents(line.Total)
+		}
+	}
+	if len(eligible) == 0 {
+		return CouponNoEligibleItems
+	}
+	if spend < toCents(p.MinSpend) {
+		return CouponMinSpend
+	}
+
+	amounts := p.discounts(q.Lines, eligible, spend)
+
+	applied := false
+	for _, i := range eligible {
+		amount := amounts[i]
+		if amount <= 0 {
+			continue
+		}
+
+		line := &q.Lines[i]
+		line.Adjustments = append(line.Adjustments, Adjustment{
+			PromotionID: p.ID,
+			CouponCode:  p.CouponCode,
+			Amount:      fromCents(amount),
+			Reason:      p.reason(),
+		})
+		line.Total = fromCents(toCents(line.Total) - amount)
+		q.Discount = fromCents(toCents(q.Discount) + amount)
+		applied = true
+	}
+	if !applied {
+		return CouponNoEligibleItems
+	}
+
+	return ""
+}
+
+// discounts returns the cents p takes off each eligible line, by line index. spend
+// is the cents left of the eligible lines.
+func (p *Promotion) discounts(lines []QuoteLine, eligible []int, spend int64) map[int]int64 {
+	amounts := make(map[int]int64, len(eligible))
+
+	switch p.Kind {
+	case DiscountPercentage:
+		for _, i := range eligible {
+			amounts[i] = roundHalfUp(percentOf(toCents(lines[i].Total), p.Value))
+		}
+
+	case DiscountFixed:
+		// The amount is shared by the lines in proportion to what is left of them: each
+		// gets the whole cents of its exact share, and the cents left go to the lines
+		// with the largest remainders. No line takes more than is left of it, its excess
+		// going to the next lines.
+		total := min(toCents(Money(p.Value)), spend)
+		left := total
+		remainders := make([]*big.Rat, len(eligible))
+		for n, i := range eligible {
+			share := new(big.Rat).Mul(big.NewRat(total, spend), new(big.Rat).SetInt64(toCents(lines[i].Total)))
+			amounts[i] = min(floor(share), toCents(lines[i].Total))
+			remainders[n] = share.Sub(share, new(big.Rat).SetInt64(amounts[i]))
+			left -= amounts[i]
+		}
+
+		order := make([]int, len(eligible))
+		for n := range order {
+			order[n] = n
+		}
+		slices.SortStableFunc(order, func(a, b int) int { return remainders[b].Cmp(remainders[a]) })
+		// The total is at most the spend, so the cents left always find a line with room
+		for left > 0 {
+			for _, n := range order {
+				i := eligible[n]
+				if left > 0 && amounts[i] < toCents(lines[i].Total) {
+					amounts[i]++
+					left--
+				}
+			}
+		}
+
+	case DiscountBuyXGetY:
+		// The discounted units are the cheapest of the eligible ones
+		units := 0
+		for _, i := range eligible {
+			units += lines[i].Quantity
+		}
+		free := units / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+
+		cheapest := slices.Clone(eligible)
+		slices.SortStableFunc(cheapest, func(a, b int) int {
+			return unitPrice(lines[a]).Cmp(unitPrice(lines[b]))
+		})
+		for _, i := range cheapest {
+			if free == 0 {
+				break
+			}
+			n := min(free, lines[i].Quantity)
+			discount := percentOf(toCents(lines[i].Total), p.Value)
+			discount.Mul(discount, big.NewRat(int64(n), int64(lines[i].Quantity)))
+			amounts[i] = min(roundHalfUp(discount), toCents(lines[i].Total))
+			free -= n
+		}
+	}
+
+	return amounts
+}
+
+// reason describes the discount of p to the customer
+func (p *Promotion) reason() string {
+	switch p.Kind {
+	case DiscountPercentage:
+		return fmt.Sprintf("%s%% off: %s", formatNumber(p.Value), p.Name)
+	case DiscountFixed:
+		return fmt.Sprintf("%.2f off: %s", p.Value, p.Name)
+	case DiscountBuyXGetY:
+		if p.Value == 100 {
+			return fmt.Sprintf("Buy %d get %d free: %s", p.BuyQuantity, p.GetQuantity, p.Name)
+		}
+		return fmt.Sprintf("Buy %d get %d at %s%% off: %s", p.BuyQuantity, p.GetQuantity, formatNumber(p.Value), p.Name)
+	}
+	return p.Name
+}
+
+// unitPrice returns the cents left of the price of a unit of the line
+func unitPrice(line QuoteLine) *big.Rat {
+	return big.NewRat(toCents(line.Total), int64(line.Quantity))
+}
+
+// formatNumber formats f without trailing zeros
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
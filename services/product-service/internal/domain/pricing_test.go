@@ -0,0 +1,168 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func cartLine(unitPrice Money, quantity int) CartLine {
+	return CartLine{ProductID: uuid.New(), SKU: "SKU-" + uuid.NewString()[:8], UnitPrice: unitPrice, Quantity: quantity}
+}
+
+func promotion(kind DiscountKind, value float64) *Promotion {
+	return &Promotion{ID: uuid.New(), Name: string(kind), Kind: kind, Value: value, BuyQuantity: 2, GetQuantity: 1, Active: true}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []CartLine
+		promotions   []*Promotion
+		wantTotals   []int64
+		wantDiscount int64
+	}{
+		{
+			name:         "sums in cents",
+			lines:        []CartLine{cartLine(0.1, 3), cartLine(0.2, 1)},
+			wantTotals:   []int64{30, 20},
+			wantDiscount: 0,
+		},
+		{
+			name:         "percentage rounds half up",
+			lines:        []CartLine{cartLine(0.10, 1), cartLine(1.10, 3)},
+			promotions:   []*Promotion{promotion(DiscountPercentage, 15)},
+			wantTotals:   []int64{8, 280},
+			wantDiscount: 52,
+		},
+		{
+			name:         "fixed shared by value",
+			lines:        []CartLine{cartLine(10, 1), cartLine(20, 1)},
+			promotions:   []*Promotion{promotion(DiscountFixed, 3)},
+			wantTotals:   []int64{900, 1800},
+			wantDiscount: 300,
+		},
+		{
+			// A third of a cent each, the cent left going to the first line on equal remainders
+			name:         "fixed shares remainder cents",
+			lines:        []CartLine{cartLine(1, 1), cartLine(1, 1), cartLine(1, 1)},
+			promotions:   []*Promotion{promotion(DiscountFixed, 1)},
+			wantTotals:   []int64{66, 67, 67},
+			wantDiscount: 100,
+		},
+		{
+			name:       "fixed shares remainder cents to largest remainders",
+			lines:      []CartLine{cartLine(0.03, 1), cartLine(0.01, 1), cartLine(0.05, 1)},
+			promotions: []*Promotion{promotion(DiscountFixed, 0.04)},
+			// 1.33, 0.44 and 2.22 cents, the cent left going to the second line
+			wantTotals:   []int64{2, 0, 3},
+			wantDiscount: 4,
+		},
+		{
+			name:         "fixed beyond the spend takes the lines to zero",
+			lines:        []CartLine{cartLine(0.01, 1), cartLine(4.99, 1)},
+			promotions:   []*Promotion{promotion(DiscountFixed, 20)},
+			wantTotals:   []int64{0, 0},
+			wantDiscount: 500,
+		},
+		{
+			name:         "buy x get y discounts the cheapest units",
+			lines:        []CartLine{cartLine(10, 2), cartLine(4, 1)},
+			promotions:   []*Promotion{promotion(DiscountBuyXGetY, 100)},
+			wantTotals:   []int64{2000, 0},
+			wantDiscount: 400,
+		},
+		{
+			// 10% off leaves 2.70 of three units, so a free unit is worth 0.90
+			name:         "buy x get y after a percentage",
+			lines:        []CartLine{cartLine(1, 3)},
+			promotions:   []*Promotion{promotion(DiscountBuyXGetY, 100), func() *Promotion { p := promotion(DiscountPercentage, 10); p.Priority = 1; return p }()},
+			wantTotals:   []int64{180},
+			wantDiscount: 120,
+		},
+		{
+			name:         "min spend not reached",
+			lines:        []CartLine{cartLine(9.99, 1)},
+			promotions:   []*Promotion{func() *Promotion { p := promotion(DiscountFixed, 5); p.MinSpend = 10; return p }()},
+			wantTotals:   []int64{999},
+			wantDiscount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := Evaluate(Cart{Lines: tt.lines}, tt.promotions, time.Now())
+
+			var totals []int64
+			for _, line := range quote.Lines {
+				totals = append(totals, toCents(line.Total))
+			}
+			if !slices.Equal(totals, tt.wantTotals) {
+				t.Errorf("line totals = %v cents, want %v", totals, tt.wantTotals)
+			}
+			if got := toCents(quote.Discount); got != tt.wantDiscount {
+				t.Errorf("discount = %d cents, want %d", got, tt.wantDiscount)
+			}
+			if toCents(quote.Subtotal)-toCents(quote.Discount) != toCents(quote.Total) {
+				t.Errorf("%v - %v != %v", quote.Subtotal, quote.Discount, quote.Total)
+			}
+		})
+	}
+}
+
+func TestEvaluateFixedDiscountKeepsLinesWhole(t *testing.T) {
+	prices := []Money{0.01, 0.07, 0.33, 1.99, 0.5, 12.34, 0.02}
+
+	for _, value := range []float64{0.01, 0.05, 0.99, 1, 3.33, 15, 100} {
+		var lines []CartLine
+		var subtotal int64
+		for i, price := range prices {
+			lines = append(lines, cartLine(price, i%3+1))
+			subtotal += toCents(price) * int64(i%3+1)
+		}
+
+		quote := Evaluate(Cart{Lines: lines}, []*Promotion{promotion(DiscountFixed, value)}, time.Now())
+
+		if want := min(toCents(Money(value)), subtotal); toCents(quote.Discount) != want {
+			t.Errorf("%v off: discount = %d cents, want %d", value, toCents(quote.Discount), want)
+		}
+		var adjusted int64
+		for _, line := range quote.Lines {
+			for _, a := range line.Adjustments {
+				adjusted += toCents(a.Amount)
+			}
+			if line.Total < 0 {
+				t.Errorf("%v off: line of %v left at %v", value, line.Subtotal, line.Total)
+			}
+		}
+		if adjusted != toCents(quote.Discount) {
+			t.Errorf("%v off: adjustments sum to %d cents, discount is %v", value, adjusted, quote.Discount)
+		}
+	}
+}
+
+func TestBundleDiscountedPrice(t *testing.T) {
+	tests := []struct {
+		discount float64
+		want     int64
+	}{
+		{0, 1029},
+		{10, 926},
+		// 12.5% of 10.29 is 128.625 cents, leaving 900.375
+		{12.5, 900},
+		{33.3, 686},
+		{100, 0},
+	}
+
+	for _, tt := range tests {
+		b := &Bundle{DiscountPercent: tt.discount, Components: []BundleComponent{
+			{Price: 0.1, Quantity: 3},
+			{Price: 3.33, Quantity: 3},
+		}}
+		if got := toCents(b.DiscountedPrice()); got != tt.want {
+			t.Errorf("%v%% off: price = %d cents, want %d", tt.discount, got, tt.want)
+		}
+	}
+}
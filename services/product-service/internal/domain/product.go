@@ -11,10 +11,51 @@ var (
 	ErrInvalidPrice    = errors.New("invalid price")
 	ErrInvalidProduct  = errors.New("invalid product")
 	ErrProductNotFound = errors.New("product not found")
+	ErrInvalidStatus   = errors.New("invalid product status")
+	ErrDuplicateSKU    = errors.New("a product with this SKU already exists")
+	ErrInvalidType     = errors.New("invalid product type")
 )
 
 type Money float64
 
+// ProductStatus is the publication state of a product. Only published products
+// are visible to the public.
+type ProductStatus string
+
+const (
+	StatusDraft     ProductStatus = "draft"
+	StatusPublished ProductStatus = "published"
+	StatusArchived  ProductStatus = "archived"
+)
+
+// Valid reports whether s is a known status
+func (s ProductStatus) Valid() bool {
+	switch s {
+	case StatusDraft, StatusPublished, StatusArchived:
+		return true
+	}
+	return false
+}
+
+// ProductType tells single products from bundles sold as a single SKU
+type ProductType string
+
+const (
+	TypeSimple ProductType = "simple"
+	TypeBundle ProductType = "bundle"
+)
+
+// Valid reports whether t is a known product type
+func (t ProductType) Valid() bool {
+	return t == TypeSimple || t == TypeBundle
+}
+
+// ProductFilter restricts the products returned by list queries
+type ProductFilter struct {
+	// Statuses limits the results to products in one of the statuses; empty means any
+	Statuses []ProductStatus
+}
+
 type Product struct {
 	ID          uuid.UUID
 	Name        string
@@ -22,16 +63,146 @@ type Product struct {
 	Price       Money
 	SKU         string
 	CategoryID  uuid.UUID
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	Status      ProductStatus
+	// Type is set when the product is created and never changes
+	Type      ProductType
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Slug identifies the product in storefront URLs. It is generated from the name
+	// by the repository and changes when the product is renamed.
+	Slug string
+	// Locale is the locale of the translation applied to Name and Description, if any
+	Locale string
+	// TranslatedAt is when the applied translation last changed
+	TranslatedAt time.Time
+	// Bundle is the composition of a bundle product, when loaded
+	Bundle *Bundle
+
+	events []Event
+}
+
+// MarkCreated records that the product has been added to the catalog
+func (p *Product) MarkCreated() {
+	p.recordEvent(EventProductCreated, ProductCreated{
+		ProductID:   p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       float64(p.Price),
+		SKU:         p.SKU,
+		CategoryID:  p.CategoryID,
+		Status:      string(p.Status),
+		Type:        string(p.Type),
+	})
+}
+
+// IsPublished reports whether the product is visible to the public
+func (p *Product) IsPublished() bool {
+	return p.Status == StatusPublished
+}
+
+// IsAvailable reports whether the product can be sold: it is published and, for a
+// bundle, every one of its components is available
+func (p *Product) IsAvailable() bool {
+	if !p.IsPublished() {
+		return false
+	}
+	if p.Type == TypeBundle {
+		return p.Bundle != nil && p.Bundle.Available()
+	}
+	return true
+}
+
+// SetBundle attaches the composition of a bundle product, deriving the price of a
+// bundle priced at a discount on its components
+func (p *Product) SetBundle(b *Bundle) {
+	p.Bundle = b
+	if b.Pricing == BundlePricingDiscount {
+		p.Price = b.DiscountedPrice()
+	}
+}
+
+// ChangeStatus moves the product to another publication state
+func (p *Product) ChangeStatus(status ProductStatus) error {
+	if !status.Valid() {
+		return ErrInvalidStatus
+	}
+
+	if p.Status == status {
+		return nil
+	}
+
+	before := *p
+	p.Status = status
+	p.UpdatedAt = time.Now()
+	p.recordEvent(EventProductUpdated, ProductUpdated{
+		ProductID: p.ID,
+		Changes:   DiffProducts(&before, p),
+	})
+
+	return nil
+}
+
+// UpdateDetails changes the descriptive fields of the product
+func (p *Product) UpdateDetails(name, description, sku string, categoryID uuid.UUID) error {
+	if name == "" {
+		return ErrInvalidProduct
+	}
+
+	before := *p
+	p.Name = name
+	p.Description = description
+	p.SKU = sku
+	p.CategoryID = categoryID
+
+	changes := DiffProducts(&before, p)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	p.UpdatedAt = time.Now()
+	p.recordEvent(EventProductUpdated, ProductUpdated{
+		ProductID: p.ID,
+		Changes:   changes,
+	})
+
+	return nil
 }
 
 func (p *Product) UpdatePrice(newPrice Money) error {
 	if newPrice < 0 {
 		return ErrInvalidPrice
 	}
+
+	oldPrice := p.Price
 	p.Price = newPrice
 	p.UpdatedAt = time.Now()
 
+	if oldPrice != newPrice {
+		p.recordEvent(EventPriceChanged, PriceChanged{
+			ProductID: p.ID,
+			OldPrice:  float64(oldPrice),
+			NewPrice:  float64(newPrice),
+		})
+	}
+
 	return nil
 }
+
+// MarkDeleted records that the product has been removed from the catalog
+func (p *Product) MarkDeleted() {
+	p.recordEvent(EventProductDeleted, ProductDeleted{
+		ProductID: p.ID,
+		SKU:       p.SKU,
+	})
+}
+
+// PullEvents returns the events raised since the last call and clears them
+func (p *Product) PullEvents() []Event {
+	events := p.events
+	p.events = nil
+	return events
+}
+
+func (p *Product) recordEvent(eventType EventType, payload any) {
+	p.events = append(p.events, newEvent(eventType, p.ID, payload))
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPromotionNotFound = errors.New("promotion not found")
+	ErrInvalidPromotion  = errors.New("invalid promotion")
+	ErrDuplicateCoupon   = errors.New("a promotion with this coupon code already exists")
+	ErrCouponExhausted   = errors.New("a promotion has reached its usage limit")
+)
+
+// DiscountKind is how a promotion takes money off the lines it targets
+type DiscountKind string
+
+const (
+	// DiscountPercentage takes a percentage off each targeted line
+	DiscountPercentage DiscountKind = "percentage"
+	// DiscountFixed takes an amount off the targeted lines, shared by their value
+	DiscountFixed DiscountKind = "fixed"
+	// DiscountBuyXGetY takes a percentage off the cheapest Y of every X+Y targeted units
+	DiscountBuyXGetY DiscountKind = "buy_x_get_y"
+)
+
+// Valid reports whether k is a known discount kind
+func (k DiscountKind) Valid() bool {
+	switch k {
+	case DiscountPercentage, DiscountFixed, DiscountBuyXGetY:
+		return true
+	}
+	return false
+}
+
+// Promotion is a discount rule applied to the carts it matches
+type Promotion struct {
+	ID   uuid.UUID
+	Name string
+	Kind DiscountKind
+	// Value is the amount off of a fixed discount, and the percentage off otherwise;
+	// 100 makes the Y units of a buy-X-get-Y discount free
+	Value float64
+	// BuyQuantity and GetQuantity are the X and Y of a buy-X-get-Y discount
+	BuyQuantity int
+	GetQuantity int
+	// CategoryIDs and SKUs restrict the promotion to the lines of products in one of
+	// the categories or with one of the SKUs. A promotion without either targets
+	// every line.
+	CategoryIDs []uuid.UUID
+	SKUs        []string
+	// MinSpend is the value the targeted lines must reach, after the discounts of the
+	// promotions applied before
+	MinSpend Money
+	// CouponCode restricts the promotion to the carts presenting it; a promotion
+	// without one applies automatically
+	CouponCode string
+	// UsageLimit caps the redemptions of the promotion; nil means unlimited
+	UsageLimit *int
+	UsageCount int
+	// Priority orders the promotions, highest first, deciding how they stack
+	Priority int
+	// Exclusive promotions apply alone: not after another promotion, and none after them
+	Exclusive bool
+	Active    bool
+	StartsAt  *time.Time
+	EndsAt    *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// NormalizeCoupon returns the form coupon codes are stored and compared in
+func NormalizeCoupon(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks that the promotion has a name and a discount its kind can apply,
+// and that it ends after it starts
+func (p *Promotion) Validate() error {
+	if p.Name == "" || !p.Kind.Valid() || p.Value <= 0 || p.MinSpend < 0 {
+		return ErrInvalidPromotion
+	}
+
+	switch p.Kind {
+	case DiscountPercentage:
+		if p.Value > 100 {
+			return ErrInvalidPromotion
+		}
+	case DiscountBuyXGetY:
+		if p.Value > 100 || p.BuyQuantity < 1 || p.GetQuantity < 1 {
+			return ErrInvalidPromotion
+		}
+	}
+
+	if p.UsageLimit != nil && *p.UsageLimit < 1 {
+		return ErrInvalidPromotion
+	}
+
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return ErrInvalidPromotion
+	}
+
+	return nil
+}
+
+// ActiveAt reports whether the promotion is enabled and runs at t
+func (p *Promotion) ActiveAt(t time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	return p.EndsAt == nil || t.Before(*p.EndsAt)
+}
+
+// Exhausted reports whether the promotion has reached its usage limit
+func (p *Promotion) Exhausted() bool {
+	return p.UsageLimit != nil && p.UsageCount >= *p.UsageLimit
+}
+
+// Targets reports whether the promotion applies to a line
+func (p *Promotion) Targets(line CartLine) bool {
+	if len(p.CategoryIDs) == 0 && len(p.SKUs) == 0 {
+		return true
+	}
+	return slices.Contains(p.CategoryIDs, line.CategoryID) || slices.Contains(p.SKUs, line.SKU)
+}
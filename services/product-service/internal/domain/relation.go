@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRelationNotFound  = errors.New("product relation not found")
+	ErrInvalidRelation   = errors.New("invalid product relation")
+	ErrDuplicateRelation = errors.New("the products are already related with this type")
+)
+
+// RelationType is the kind of list a related product is shown in
+type RelationType string
+
+const (
+	// RelationRelated lists similar products
+	RelationRelated RelationType = "related"
+	// RelationCrossSell lists products frequently bought together
+	RelationCrossSell RelationType = "cross_sell"
+	// RelationUpSell lists more expensive alternatives to upgrade to
+	RelationUpSell RelationType = "up_sell"
+	// RelationAccessory lists products complementing the product
+	RelationAccessory RelationType = "accessory"
+)
+
+// Valid reports whether t is a known relation type
+func (t RelationType) Valid() bool {
+	switch t {
+	case RelationRelated, RelationCrossSell, RelationUpSell, RelationAccessory:
+		return true
+	}
+	return false
+}
+
+// Symmetric reports whether a relation of type t may hold in both directions. An
+// up-sell or accessory of a product does not have the product as its own.
+func (t RelationType) Symmetric() bool {
+	return t == RelationRelated || t == RelationCrossSell
+}
+
+// ProductRelation places a related product in one of the ordered lists of a product
+type ProductRelation struct {
+	ProductID uuid.UUID
+	RelatedID uuid.UUID
+	Type      RelationType
+	// Position is the 0-based place of the related product in the list of the
+	// product and type. When saving, a negative or past the end position places it last.
+	Position int
+	// Bidirectional relations are mirrored by the same relation from the related
+	// product, and are removed with it
+	Bidirectional bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	// Related is the related product, when loaded with the relation
+	Related *Product
+}
+
+// Reverse returns the relation mirroring a bidirectional relation
+func (r *ProductRelation) Reverse() *ProductRelation {
+	return &ProductRelation{
+		ProductID:     r.RelatedID,
+		RelatedID:     r.ProductID,
+		Type:          r.Type,
+		Position:      -1,
+		Bidirectional: true,
+	}
+}
+
+// Validate checks that the relation links two different products with a known type,
+// in both directions only if its type is symmetric
+func (r *ProductRelation) Validate() error {
+	if !r.Type.Valid() || r.ProductID == r.RelatedID {
+		return ErrInvalidRelation
+	}
+	if r.Bidirectional && !r.Type.Symmetric() {
+		return ErrInvalidRelation
+	}
+	return nil
+}
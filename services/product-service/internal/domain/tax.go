@@ -0,0 +1,388 @@
+package domain
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidTaxClass   = errors.New("invalid tax class")
+	ErrInvalidTaxRate    = errors.New("invalid tax rate")
+	ErrTaxRateNotFound   = errors.New("no tax rate applies")
+	ErrInvalidTaxRequest = errors.New("invalid tax calculation")
+)
+
+// TaxClassStandard is the tax class of the products neither they nor their category
+// have one
+const TaxClassStandard = "standard"
+
+// TaxClassSource tells where the tax class of a product comes from
+type TaxClassSource string
+
+const (
+	TaxClassFromProduct  TaxClassSource = "product"
+	TaxClassFromCategory TaxClassSource = "category"
+	TaxClassFromDefault  TaxClassSource = "default"
+)
+
+// PriceDisplay is whether prices are shown to customers with tax or without
+type PriceDisplay string
+
+const (
+	PriceDisplayInclusive PriceDisplay = "inclusive"
+	PriceDisplayExclusive PriceDisplay = "exclusive"
+)
+
+// Valid reports whether d is a known display mode
+func (d PriceDisplay) Valid() bool {
+	return d == PriceDisplayInclusive || d == PriceDisplayExclusive
+}
+
+// TaxRounding is where tax amounts are rounded to the cent
+type TaxRounding string
+
+const (
+	// TaxRoundingLine rounds the tax of each line, the total being their sum
+	TaxRoundingLine TaxRounding = "line"
+	// TaxRoundingTotal rounds the tax of the lines sharing a rate once, sharing the
+	// rounded amount out to them
+	TaxRoundingTotal TaxRounding = "total"
+)
+
+// Valid reports whether r is a known rounding
+func (r TaxRounding) Valid() bool {
+	return r == TaxRoundingLine || r == TaxRoundingTotal
+}
+
+var (
+	taxClassPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+	countryPattern  = regexp.MustCompile(`^[A-Z]{2}$`)
+	regionPattern   = regexp.MustCompile(`^[A-Z0-9]{1,3}$`)
+)
+
+// ValidTaxClass reports whether class is a valid tax class code: lowercase letters,
+// digits and underscores, starting with a letter
+func ValidTaxClass(class string) bool {
+	return taxClassPattern.MatchString(class)
+}
+
+// TaxRate is the rate of a tax class in a country or one of its regions over a period
+type TaxRate struct {
+	// Country is an ISO 3166-1 alpha-2 code
+	Country string
+	// Region is the subdivision part of an ISO 3166-2 code, as CA for US-CA; empty for
+	// the rate of the whole country
+	Region string
+	Class  string
+	// Rate is a percentage of the price without tax
+	Rate float64
+	// ValidFrom is the first day the rate applies, and ValidTo the day it stops
+	// applying; nil while it has no end
+	ValidFrom time.Time
+	ValidTo   *time.Time
+}
+
+// Validate checks the codes of the rate and that it applies over a period
+func (r *TaxRate) Validate() error {
+	if !countryPattern.MatchString(r.Country) {
+		return fmt.Errorf("%w: country %q is not an ISO 3166-1 alpha-2 code", ErrInvalidTaxRate, r.Country)
+	}
+	if r.Region != "" && !regionPattern.MatchString(r.Region) {
+		return fmt.Errorf("%w: region %q is not an ISO 3166-2 subdivision code", ErrInvalidTaxRate, r.Region)
+	}
+	if !ValidTaxClass(r.Class) {
+		return fmt.Errorf("%w: tax class %q", ErrInvalidTaxRate, r.Class)
+	}
+	if r.Rate < 0 || r.Rate > 100 {
+		return fmt.Errorf("%w: rate %s is not between 0 and 100", ErrInvalidTaxRate, formatNumber(r.Rate))
+	}
+	if r.ValidTo != nil && !r.ValidTo.After(r.ValidFrom) {
+		return fmt.Errorf("%w: valid_to must be after valid_from", ErrInvalidTaxRate)
+	}
+	return nil
+}
+
+// AppliesAt reports whether the rate applies at t
+func (r *TaxRate) AppliesAt(t time.Time) bool {
+	return !t.Before(r.ValidFrom) && (r.ValidTo == nil || t.Before(*r.ValidTo))
+}
+
+// overlaps reports whether r and other are rates of the same class and place whose
+// periods overlap
+func (r *TaxRate) overlaps(other *TaxRate) bool {
+	if r.Country != other.Country || r.Region != other.Region || r.Class != other.Class {
+		return false
+	}
+	return (r.ValidTo == nil || other.ValidFrom.Before(*r.ValidTo)) &&
+		(other.ValidTo == nil || r.ValidFrom.Before(*other.ValidTo))
+}
+
+// ValidateTaxRates checks each rate and that no two rates of a class and place apply
+// at the same time
+func ValidateTaxRates(rates []TaxRate) error {
+	for i := range rates {
+		if err := rates[i].Validate(); err != nil {
+			return err
+		}
+		for j := range i {
+			if rates[i].overlaps(&rates[j]) {
+				return fmt.Errorf("%w: rates of %s in %s overlap from %s",
+					ErrInvalidTaxRate, rates[i].Class, rates[i].Place(), later(rates[i].ValidFrom, rates[j].ValidFrom).Format(time.DateOnly))
+			}
+		}
+	}
+	return nil
+}
+
+// Place returns the country, or the ISO 3166-2 code of the region, of the rate
+func (r *TaxRate) Place() string {
+	return TaxDestination{Country: r.Country, Region: r.Region}.String()
+}
+
+// SortTaxRates orders rates by country, region, class and start
+func SortTaxRates(rates []TaxRate) {
+	slices.SortFunc(rates, func(a, b TaxRate) int {
+		return cmp.Or(
+			cmp.Compare(a.Country, b.Country),
+			cmp.Compare(a.Region, b.Region),
+			cmp.Compare(a.Class, b.Class),
+			a.ValidFrom.Compare(b.ValidFrom),
+		)
+	})
+}
+
+// TaxDestination is where goods are delivered, which decides the rates applying
+type TaxDestination struct {
+	Country string
+	Region  string
+}
+
+// Validate checks the codes of the destination
+func (d TaxDestination) Validate() error {
+	if !countryPattern.MatchString(d.Country) || (d.Region != "" && !regionPattern.MatchString(d.Region)) {
+		return ErrInvalidTaxRequest
+	}
+	return nil
+}
+
+func (d TaxDestination) String() string {
+	if d.Region == "" {
+		return d.Country
+	}
+	return d.Country + "-" + d.Region
+}
+
+// FindTaxRate returns the rate of class applying at destination at t. The rate of
+// the region wins over the rate of the whole country.
+func FindTaxRate(rates []TaxRate, destination TaxDestination, class string, t time.Time) (TaxRate, bool) {
+	var (
+		found TaxRate
+		ok    bool
+	)
+	for _, r := range rates {
+		if r.Country != destination.Country || r.Class != class || !r.AppliesAt(t) {
+			continue
+		}
+		if r.Region == destination.Region && destination.Region != "" {
+			return r, true
+		}
+		if r.Region == "" {
+			found, ok = r, true
+		}
+	}
+	return found, ok
+}
+
+// ResolveTaxClass returns the tax class of a product, from the product itself, then
+// its category, then TaxClassStandard
+func ResolveTaxClass(productClass, categoryClass string) (string, TaxClassSource) {
+	switch {
+	case productClass != "":
+		return productClass, TaxClassFromProduct
+	case categoryClass != "":
+		return categoryClass, TaxClassFromCategory
+	}
+	return TaxClassStandard, TaxClassFromDefault
+}
+
+// TaxableLine is a quantity of a product to tax
+type TaxableLine struct {
+	ProductID uuid.UUID
+	Class     string
+	// UnitPrice is the price without tax
+	UnitPrice Money
+	Quantity  int
+}
+
+// TaxLine is a taxed line
+type TaxLine struct {
+	TaxableLine
+	Rate float64
+	// DisplayPrice is the unit price shown to customers in the display mode
+	DisplayPrice Money
+	Net          Money
+	Tax          Money
+	Gross        Money
+}
+
+// TaxBreakdown sums the lines taxed at a rate
+type TaxBreakdown struct {
+	Rate float64
+	Net  Money
+	Tax  Money
+}
+
+// TaxCalculation is the tax of a set of lines
+type TaxCalculation struct {
+	Destination TaxDestination
+	Display     PriceDisplay
+	Rounding    TaxRounding
+	Lines       []TaxLine
+	// Breakdown sums the lines by rate, highest rate first
+	Breakdown []TaxBreakdown
+	Net       Money
+	Tax       Money
+	Gross     Money
+}
+
+// CalculateTax taxes the lines at the rates of their class applying at destination
+// at t. It fails with ErrTaxRateNotFound if a class has no rate there. Amounts are
+// computed exactly in cents and rounded half up.
+func CalculateTax(lines []TaxableLine, rates []TaxRate, destination TaxDestination, t time.Time, display PriceDisplay, rounding TaxRounding) (*TaxCalculation, error) {
+	calc := &TaxCalculation{
+		Destination: destination,
+		Display:     display,
+		Rounding:    rounding,
+		Lines:       make([]TaxLine, len(lines)),
+	}
+
+	net := make([]int64, len(lines))
+	tax := make([]int64, len(lines))
+	for i, line := range lines {
+		rate, ok := FindTaxRate(rates, destination, line.Class, t)
+		if !ok {
+			return nil, fmt.Errorf("%w: no rate of tax class %s in %s on %s", ErrTaxRateNotFound, line.Class, destination, t.Format(time.DateOnly))
+		}
+
+		net[i] = toCents(line.UnitPrice) * int64(line.Quantity)
+		calc.Lines[i] = TaxLine{
+			TaxableLine: line,
+			Rate:        rate.Rate,
+		}
+	}
+
+	// Lines are taxed by rate, so rounding on the total rounds once per rate
+	byRate := make(map[float64][]int)
+	for i, line := range calc.Lines {
+		byRate[line.Rate] = append(byRate[line.Rate], i)
+	}
+
+	for rate, indexes := range byRate {
+		var netCents, taxCents int64
+		for _, i := range indexes {
+			netCents += net[i]
+		}
+
+		switch rounding {
+		case TaxRoundingTotal:
+			taxCents = roundHalfUp(percentOf(netCents, rate))
+			// The rounded tax is shared by the lines in proportion to their value: each
+			// gets the whole cents of its exact share, and the cents left go to the
+			// lines with the largest remainders
+			remainders := make([]*big.Rat, len(indexes))
+			left := taxCents
+			for n, i := range indexes {
+				share := percentOf(net[i], rate)
+				tax[i] = floor(share)
+				remainders[n] = share.Sub(share, new(big.Rat).SetInt64(tax[i]))
+				left -= tax[i]
+			}
+
+			order := make([]int, len(indexes))
+			for n := range order {
+				order[n] = n
+			}
+			slices.SortStableFunc(order, func(a, b int) int { return remainders[b].Cmp(remainders[a]) })
+			for _, n := range order[:left] {
+				tax[indexes[n]]++
+			}
+		default:
+			for _, i := range indexes {
+				tax[i] = roundHalfUp(percentOf(net[i], rate))
+				taxCents += tax[i]
+			}
+		}
+
+		calc.Breakdown = append(calc.Breakdown, TaxBreakdown{
+			Rate: rate,
+			Net:  fromCents(netCents),
+			Tax:  fromCents(taxCents),
+		})
+	}
+
+	slices.SortFunc(calc.Breakdown, func(a, b TaxBreakdown) int { return cmp.Compare(b.Rate, a.Rate) })
+
+	var netTotal, taxTotal int64
+	for i := range calc.Lines {
+		line := &calc.Lines[i]
+		line.Net = fromCents(net[i])
+		line.Tax = fromCents(tax[i])
+		line.Gross = fromCents(net[i] + tax[i])
+		line.DisplayPrice = line.UnitPrice
+		if display == PriceDisplayInclusive {
+			unit := toCents(line.UnitPrice)
+			line.DisplayPrice = fromCents(unit + roundHalfUp(percentOf(unit, line.Rate)))
+		}
+
+		netTotal += net[i]
+		taxTotal += tax[i]
+	}
+	calc.Net = fromCents(netTotal)
+	calc.Tax = fromCents(taxTotal)
+	calc.Gross = fromCents(netTotal + taxTotal)
+
+	return calc, nil
+}
+
+// toCents returns an amount in whole cents; prices carry at most two decimals
+func toCents(amount Money) int64 {
+	return int64(math.Round(float64(amount) * 100))
+}
+
+func fromCents(cents int64) Money {
+	return Money(float64(cents) / 100)
+}
+
+// percentOf returns rate percent of an amount of cents, exactly. The rate is taken
+// as the decimal it is written as, so 7.25 is 725/10000 and not its binary fraction.
+func percentOf(cents int64, rate float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	return r.Mul(r, big.NewRat(cents, 100))
+}
+
+// floor returns the whole cents of a non-negative amount
+func floor(x *big.Rat) int64 {
+	return new(big.Int).Quo(x.Num(), x.Denom()).Int64()
+}
+
+// roundHalfUp rounds a non-negative amount to whole cents, halves up
+func roundHalfUp(x *big.Rat) int64 {
+	return floor(new(big.Rat).Add(x, big.NewRat(1, 2)))
+}
+
+// later returns the later of a and b
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
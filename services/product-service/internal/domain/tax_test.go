@@ -0,0 +1,212 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var taxDay = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func testTaxRates() []TaxRate {
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []TaxRate{
+		{Country: "DE", Class: "standard", Rate: 19, ValidFrom: from},
+		{Country: "DE", Class: "reduced", Rate: 7, ValidFrom: from},
+		{Country: "DE", Class: "exempt", Rate: 0, ValidFrom: from},
+		{Country: "DE", Class: "half", Rate: 50, ValidFrom: from},
+		{Country: "DE", Class: "tenth", Rate: 10, ValidFrom: from},
+		{Country: "US", Class: "standard", Rate: 6, ValidFrom: from},
+		{Country: "US", Region: "CA", Class: "standard", Rate: 7.25, ValidFrom: from},
+	}
+}
+
+func taxLine(class string, unitPrice Money, quantity int) TaxableLine {
+	return TaxableLine{ProductID: uuid.New(), Class: class, UnitPrice: unitPrice, Quantity: quantity}
+}
+
+// lineCents returns the cents of an amount of each line
+func lineCents(lines []TaxLine, amount func(TaxLine) Money) []int64 {
+	var cents []int64
+	for _, line := range lines {
+		cents = append(cents, toCents(amount(line)))
+	}
+	return cents
+}
+
+func TestCalculateTaxDisplayPrice(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination TaxDestination
+		class       string
+		unitPrice   Money
+		display     PriceDisplay
+		want        int64
+	}{
+		{"exclusive", TaxDestination{Country: "DE"}, "standard", 10, PriceDisplayExclusive, 1000},
+		{"inclusive", TaxDestination{Country: "DE"}, "standard", 10, PriceDisplayInclusive, 1190},
+		{"inclusive rounded", TaxDestination{Country: "DE"}, "reduced", 0.99, PriceDisplayInclusive, 106},
+		{"inclusive decimal rate", TaxDestination{Country: "US", Region: "CA"}, "standard", 9.99, PriceDisplayInclusive, 1071},
+		{"inclusive country rate", TaxDestination{Country: "US", Region: "NY"}, "standard", 9.99, PriceDisplayInclusive, 1059},
+		{"inclusive zero rate", TaxDestination{Country: "DE"}, "exempt", 4.99, PriceDisplayInclusive, 499},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc, err := CalculateTax([]TaxableLine{taxLine(tt.class, tt.unitPrice, 3)}, testTaxRates(), tt.destination, taxDay, tt.display, TaxRoundingLine)
+			if err != nil {
+				t.Fatalf("CalculateTax: %v", err)
+			}
+			if got := toCents(calc.Lines[0].DisplayPrice); got != tt.want {
+				t.Errorf("display price = %d cents, want %d", got, tt.want)
+			}
+			// The display mode does not change the amounts
+			if net := toCents(calc.Net); net != toCents(tt.unitPrice)*3 {
+				t.Errorf("net = %d cents, want %d", net, toCents(tt.unitPrice)*3)
+			}
+		})
+	}
+}
+
+func TestCalculateTaxRounding(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []TaxableLine
+		rounding TaxRounding
+		wantTax  []int64
+	}{
+		{
+			name:     "line rounds each half cent up",
+			lines:    []TaxableLine{taxLine("half", 0.01, 1), taxLine("half", 0.01, 1), taxLine("half", 0.01, 1)},
+			rounding: TaxRoundingLine,
+			wantTax:  []int64{1, 1, 1},
+		},
+		{
+			// 1.5 cents round to 2, shared out to the first lines on equal remainders
+			name:     "total shares remainder cents on ties",
+			lines:    []TaxableLine{taxLine("half", 0.01, 1), taxLine("half", 0.01, 1), taxLine("half", 0.01, 1)},
+			rounding: TaxRoundingTotal,
+			wantTax:  []int64{1, 1, 0},
+		},
+		{
+			name:     "line rounds exact half up",
+			lines:    []TaxableLine{taxLine("tenth", 1.25, 1)},
+			rounding: TaxRoundingLine,
+			wantTax:  []int64{13},
+		},
+		{
+			// 0.49 + 0.7 + 0.63 = 1.82 cents round to 2, the largest remainders first
+			name:     "total shares remainder cents to largest remainders",
+			lines:    []TaxableLine{taxLine("reduced", 0.07, 1), taxLine("reduced", 0.10, 1), taxLine("reduced", 0.09, 1)},
+			rounding: TaxRoundingTotal,
+			wantTax:  []int64{0, 1, 1},
+		},
+		{
+			name:     "zero rate",
+			lines:    []TaxableLine{taxLine("exempt", 19.99, 2), taxLine("exempt", 0.01, 1)},
+			rounding: TaxRoundingTotal,
+			wantTax:  []int64{0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc, err := CalculateTax(tt.lines, testTaxRates(), TaxDestination{Country: "DE"}, taxDay, PriceDisplayExclusive, tt.rounding)
+			if err != nil {
+				t.Fatalf("CalculateTax: %v", err)
+			}
+			if got := lineCents(calc.Lines, func(l TaxLine) Money { return l.Tax }); !slices.Equal(got, tt.wantTax) {
+				t.Errorf("line tax = %v cents, want %v", got, tt.wantTax)
+			}
+		})
+	}
+}
+
+func TestCalculateTaxMultipleRates(t *testing.T) {
+	lines := []TaxableLine{
+		taxLine("reduced", 2.49, 3),
+		taxLine("standard", 19.99, 1),
+		taxLine("exempt", 5, 2),
+		taxLine("reduced", 0.35, 1),
+	}
+
+	calc, err := CalculateTax(lines, testTaxRates(), TaxDestination{Country: "DE"}, taxDay, PriceDisplayExclusive, TaxRoundingTotal)
+	if err != nil {
+		t.Fatalf("CalculateTax: %v", err)
+	}
+
+	want := []struct {
+		rate     float64
+		net, tax int64
+	}{
+		// 19% of 19.99 is 379.81 cents
+		{19, 1999, 380},
+		// 7% of 7.47 + 0.35 is 54.74 cents
+		{7, 782, 55},
+		{0, 1000, 0},
+	}
+	if len(calc.Breakdown) != len(want) {
+		t.Fatalf("breakdown has %d rates, want %d", len(calc.Breakdown), len(want))
+	}
+	for i, w := range want {
+		b := calc.Breakdown[i]
+		if b.Rate != w.rate || toCents(b.Net) != w.net || toCents(b.Tax) != w.tax {
+			t.Errorf("breakdown %d = %v%% of %d cents is %d cents, want %v%% of %d cents is %d cents",
+				i, b.Rate, toCents(b.Net), toCents(b.Tax), w.rate, w.net, w.tax)
+		}
+	}
+
+	if got := lineCents(calc.Lines, func(l TaxLine) Money { return l.Tax }); !slices.Equal(got, []int64{52, 380, 0, 3}) {
+		t.Errorf("line tax = %v cents, want [52 380 0 3]", got)
+	}
+	if net, tax, gross := toCents(calc.Net), toCents(calc.Tax), toCents(calc.Gross); net != 3781 || tax != 435 || gross != 4216 {
+		t.Errorf("totals = %d + %d = %d cents, want 3781 + 435 = 4216", net, tax, gross)
+	}
+}
+
+func TestCalculateTaxLinesSumToTotals(t *testing.T) {
+	var lines []TaxableLine
+	for i, price := range []Money{0.01, 0.99, 1.05, 3.33, 7.77, 12.49, 0.5, 99.99} {
+		lines = append(lines, taxLine([]string{"standard", "reduced"}[i%2], price, i+1))
+	}
+
+	for _, rounding := range []TaxRounding{TaxRoundingLine, TaxRoundingTotal} {
+		t.Run(string(rounding), func(t *testing.T) {
+			calc, err := CalculateTax(lines, testTaxRates(), TaxDestination{Country: "DE"}, taxDay, PriceDisplayInclusive, rounding)
+			if err != nil {
+				t.Fatalf("CalculateTax: %v", err)
+			}
+
+			var net, tax, gross int64
+			for _, line := range calc.Lines {
+				net += toCents(line.Net)
+				tax += toCents(line.Tax)
+				gross += toCents(line.Gross)
+				if toCents(line.Net)+toCents(line.Tax) != toCents(line.Gross) {
+					t.Errorf("line %s: %v + %v != %v", line.ProductID, line.Net, line.Tax, line.Gross)
+				}
+			}
+			var breakdownTax int64
+			for _, b := range calc.Breakdown {
+				breakdownTax += toCents(b.Tax)
+			}
+
+			if net != toCents(calc.Net) || tax != toCents(calc.Tax) || gross != toCents(calc.Gross) {
+				t.Errorf("lines sum to %d + %d = %d cents, totals are %v + %v = %v", net, tax, gross, calc.Net, calc.Tax, calc.Gross)
+			}
+			if breakdownTax != tax {
+				t.Errorf("breakdown tax = %d cents, lines tax = %d", breakdownTax, tax)
+			}
+		})
+	}
+}
+
+func TestCalculateTaxWithoutRate(t *testing.T) {
+	_, err := CalculateTax([]TaxableLine{taxLine("luxury", 10, 1)}, testTaxRates(), TaxDestination{Country: "DE"}, taxDay, PriceDisplayExclusive, TaxRoundingLine)
+	if !errors.Is(err, ErrTaxRateNotFound) {
+		t.Errorf("CalculateTax error = %v, want ErrTaxRateNotFound", err)
+	}
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTranslationNotFound = errors.New("translation not found")
+	ErrInvalidTranslation  = errors.New("invalid translation")
+	// ErrDefaultLocale is returned when translating a product into the locale of its
+	// own name and description
+	ErrDefaultLocale = errors.New("products cannot be translated into the default locale")
+)
+
+// ProductTranslation holds the name and description of a product in a locale
+type ProductTranslation struct {
+	ProductID uuid.UUID
+	// Locale is a canonical BCP 47 tag, as de-AT
+	Locale      string
+	Name        string
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Translate replaces the name and description of the product with a translation
+func (p *Product) Translate(t *ProductTranslation) {
+	p.Name = t.Name
+	p.Description = t.Description
+	p.Locale = t.Locale
+	p.TranslatedAt = t.UpdatedAt
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"microservice/pkg/auth"
+	"microservice/pkg/logger"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/infrastructure/validator"
+	"microservice/services/product-service/internal/interfaces"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// APIKeyHandler serves the administration of the API keys of machine clients
+type APIKeyHandler struct {
+	service interfaces.APIKeyService
+	policy  *auth.Policy
+	logger  logger.Logger
+}
+
+func NewAPIKeyHandler(service interfaces.APIKeyService, policy *auth.Policy, logger logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		service: service,
+		policy:  policy,
+		logger:  logger,
+	}
+}
+
+// apiKeyValidation validates API key requests
+var apiKeyValidation = validator.NewPipeline[APIKeyRequest]().
+	Check(func(v *validator.Validator, req *APIKeyRequest) { req.Validate(v) })
+
+func (h *APIKeyHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/admin/api-keys", func(r chi.Router) {
+		r.Use(Authorize(h.policy, domain.PermissionManageAPIKeys, h.logger))
+		r.Get("/", h.ListAPIKeys)
+		r.Post("/", h.IssueAPIKey)
+		r.Delete("/{id}", h.RevokeAPIKey)
+	})
+}
+
+// IssueAPIKey godoc
+// @Summary Issue an API key
+// @Description Create an API key for a machine client. The key is only returned in this response; only its hash is stored.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body api.APIKeyRequest true "API key details"
+// @Success 201 {object} api.APIResponse{data=api.IssuedAPIKeyResponse} "Created"
+// @Failure 400 {object} api.Problem "Validation Error"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Router /admin/api-keys [post]
+func (h *APIKeyHandler) IssueAPIKey(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	req, errs, err := apiKeyValidation.Run(r.Context(), validator.DecodeJSON[APIKeyRequest](r.Body))
+	if err != nil {
+		RespondWithError(w, r, "failed to decode request body", http.StatusBadRequest)
+		return
+	}
+	if len(errs) > 0 {
+		RespondWithValidationErrors(w, r, errs)
+		return
+	}
+
+	key, secret, err := h.service.Issue(r.Context(), req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to issue api key", h.logger)
+		return
+	}
+
+	h.logger.Info("API key %s (%s) issued by %s with scopes %v", key.ID, key.Name, key.CreatedBy, key.Scopes)
+
+	w.Header().Set("Cache-Control", "no-store")
+	RespondWithJSON(w, http.StatusCreated, IssuedAPIKeyResponse{
+		APIKeyResponse: APIKeyResponseFromModel(key),
+		Key:            secret,
+	})
+}
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description List the API keys, newest first, including revoked and expired ones. Secrets are never returned.
+// @Tags api-keys
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param perPage query int false "Items per page" default(10)
+// @Success 200 {object} api.PaginatedResponse{items=[]api.APIKeyResponse} "Success"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Router /admin/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	params := ParseQueryParams(r)
+
+	keys, total, err := h.service.List(r.Context(), params.GetLimit(), params.GetOffset())
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to list api keys", h.logger)
+		return
+	}
+
+	items := make([]APIKeyResponse, len(keys))
+	for i, k := range keys {
+		items[i] = APIKeyResponseFromModel(k)
+	}
+
+	RespondWithPagination(w, items, params.Page, params.PerPage, total)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key; requests made with it are rejected from then on
+// @Tags api-keys
+// @Param id path string true "API key ID" format(uuid)
+// @Success 204 "No Content"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Router /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		RespondWithError(w, r, "invalid api key ID format", http.StatusBadRequest)
+		return
+	}
+
+	key, err := h.service.Revoke(r.Context(), id)
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to revoke api key", h.logger)
+		return
+	}
+
+	h.logger.Info("API key %s (%s) revoked by %s", key.ID, key.Name, domain.AuditContextFromContext(r.Context()).Actor)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"microservice/pkg/auth"
+	"microservice/pkg/logger"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/infrastructure/validator"
+	"microservice/services/product-service/internal/interfaces"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// BundleHandler serves the components of bundle products
+type BundleHandler struct {
+	service interfaces.BundleService
+	policy  *auth.Policy
+	logger  logger.Logger
+}
+
+func NewBundleHandler(service interfaces.BundleService, policy *auth.Policy, logger logger.Logger) *BundleHandler {
+	return &BundleHandler{
+		service: service,
+		policy:  policy,
+		logger:  logger,
+	}
+}
+
+// bundleValidation validates bundle requests
+var bundleValidation = validator.NewPipeline[BundleRequest]().
+	Check(func(v *validator.Validator, req *BundleRequest) { req.Validate(v) })
+
+func (h *BundleHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/products/{id}/bundle", func(r chi.Router) {
+		r.Get("/", h.GetBundle)
+		r.With(Authorize(h.policy, domain.PermissionUpdateProduct, h.logger)).Put("/", h.PutBundle)
+	})
+}
+
+// GetBundle godoc
+// @Summary Get the bundle of a product
+// @Description Get the pricing and components of a bundle product, with the unit price and availability of each component
+// @Tags bundles
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Success 200 {object} api.APIResponse{data=api.BundleResponse} "Success"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/bundle [get]
+func (h *BundleHandler) GetBundle(w http.ResponseWriter, r *http.Request) {
+	productID, ok := parseProductID(w, r)
+	if !ok {
+		return
+	}
+
+	bundle, err := h.service.Get(r.Context(), productID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to get bundle", h.logger)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, BundleResponseFromModel(bundle))
+}
+
+// PutBundle godoc
+// @Summary Set the bundle of a product
+// @Description Create or replace the components of a bundle product. A bundle is sold at the price of its product when priced fixed, or at a discount on the summed price of its components. Components must be simple products that are not archived, and cannot be deleted while the bundle is not archived.
+// @Tags bundles
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Param bundle body api.BundleRequest true "Bundle"
+// @Success 200 {object} api.APIResponse{data=api.BundleResponse} "Success"
+// @Failure 400 {object} api.Problem "Validation Error"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/bundle [put]
+func (h *BundleHandler) PutBundle(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	productID, ok := parseProductID(w, r)
+	if !ok {
+		return
+	}
+
+	req, errs, err := bundleValidation.Run(r.Context(), validator.DecodeJSON[BundleRequest](r.Body))
+	if err != nil {
+		RespondWithError(w, r, "failed to decode request body", http.StatusBadRequest)
+		return
+	}
+	if len(errs) > 0 {
+		RespondWithValidationErrors(w, r, errs)
+		return
+	}
+
+	bundle := req.ToModel(productID)
+	if err := h.service.Save(r.Context(), bundle); err != nil {
+		respondWithDomainError(w, r, err, "failed to save bundle", h.logger)
+		return
+	}
+
+	h.logger.Info("Bundle %s saved with %d components", productID, len(bundle.Components))
+
+	RespondWithJSON(w, http.StatusOK, BundleResponseFromModel(bundle))
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CacheControlConfig holds the Cache-Control directive of each cacheable route.
+// An empty directive leaves the header unset.
+type CacheControlConfig struct {
+	Product     string
+	ProductList string
+}
+
+// Validators identify the version of a representation for conditional requests
+type Validators struct {
+	// ETag is the entity tag without quotes; when empty a hash of the body is used
+	ETag string
+	// LastModified is sent as Last-Modified when set. Collections leave it unset, as
+	// removing an item from one does not make the remaining items newer.
+	LastModified time.Time
+	// CacheControl is sent as Cache-Control when set
+	CacheControl string
+}
+
+// VersionETag builds an entity tag from the parts that identify a version, e.g. an ID and its update time
+func VersionETag(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// RespondWithConditionalJSON sends a JSON response with ETag and Last-Modified
+// headers, or 304 Not Modified without a body when the client's copy is current
+func RespondWithConditionalJSON(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}, v Validators) {
+	jsonData, err := marshalResponse(statusCode, data)
+	if err != nil {
+		respondWithEncodingError(w)
+		return
+	}
+
+	etag := v.ETag
+	if etag == "" {
+		sum := sha256.Sum256(jsonData)
+		etag = hex.EncodeToString(sum[:16])
+	}
+	etag = `"` + etag + `"`
+
+	w.Header().Set("ETag", etag)
+	if v.CacheControl != "" {
+		w.Header().Set("Cache-Control", v.CacheControl)
+	}
+	if !v.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", v.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, v.LastModified) {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(jsonData)
+}
+
+// notModified evaluates If-None-Match and, when it is absent, If-Modified-Since (RFC 9110 section 13.2.2)
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, etag)
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// etagMatches reports whether the If-None-Match list contains etag, using weak comparison
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
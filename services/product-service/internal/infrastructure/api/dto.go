@@ -3,33 +3,28 @@ package api
 import (
 	"microservice/services/product-service/internal/domain"
 	"microservice/services/product-service/internal/infrastructure/validator"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type ProductRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	SKU         string  `json:"sku"`
-	CategoryID  string  `json:"category_id"`
+	Name        string  `json:"name" validate:"required,max=255"`
+	Description string  `json:"description" validate:"required"`
+	Price       float64 `json:"price" validate:"gte=0.01"`
+	SKU         string  `json:"sku" validate:"required,max=50"`
+	CategoryID  string  `json:"category_id" validate:"required,uuid"`
+	// Status defaults to published on create and is left unchanged on update when empty
+	Status string `json:"status,omitempty" enums:"draft,published,archived" validate:"omitempty,oneof=draft published archived"`
+	// Type defaults to simple on create and cannot change. The price of a bundle priced
+	// at a discount is derived from its components.
+	Type string `json:"type,omitempty" enums:"simple,bundle" validate:"omitempty,oneof=simple bundle"`
 }
 
 // Validate validates the ProductRequest
-func (p *ProductRequest) Validate(v *validator.Validator) uuid.UUID {
-	// Validate required fields
-	v.Required("name", p.Name)
-	v.Required("description", p.Description)
-	v.Required("sku", p.SKU)
-
-	// Validate price
-	v.MinValue("price", p.Price, 0.01)
-
-	// Validate and parse category ID
-	categoryID, _ := v.ValidUUID("category_id", p.CategoryID)
-
-	return categoryID
+func (p *ProductRequest) Validate(v *validator.Validator) {
+	v.Struct(p)
 }
 
 // services/product-service/internal/infrastructure/api/dto.go
@@ -47,6 +42,8 @@ func (p *ProductRequest) ToModel() (*domain.Product, error) {
 		Price:       domain.Money(p.Price),
 		SKU:         p.SKU,
 		CategoryID:  categoryID,
+		Status:      domain.ProductStatus(p.Status),
+		Type:        domain.ProductType(p.Type),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}, nil
@@ -58,23 +55,714 @@ type ProductResponse struct {
 	Description string  `json:"description"`
 	Price       float64 `json:"price"`
 	SKU         string  `json:"sku"`
+	Slug        string  `json:"slug" example:"smartphone-x"`
 	CategoryID  string  `json:"category_id"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+	Status      string  `json:"status"`
+	Type        string  `json:"type" enums:"simple,bundle"`
+	// Available tells whether the product can be sold: it is published and, for a
+	// bundle, so are all of its components
+	Available bool `json:"available"`
+	// Bundle is the composition of a bundle product
+	Bundle *BundleResponse `json:"bundle,omitempty"`
+	// Locale is the locale of the translated name and description; omitted for the
+	// untranslated content
+	Locale    string `json:"locale,omitempty" example:"de"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
 }
 
 // FromModel converts a domain.Product to a ProductResponse
 func ProductResponseFromModel(p *domain.Product) ProductResponse {
-	return ProductResponse{
+	response := ProductResponse{
 		ID:          p.ID.String(),
 		Name:        p.Name,
 		Description: p.Description,
 		Price:       float64(p.Price),
 		SKU:         p.SKU,
+		Slug:        p.Slug,
 		CategoryID:  p.CategoryID.String(),
+		Status:      string(p.Status),
+		Type:        string(p.Type),
+		Available:   p.IsAvailable(),
+		Locale:      p.Locale,
 		CreatedAt:   p.CreatedAt.Format(time.RFC1123),
 		UpdatedAt:   p.UpdatedAt.Format(time.RFC1123),
 	}
+	if p.Bundle != nil {
+		bundle := BundleResponseFromModel(p.Bundle)
+		response.Bundle = &bundle
+	}
+	return response
+}
+
+type TranslationRequest struct {
+	Name        string `json:"name" validate:"required,max=255"`
+	Description string `json:"description" validate:"required"`
+}
+
+// Validate validates the TranslationRequest
+func (t *TranslationRequest) Validate(v *validator.Validator) {
+	v.Struct(t)
+}
+
+// ToModel converts a TranslationRequest to the translation of a product in a locale
+func (t *TranslationRequest) ToModel(productID uuid.UUID, locale string) *domain.ProductTranslation {
+	return &domain.ProductTranslation{
+		ProductID:   productID,
+		Locale:      locale,
+		Name:        t.Name,
+		Description: t.Description,
+	}
+}
+
+type TranslationResponse struct {
+	ProductID   string `json:"product_id"`
+	Locale      string `json:"locale" example:"de-AT"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// TranslationResponseFromModel converts a domain.ProductTranslation to a TranslationResponse
+func TranslationResponseFromModel(t *domain.ProductTranslation) TranslationResponse {
+	return TranslationResponse{
+		ProductID:   t.ProductID.String(),
+		Locale:      t.Locale,
+		Name:        t.Name,
+		Description: t.Description,
+		CreatedAt:   t.CreatedAt.Format(time.RFC1123),
+		UpdatedAt:   t.UpdatedAt.Format(time.RFC1123),
+	}
+}
+
+type BundleRequest struct {
+	Pricing string `json:"pricing" enums:"fixed,discount" validate:"required,oneof=fixed discount"`
+	// DiscountPercent is taken off the summed price of the components; only for discount pricing
+	DiscountPercent float64                  `json:"discount_percent" validate:"gte=0,lte=100"`
+	Components      []BundleComponentRequest `json:"components" validate:"required,min=1"`
+}
+
+type BundleComponentRequest struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	Quantity  int    `json:"quantity" validate:"required,min=1"`
+}
+
+// Validate validates the BundleRequest
+func (b *BundleRequest) Validate(v *validator.Validator) {
+	v.Struct(b)
+}
+
+// ToModel converts a BundleRequest to the bundle of the product
+func (b *BundleRequest) ToModel(productID uuid.UUID) *domain.Bundle {
+	components := make([]domain.BundleComponent, len(b.Components))
+	for i, c := range b.Components {
+		components[i] = domain.BundleComponent{
+			ProductID: uuid.MustParse(c.ProductID),
+			Quantity:  c.Quantity,
+		}
+	}
+
+	return &domain.Bundle{
+		ProductID:       productID,
+		Pricing:         domain.BundlePricing(b.Pricing),
+		DiscountPercent: b.DiscountPercent,
+		Components:      components,
+	}
+}
+
+type BundleResponse struct {
+	Pricing         string                    `json:"pricing" enums:"fixed,discount"`
+	DiscountPercent float64                   `json:"discount_percent"`
+	Components      []BundleComponentResponse `json:"components"`
+	UpdatedAt       string                    `json:"updated_at"`
+}
+
+type BundleComponentResponse struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	// Price is the unit price of the component
+	Price     float64 `json:"price"`
+	Available bool    `json:"available"`
+}
+
+// BundleResponseFromModel converts a domain.Bundle to a BundleResponse
+func BundleResponseFromModel(b *domain.Bundle) BundleResponse {
+	components := make([]BundleComponentResponse, len(b.Components))
+	for i, c := range b.Components {
+		components[i] = BundleComponentResponse{
+			ProductID: c.ProductID.String(),
+			Quantity:  c.Quantity,
+			Price:     float64(c.Price),
+			Available: c.Available,
+		}
+	}
+
+	return BundleResponse{
+		Pricing:         string(b.Pricing),
+		DiscountPercent: b.DiscountPercent,
+		Components:      components,
+		UpdatedAt:       b.UpdatedAt.Format(time.RFC1123),
+	}
+}
+
+type RelationRequest struct {
+	RelatedID string `json:"related_id" validate:"required,uuid"`
+	Type      string `json:"type" enums:"related,cross_sell,up_sell,accessory" validate:"required,oneof=related cross_sell up_sell accessory"`
+	// Position is the 0-based place of the related product in the list; appended if omitted
+	Position *int `json:"position,omitempty" validate:"omitempty,min=0"`
+	// Bidirectional relations are mirrored from the related product; only for related and cross_sell
+	Bidirectional bool `json:"bidirectional"`
+}
+
+// Validate validates the RelationRequest
+func (r *RelationRequest) Validate(v *validator.Validator) {
+	v.Struct(r)
+}
+
+// ToModel converts a RelationRequest to a relation of the product
+func (r *RelationRequest) ToModel(productID uuid.UUID) *domain.ProductRelation {
+	position := -1
+	if r.Position != nil {
+		position = *r.Position
+	}
+
+	return &domain.ProductRelation{
+		ProductID:     productID,
+		RelatedID:     uuid.MustParse(r.RelatedID),
+		Type:          domain.RelationType(r.Type),
+		Position:      position,
+		Bidirectional: r.Bidirectional,
+	}
+}
+
+type RelationUpdateRequest struct {
+	Position      *int `json:"position" validate:"required,min=0"`
+	Bidirectional bool `json:"bidirectional"`
+}
+
+// Validate validates the RelationUpdateRequest
+func (u *RelationUpdateRequest) Validate(v *validator.Validator) {
+	v.Struct(u)
+}
+
+type RelationResponse struct {
+	ProductID     string `json:"product_id"`
+	RelatedID     string `json:"related_id"`
+	Type          string `json:"type" enums:"related,cross_sell,up_sell,accessory"`
+	Position      int    `json:"position"`
+	Bidirectional bool   `json:"bidirectional"`
+	// Product is the related product
+	Product   *ProductResponse `json:"product,omitempty"`
+	CreatedAt string           `json:"created_at"`
+	UpdatedAt string           `json:"updated_at"`
+}
+
+// RelationResponseFromModel converts a domain.ProductRelation to a RelationResponse
+func RelationResponseFromModel(r *domain.ProductRelation) RelationResponse {
+	response := RelationResponse{
+		ProductID:     r.ProductID.String(),
+		RelatedID:     r.RelatedID.String(),
+		Type:          string(r.Type),
+		Position:      r.Position,
+		Bidirectional: r.Bidirectional,
+		CreatedAt:     r.CreatedAt.Format(time.RFC1123),
+		UpdatedAt:     r.UpdatedAt.Format(time.RFC1123),
+	}
+	if r.Related != nil {
+		product := ProductResponseFromModel(r.Related)
+		response.Product = &product
+	}
+	return response
+}
+
+type FieldChangeResponse struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+type AuditEntryResponse struct {
+	ID        string                `json:"id"`
+	ProductID string                `json:"product_id"`
+	Action    string                `json:"action"`
+	Actor     string                `json:"actor"`
+	RequestID string                `json:"request_id"`
+	Changes   []FieldChangeResponse `json:"changes"`
+	CreatedAt string                `json:"created_at"`
+}
+
+// AuditEntryResponseFromModel converts a domain.AuditEntry to an AuditEntryResponse
+func AuditEntryResponseFromModel(e *domain.AuditEntry) AuditEntryResponse {
+	changes := make([]FieldChangeResponse, len(e.Changes))
+	for i, c := range e.Changes {
+		changes[i] = FieldChangeResponse{Field: c.Field, Old: c.Old, New: c.New}
+	}
+
+	return AuditEntryResponse{
+		ID:        e.ID.String(),
+		ProductID: e.ProductID.String(),
+		Action:    string(e.Action),
+		Actor:     e.Actor,
+		RequestID: e.RequestID,
+		Changes:   changes,
+		CreatedAt: e.CreatedAt.Format(time.RFC1123),
+	}
+}
+
+func init() {
+	validator.RegisterRule("api_key_scope", func(f validator.Field) bool {
+		return domain.ValidAPIKeyScope(f.Value.String())
+	}, "{field} must be one of: "+strings.Join(domain.APIKeyScopes, ", "))
+}
+
+type APIKeyRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"min=1,dive,api_key_scope"`
+	// ExpiresAt is optional; keys without one never expire
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Validate validates the APIKeyRequest
+func (k *APIKeyRequest) Validate(v *validator.Validator) {
+	v.Struct(k)
+
+	if k.ExpiresAt != nil {
+		v.CheckRule(k.ExpiresAt.After(time.Now()), "expires_at", "future", nil)
+	}
+}
+
+type APIKeyResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedBy  string   `json:"created_by"`
+	ExpiresAt  *string  `json:"expires_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	RevokedAt  *string  `json:"revoked_at"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// IssuedAPIKeyResponse is returned once, when a key is issued
+type IssuedAPIKeyResponse struct {
+	APIKeyResponse
+	// Key is the secret to send in the X-API-Key header; it cannot be retrieved again
+	Key string `json:"key"`
+}
+
+// APIKeyResponseFromModel converts a domain.APIKey to an APIKeyResponse, leaving out its hash
+func APIKeyResponseFromModel(k *domain.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         k.ID.String(),
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.Scopes,
+		CreatedBy:  k.CreatedBy,
+		ExpiresAt:  formatOptionalTime(k.ExpiresAt),
+		LastUsedAt: formatOptionalTime(k.LastUsedAt),
+		RevokedAt:  formatOptionalTime(k.RevokedAt),
+		CreatedAt:  k.CreatedAt.Format(time.RFC1123),
+	}
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	s := t.Format(time.RFC1123)
+	return &s
+}
+
+type PromotionRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+	Kind string `json:"kind" enums:"percentage,fixed,buy_x_get_y" validate:"required,oneof=percentage fixed buy_x_get_y"`
+	// Value is the amount off of a fixed discount and the percentage off otherwise;
+	// 100 makes the get_quantity units of a buy_x_get_y discount free
+	Value float64 `json:"value" validate:"required,gt=0"`
+	// BuyQuantity and GetQuantity are the X and Y of a buy_x_get_y discount
+	BuyQuantity int `json:"buy_quantity" validate:"required_if=Kind buy_x_get_y,gte=0"`
+	GetQuantity int `json:"get_quantity" validate:"required_if=Kind buy_x_get_y,gte=0"`
+	// CategoryIDs and SKUs restrict the promotion to matching items; without either it targets every item
+	CategoryIDs []string `json:"category_ids" validate:"max=100,dive,uuid"`
+	SKUs        []string `json:"skus" validate:"max=100,dive,required,max=100"`
+	MinSpend    float64  `json:"min_spend" validate:"gte=0"`
+	// CouponCode restricts the promotion to carts presenting it, case-insensitively; without one it applies automatically
+	CouponCode string `json:"coupon_code,omitempty" validate:"max=50"`
+	// UsageLimit caps the redemptions of the promotion; unlimited if omitted
+	UsageLimit *int `json:"usage_limit,omitempty" validate:"omitempty,min=1"`
+	// Priority orders the promotions, highest first
+	Priority int `json:"priority"`
+	// Exclusive promotions do not stack with any other
+	Exclusive bool `json:"exclusive"`
+	// Active defaults to true
+	Active   *bool      `json:"active,omitempty"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// Validate validates the PromotionRequest
+func (p *PromotionRequest) Validate(v *validator.Validator) {
+	v.Struct(p)
+
+	if p.Kind != string(domain.DiscountFixed) {
+		v.CheckRule(p.Value <= 100, "value", "lte", validator.Params{"param": "100"})
+	}
+	if p.StartsAt != nil && p.EndsAt != nil {
+		v.CheckRule(p.EndsAt.After(*p.StartsAt), "ends_at", "gtfield", validator.Params{"other": "starts_at"})
+	}
+}
+
+// ToModel converts a PromotionRequest to a domain.Promotion
+func (p *PromotionRequest) ToModel() *domain.Promotion {
+	categoryIDs := make([]uuid.UUID, len(p.CategoryIDs))
+	for i, id := range p.CategoryIDs {
+		categoryIDs[i] = uuid.MustParse(id)
+	}
+
+	active := true
+	if p.Active != nil {
+		active = *p.Active
+	}
+
+	return &domain.Promotion{
+		Name:        p.Name,
+		Kind:        domain.DiscountKind(p.Kind),
+		Value:       p.Value,
+		BuyQuantity: p.BuyQuantity,
+		GetQuantity: p.GetQuantity,
+		CategoryIDs: categoryIDs,
+		SKUs:        p.SKUs,
+		MinSpend:    domain.Money(p.MinSpend),
+		CouponCode:  p.CouponCode,
+		UsageLimit:  p.UsageLimit,
+		Priority:    p.Priority,
+		Exclusive:   p.Exclusive,
+		Active:      active,
+		StartsAt:    p.StartsAt,
+		EndsAt:      p.EndsAt,
+	}
+}
+
+type PromotionResponse struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind" enums:"percentage,fixed,buy_x_get_y"`
+	Value       float64  `json:"value"`
+	BuyQuantity int      `json:"buy_quantity"`
+	GetQuantity int      `json:"get_quantity"`
+	CategoryIDs []string `json:"category_ids"`
+	SKUs        []string `json:"skus"`
+	MinSpend    float64  `json:"min_spend"`
+	CouponCode  string   `json:"coupon_code,omitempty"`
+	UsageLimit  *int     `json:"usage_limit"`
+	UsageCount  int      `json:"usage_count"`
+	Priority    int      `json:"priority"`
+	Exclusive   bool     `json:"exclusive"`
+	Active      bool     `json:"active"`
+	StartsAt    *string  `json:"starts_at"`
+	EndsAt      *string  `json:"ends_at"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+// PromotionResponseFromModel converts a domain.Promotion to a PromotionResponse
+func PromotionResponseFromModel(p *domain.Promotion) PromotionResponse {
+	categoryIDs := make([]string, len(p.CategoryIDs))
+	for i, id := range p.CategoryIDs {
+		categoryIDs[i] = id.String()
+	}
+
+	skus := p.SKUs
+	if skus == nil {
+		skus = []string{}
+	}
+
+	return PromotionResponse{
+		ID:          p.ID.String(),
+		Name:        p.Name,
+		Kind:        string(p.Kind),
+		Value:       p.Value,
+		BuyQuantity: p.BuyQuantity,
+		GetQuantity: p.GetQuantity,
+		CategoryIDs: categoryIDs,
+		SKUs:        skus,
+		MinSpend:    float64(p.MinSpend),
+		CouponCode:  p.CouponCode,
+		UsageLimit:  p.UsageLimit,
+		UsageCount:  p.UsageCount,
+		Priority:    p.Priority,
+		Exclusive:   p.Exclusive,
+		Active:      p.Active,
+		StartsAt:    formatOptionalTime(p.StartsAt),
+		EndsAt:      formatOptionalTime(p.EndsAt),
+		CreatedAt:   p.CreatedAt.Format(time.RFC1123),
+		UpdatedAt:   p.UpdatedAt.Format(time.RFC1123),
+	}
+}
+
+type EvaluateRequest struct {
+	Items   []CartItemRequest `json:"items" validate:"required,min=1,max=100"`
+	Coupons []string          `json:"coupons" validate:"max=10,dive,required,max=50"`
+	// Redeem counts a use of the applied promotions, when the cart is checked out
+	Redeem bool `json:"redeem"`
+}
+
+type CartItemRequest struct {
+	ProductID string `json:"product_id" validate:"required,uuid"`
+	Quantity  int    `json:"quantity" validate:"required,min=1,max=1000"`
+}
+
+// Validate validates the EvaluateRequest
+func (e *EvaluateRequest) Validate(v *validator.Validator) {
+	v.Struct(e)
+}
+
+// ToModel converts the items of an EvaluateRequest to cart items
+func (e *EvaluateRequest) ToModel() []domain.CartItem {
+	items := make([]domain.CartItem, len(e.Items))
+	for i, item := range e.Items {
+		items[i] = domain.CartItem{
+			ProductID: uuid.MustParse(item.ProductID),
+			Quantity:  item.Quantity,
+		}
+	}
+	return items
+}
+
+type QuoteResponse struct {
+	Items    []QuoteItemResponse `json:"items"`
+	Subtotal float64             `json:"subtotal"`
+	Discount float64             `json:"discount"`
+	Total    float64             `json:"total"`
+	// AppliedPromotions lists the promotions that adjusted an item, in the order they applied
+	AppliedPromotions []AppliedPromotionResponse `json:"applied_promotions"`
+	RejectedCoupons   []RejectedCouponResponse   `json:"rejected_coupons"`
+}
+
+type QuoteItemResponse struct {
+	ProductID string  `json:"product_id"`
+	SKU       string  `json:"sku"`
+	UnitPrice float64 `json:"unit_price"`
+	Quantity  int     `json:"quantity"`
+	Subtotal  float64 `json:"subtotal"`
+	// Adjustments are the discounts taken off the item, in the order they applied
+	Adjustments []AdjustmentResponse `json:"adjustments"`
+	Total       float64              `json:"total"`
+}
+
+type AdjustmentResponse struct {
+	PromotionID string  `json:"promotion_id"`
+	CouponCode  string  `json:"coupon_code,omitempty"`
+	Amount      float64 `json:"amount"`
+	Reason      string  `json:"reason" example:"15% off: Summer sale"`
+}
+
+type AppliedPromotionResponse struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	CouponCode string `json:"coupon_code,omitempty"`
+}
+
+type RejectedCouponResponse struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason" enums:"unknown,usage_limit_reached,min_spend_not_reached,no_eligible_items,excluded"`
+}
+
+// QuoteResponseFromModel converts a domain.Quote to a QuoteResponse
+func QuoteResponseFromModel(q *domain.Quote) QuoteResponse {
+	items := make([]QuoteItemResponse, len(q.Lines))
+	for i, line := range q.Lines {
+		adjustments := make([]AdjustmentResponse, len(line.Adjustments))
+		for j, a := range line.Adjustments {
+			adjustments[j] = AdjustmentResponse{
+				PromotionID: a.PromotionID.String(),
+				CouponCode:  a.CouponCode,
+				Amount:      float64(a.Amount),
+				Reason:      a.Reason,
+			}
+		}
+
+		items[i] = QuoteItemResponse{
+			ProductID:   line.ProductID.String(),
+			SKU:         line.SKU,
+			UnitPrice:   float64(line.UnitPrice),
+			Quantity:    line.Quantity,
+			Subtotal:    float64(line.Subtotal),
+			Adjustments: adjustments,
+			Total:       float64(line.Total),
+		}
+	}
+
+	applied := make([]AppliedPromotionResponse, len(q.Applied))
+	for i, p := range q.Applied {
+		applied[i] = AppliedPromotionResponse{
+			ID:         p.ID.String(),
+			Name:       p.Name,
+			CouponCode: p.CouponCode,
+		}
+	}
+
+	rejected := make([]RejectedCouponResponse, len(q.RejectedCoupons))
+	for i, c := range q.RejectedCoupons {
+		rejected[i] = RejectedCouponResponse{Code: c.Code, Reason: c.Reason}
+	}
+
+	return QuoteResponse{
+		Items:             items,
+		Subtotal:          float64(q.Subtotal),
+		Discount:          float64(q.Discount),
+		Total:             float64(q.Total),
+		AppliedPromotions: applied,
+		RejectedCoupons:   rejected,
+	}
+}
+
+type TaxRateResponse struct {
+	Country string `json:"country" example:"US"`
+	// Region is empty for the rate of the whole country
+	Region    string  `json:"region" example:"CA"`
+	TaxClass  string  `json:"tax_class" example:"standard"`
+	Rate      float64 `json:"rate" example:"7.25"`
+	ValidFrom string  `json:"valid_from" example:"2017-01-01"`
+	// ValidTo is the first day the rate no longer applies; null while it has no end
+	ValidTo *string `json:"valid_to"`
+}
+
+// TaxRateResponseFromModel converts a domain.TaxRate to a TaxRateResponse
+func TaxRateResponseFromModel(r domain.TaxRate) TaxRateResponse {
+	response := TaxRateResponse{
+		Country:   r.Country,
+		Region:    r.Region,
+		TaxClass:  r.Class,
+		Rate:      r.Rate,
+		ValidFrom: r.ValidFrom.Format(time.DateOnly),
+	}
+	if r.ValidTo != nil {
+		validTo := r.ValidTo.Format(time.DateOnly)
+		response.ValidTo = &validTo
+	}
+	return response
+}
+
+type TaxClassRequest struct {
+	TaxClass string `json:"tax_class" example:"reduced" validate:"required,max=50,regex=^[a-z][a-z0-9_]*$"`
+}
+
+// Validate validates the TaxClassRequest
+func (t *TaxClassRequest) Validate(v *validator.Validator) {
+	v.Struct(t)
+}
+
+type TaxClassResponse struct {
+	TaxClass string `json:"tax_class" example:"reduced"`
+	// Source tells whether the class is the one of the product, of its category or the default
+	Source string `json:"source" enums:"product,category,default"`
+}
+
+type TaxCalculationRequest struct {
+	Items []CartItemRequest `json:"items" validate:"required,min=1,max=100"`
+	// Country is the ISO 3166-1 alpha-2 code of the destination
+	Country string `json:"country" example:"US" validate:"required,len=2,regex=^[A-Za-z]+$"`
+	// Region is the subdivision part of the ISO 3166-2 code of the destination, as CA for US-CA
+	Region string `json:"region,omitempty" example:"CA" validate:"max=3,regex=^[A-Za-z0-9]*$"`
+	// Date selects the rates applying then; now if omitted
+	Date *time.Time `json:"date,omitempty"`
+	// Display and Rounding default to the configuration of the service
+	Display  string `json:"display,omitempty" enums:"inclusive,exclusive" validate:"omitempty,oneof=inclusive exclusive"`
+	Rounding string `json:"rounding,omitempty" enums:"line,total" validate:"omitempty,oneof=line total"`
+}
+
+// Validate validates the TaxCalculationRequest
+func (t *TaxCalculationRequest) Validate(v *validator.Validator) {
+	v.Struct(t)
+}
+
+// ToModel converts the items of a TaxCalculationRequest to cart items
+func (t *TaxCalculationRequest) ToModel() []domain.CartItem {
+	items := make([]domain.CartItem, len(t.Items))
+	for i, item := range t.Items {
+		items[i] = domain.CartItem{
+			ProductID: uuid.MustParse(item.ProductID),
+			Quantity:  item.Quantity,
+		}
+	}
+	return items
+}
+
+// Destination returns the destination of a TaxCalculationRequest
+func (t *TaxCalculationRequest) Destination() domain.TaxDestination {
+	return domain.TaxDestination{
+		Country: strings.ToUpper(t.Country),
+		Region:  strings.ToUpper(t.Region),
+	}
+}
+
+type TaxCalculationResponse struct {
+	Country  string            `json:"country"`
+	Region   string            `json:"region,omitempty"`
+	Display  string            `json:"display" enums:"inclusive,exclusive"`
+	Rounding string            `json:"rounding" enums:"line,total"`
+	Items    []TaxItemResponse `json:"items"`
+	// Breakdown sums the items by rate, highest rate first
+	Breakdown []TaxBreakdownResponse `json:"breakdown"`
+	Net       float64                `json:"net"`
+	Tax       float64                `json:"tax"`
+	Gross     float64                `json:"gross"`
+}
+
+type TaxItemResponse struct {
+	ProductID string  `json:"product_id"`
+	TaxClass  string  `json:"tax_class"`
+	Rate      float64 `json:"rate"`
+	Quantity  int     `json:"quantity"`
+	// UnitPrice is the price without tax; DisplayPrice is the unit price in the display mode
+	UnitPrice    float64 `json:"unit_price"`
+	DisplayPrice float64 `json:"display_price"`
+	Net          float64 `json:"net"`
+	Tax          float64 `json:"tax"`
+	Gross        float64 `json:"gross"`
+}
+
+type TaxBreakdownResponse struct {
+	Rate float64 `json:"rate"`
+	Net  float64 `json:"net"`
+	Tax  float64 `json:"tax"`
+}
+
+// TaxCalculationResponseFromModel converts a domain.TaxCalculation to a TaxCalculationResponse
+func TaxCalculationResponseFromModel(c *domain.TaxCalculation) TaxCalculationResponse {
+	items := make([]TaxItemResponse, len(c.Lines))
+	for i, line := range c.Lines {
+		items[i] = TaxItemResponse{
+			ProductID:    line.ProductID.String(),
+			TaxClass:     line.Class,
+			Rate:         line.Rate,
+			Quantity:     line.Quantity,
+			UnitPrice:    float64(line.UnitPrice),
+			DisplayPrice: float64(line.DisplayPrice),
+			Net:          float64(line.Net),
+			Tax:          float64(line.Tax),
+			Gross:        float64(line.Gross),
+		}
+	}
+
+	breakdown := make([]TaxBreakdownResponse, len(c.Breakdown))
+	for i, b := range c.Breakdown {
+		breakdown[i] = TaxBreakdownResponse{Rate: b.Rate, Net: float64(b.Net), Tax: float64(b.Tax)}
+	}
+
+	return TaxCalculationResponse{
+		Country:   c.Destination.Country,
+		Region:    c.Destination.Region,
+		Display:   string(c.Display),
+		Rounding:  string(c.Rounding),
+		Items:     items,
+		Breakdown: breakdown,
+		Net:       float64(c.Net),
+		Tax:       float64(c.Tax),
+		Gross:     float64(c.Gross),
+	}
 }
 
 // Note: APIResponse has been moved to response.go
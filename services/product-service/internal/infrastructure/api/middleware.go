@@ -2,9 +2,16 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"microservice/pkg/auth"
 	"microservice/pkg/config"
+	"microservice/pkg/idempotency"
+	"microservice/pkg/locale"
 	"microservice/pkg/logger"
+	"microservice/pkg/ratelimit"
+	"microservice/pkg/tenant"
+	"microservice/services/product-service/internal/domain"
 	"net/http"
 	"time"
 
@@ -48,6 +55,185 @@ func RequestID(next http.Handler) http.Handler {
 	})
 }
 
+// Audit adds the acting user and request ID to the context for the audit trail. The
+// actor is the subject of the token or API key of the request, and anonymous without
+// one; clients cannot name it. It must run after RequestID and Authenticate.
+func Audit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID, _ := r.Context().Value(middleware.RequestIDKey).(string)
+
+		var actor string
+		if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+			actor = principal.Subject
+		}
+
+		ctx := domain.WithAuditContext(r.Context(), domain.AuditContext{
+			Actor:     actor,
+			RequestID: requestID,
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Authenticate adds the principal of requests with a valid API key or bearer token
+// to the context and rejects requests with invalid credentials with 401 Unauthorized.
+// An X-API-Key header takes precedence over the Authorization header. Requests
+// without credentials continue anonymously; Authorize decides what they may do.
+// Bearer tokens are ignored when verifier is nil.
+func Authenticate(verifier *auth.Verifier, keys auth.APIKeyAuthenticator, logger logger.Logger) MiddlewareFunc {
+	onError := func(w http.ResponseWriter, r *http.Request, err error) {
+		if !errors.Is(err, auth.ErrInvalidAPIKey) && !errors.Is(err, auth.ErrInvalidToken) && !errors.Is(err, auth.ErrMissingToken) {
+			logger.Error("Failed to authenticate %s %s: %v", r.Method, r.URL.Path, err)
+			RespondWithError(w, r, "failed to authenticate request", http.StatusInternalServerError)
+			return
+		}
+
+		logger.Warn("Authentication failed for %s %s: %v", r.Method, r.URL.Path, err)
+		RespondWithError(w, r, auth.Message(err), http.StatusUnauthorized)
+	}
+
+	apiKey := auth.APIKeyMiddleware(keys, onError)
+	bearer := func(next http.Handler) http.Handler { return next }
+	if verifier != nil {
+		bearer = auth.OptionalMiddleware(verifier, onError)
+	}
+
+	return func(next http.Handler) http.Handler {
+		withAPIKey, withBearer := apiKey(next), bearer(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(auth.APIKeyHeader) != "" {
+				withAPIKey.ServeHTTP(w, r)
+				return
+			}
+			withBearer.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Authorize lets only callers holding permission through
+func Authorize(policy *auth.Policy, permission string, logger logger.Logger) MiddlewareFunc {
+	return policy.Require(permission, func(w http.ResponseWriter, r *http.Request, err error) {
+		respondWithAuthError(w, r, err, logger)
+	})
+}
+
+// respondWithAuthError responds 401 to anonymous callers and 403 to callers lacking a
+// permission, logging the denial. It reports whether err was an authorization error.
+func respondWithAuthError(w http.ResponseWriter, r *http.Request, err error, logger logger.Logger) bool {
+	switch {
+	case errors.Is(err, auth.ErrMissingToken):
+		w.Header().Set("WWW-Authenticate", auth.Challenge(err))
+		RespondWithError(w, r, auth.Message(err), http.StatusUnauthorized)
+	case errors.Is(err, auth.ErrForbidden):
+		principal, _ := auth.PrincipalFromContext(r.Context())
+		logger.Warn("Access denied: method=%s path=%s subject=%s roles=%v scopes=%v error=%q",
+			r.Method, r.URL.Path, principal.Subject, principal.Roles, principal.Scopes, err.Error())
+		RespondWithError(w, r, "you do not have permission to perform this action", http.StatusForbidden)
+	default:
+		return false
+	}
+	return true
+}
+
+// Tenant adds the tenant of each request to the context, rejecting requests naming an
+// invalid tenant or none with 400 Bad Request and requests naming another tenant than
+// their credentials, or with credentials bound to none, with 403 Forbidden. It must
+// run after Authenticate.
+func Tenant(resolver *tenant.Resolver, logger logger.Logger) MiddlewareFunc {
+	return resolver.Middleware(func(w http.ResponseWriter, r *http.Request, err error) {
+		p, ok := Problems.Problem(err)
+		if !ok {
+			logger.Error("Failed to resolve tenant of %s %s: %v", r.Method, r.URL.Path, err)
+			RespondWithError(w, r, "failed to resolve tenant", http.StatusInternalServerError)
+			return
+		}
+
+		switch {
+		case errors.Is(err, tenant.ErrMissingTenant):
+			p.Detail = fmt.Sprintf("tenant is required, set the %s header", resolver.Header())
+		case errors.Is(err, tenant.ErrInvalidTenant):
+			p.Detail = "tenant must be a lowercase DNS label of at most 63 characters"
+		case errors.Is(err, tenant.ErrTenantMismatch), errors.Is(err, tenant.ErrUnboundCredentials):
+			principal, _ := auth.PrincipalFromContext(r.Context())
+			logger.Warn("Tenant denied: method=%s path=%s subject=%s tenant=%s",
+				r.Method, r.URL.Path, principal.Subject, principal.Tenant)
+		}
+		RespondWithProblem(w, r, p)
+	})
+}
+
+// Locale adds the locale chain of each request to the context, rejecting requests
+// naming an invalid locale in the query parameter with 400 Bad Request
+func Locale(resolver *locale.Resolver, logger logger.Logger) MiddlewareFunc {
+	return resolver.Middleware(func(w http.ResponseWriter, r *http.Request, err error) {
+		p, ok := Problems.Problem(err)
+		if !ok {
+			logger.Error("Failed to resolve locale of %s %s: %v", r.Method, r.URL.Path, err)
+			RespondWithError(w, r, "failed to resolve locale", http.StatusInternalServerError)
+			return
+		}
+
+		p.Detail = fmt.Sprintf("%s must be a BCP 47 language tag, as de-AT", resolver.Param())
+		RespondWithProblem(w, r, p)
+	})
+}
+
+// RateLimit rejects clients over their quota with 429 Too Many Requests. It must run
+// after Authenticate and Tenant so authenticated clients are counted for their
+// principal in their tenant.
+func RateLimit(limiter *ratelimit.Limiter, trustForwarded bool, logger logger.Logger) MiddlewareFunc {
+	client := ratelimit.ClientKey(trustForwarded)
+	return limiter.Middleware(client, onRateLimit(client, logger), onRateLimitError(logger))
+}
+
+// AddressRateLimit responds 429 Too Many Requests to addresses over the address
+// limit. It must run before Authenticate, so that requests with wrong credentials
+// are counted.
+func AddressRateLimit(limiter *ratelimit.Limiter, trustForwarded bool, logger logger.Logger) MiddlewareFunc {
+	address := ratelimit.AddressKey(trustForwarded)
+	return limiter.AddressMiddleware(address, onRateLimit(address, logger), onRateLimitError(logger))
+}
+
+func onRateLimit(client ratelimit.KeyFunc, logger logger.Logger) ratelimit.LimitHandler {
+	return func(w http.ResponseWriter, r *http.Request, result ratelimit.Result) {
+		logger.Warn("Rate limit exceeded: method=%s path=%s client=%s limit=%s",
+			r.Method, r.URL.Path, client(r), result.Limit)
+		RespondWithError(w, r, fmt.Sprintf("rate limit exceeded, retry in %ss", w.Header().Get("Retry-After")),
+			http.StatusTooManyRequests)
+	}
+}
+
+func onRateLimitError(logger logger.Logger) ratelimit.ErrorHandler {
+	return func(r *http.Request, err error) {
+		logger.Error("Failed to apply rate limit to %s %s: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+// Idempotency replays the stored response to POST requests retried with the same
+// Idempotency-Key. It must run after Authenticate, as keys are scoped to the principal.
+func Idempotency(store idempotency.Store, opts idempotency.Options, logger logger.Logger) MiddlewareFunc {
+	return idempotency.Middleware(store, opts, func(w http.ResponseWriter, r *http.Request, err error) {
+		p, ok := Problems.Problem(err)
+		if !ok {
+			logger.Error("Failed to process idempotency key of %s %s: %v", r.Method, r.URL.Path, err)
+			RespondWithError(w, r, "failed to process idempotency key", http.StatusInternalServerError)
+			return
+		}
+
+		switch {
+		case errors.Is(err, idempotency.ErrInvalidKey):
+			p.Detail = fmt.Sprintf("idempotency key must be at most %d characters", idempotency.MaxKeyLength)
+		case errors.Is(err, idempotency.ErrInProgress):
+			p.Detail = "a request with this idempotency key is still being processed"
+		case errors.Is(err, idempotency.ErrMismatch):
+			p.Detail = "idempotency key was already used for a different request"
+		}
+		RespondWithProblem(w, r, p)
+	})
+}
+
 // responseWriter is a custom response writer that captures the status code and response size
 type ResponseWriter struct {
 	http.ResponseWriter
@@ -117,6 +303,9 @@ func CORS(cfg *config.Config) MiddlewareFunc {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 			w.Header().Set("Access-Control-Allow-Methods", cfg.Server.AllowedMethods)
 			w.Header().Set("Access-Control-Allow-Headers", cfg.Server.AllowedHeaders)
+			if cfg.Server.ExposedHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", cfg.Server.ExposedHeaders)
+			}
 
 			if cfg.Server.AllowCredentials {
 				w.Header().Set("Access-Control-Allow-Credentials", "true")
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"microservice/pkg/idempotency"
+	"microservice/pkg/locale"
+	"microservice/pkg/logger"
+	"microservice/pkg/tenant"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/infrastructure/validator"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/grpc/codes"
+)
+
+// ProblemContentType is the media type of error responses (RFC 9457, formerly RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBase prefixes the type of registered problems
+const ProblemTypeBase = "urn:go-micro-commerce:problem:"
+
+// Problem types not tied to a domain error
+const (
+	// ProblemTypeBlank is the type of problems described by their status code alone
+	ProblemTypeBlank           = "about:blank"
+	ProblemTypeValidationError = ProblemTypeBase + "validation-error"
+)
+
+// Problem is the body of every error response
+type Problem struct {
+	// Type identifies the kind of problem; about:blank when the status says it all
+	Type  string `json:"type" example:"urn:go-micro-commerce:problem:product-not-found"`
+	Title string `json:"title" example:"Product Not Found"`
+	// Status repeats the HTTP status code
+	Status int    `json:"status" example:"404"`
+	Detail string `json:"detail,omitempty" example:"product not found"`
+	// Instance is the ID of the request, as echoed in the X-Request-ID header
+	Instance string `json:"instance,omitempty" example:"0b6f4a7e-2f1c-4a8e-9d53-6f1f0e6c1a2b"`
+	// Errors lists the invalid fields of a validation problem
+	Errors []validator.ValidationError `json:"errors,omitempty"`
+	// Code is the gRPC status code of the problem
+	Code codes.Code `json:"-"`
+}
+
+// problemMapping turns the errors matched by match into a problem
+type problemMapping struct {
+	match  func(err error) bool
+	typ    string
+	title  string
+	status int
+	code   codes.Code
+	// detail is the fixed detail of the problem; the error message is used if empty
+	detail string
+}
+
+// ProblemRegistry maps errors to problems. Mappings are tried in registration
+// order and the first match wins.
+type ProblemRegistry struct {
+	mappings []problemMapping
+}
+
+func NewProblemRegistry() *ProblemRegistry {
+	return &ProblemRegistry{}
+}
+
+// Register maps errors wrapping target to a problem of type ProblemTypeBase+slug
+// whose detail is the error message, with the gRPC code matching status. Only
+// register errors whose messages are safe to show to clients.
+func (reg *ProblemRegistry) Register(target error, status int, slug, title string) {
+	reg.RegisterCode(target, status, grpcCode(status), slug, title)
+}
+
+// RegisterCode is Register with a gRPC code other than the one matching status
+func (reg *ProblemRegistry) RegisterCode(target error, status int, code codes.Code, slug, title string) {
+	reg.register(func(err error) bool { return errors.Is(err, target) }, status, code, slug, title, "")
+}
+
+// RegisterFunc maps the errors match accepts to a problem with the given detail,
+// or the error message if detail is empty
+func (reg *ProblemRegistry) RegisterFunc(match func(err error) bool, status int, slug, title, detail string) {
+	reg.register(match, status, grpcCode(status), slug, title, detail)
+}
+
+func (reg *ProblemRegistry) register(match func(err error) bool, status int, code codes.Code, slug, title, detail string) {
+	reg.mappings = append(reg.mappings, problemMapping{
+		match:  match,
+		typ:    ProblemTypeBase + slug,
+		title:  title,
+		status: status,
+		code:   code,
+		detail: detail,
+	})
+}
+
+// grpcCode returns the gRPC code matching an HTTP status
+func grpcCode(status int) codes.Code {
+	switch status {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed, http.StatusUnprocessableEntity:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	default:
+		return codes.Internal
+	}
+}
+
+// Problem returns the problem err maps to and whether there is one
+func (reg *ProblemRegistry) Problem(err error) (Problem, bool) {
+	for _, m := range reg.mappings {
+		if !m.match(err) {
+			continue
+		}
+
+		detail := m.detail
+		if detail == "" {
+			detail = err.Error()
+		}
+
+		return Problem{Type: m.typ, Title: m.title, Status: m.status, Detail: detail, Code: m.code}, true
+	}
+
+	return Problem{}, false
+}
+
+// Problems is the registry error responses are looked up in
+var Problems = defaultProblems()
+
+func defaultProblems() *ProblemRegistry {
+	reg := NewProblemRegistry()
+
+	reg.Register(domain.ErrProductNotFound, http.StatusNotFound, "product-not-found", "Product Not Found")
+	reg.Register(domain.ErrCategoryNotFound, http.StatusNotFound, "category-not-found", "Category Not Found")
+	reg.Register(domain.ErrAPIKeyNotFound, http.StatusNotFound, "api-key-not-found", "API Key Not Found")
+	reg.Register(domain.ErrInvalidProduct, http.StatusBadRequest, "invalid-product", "Invalid Product")
+	reg.Register(domain.ErrInvalidPrice, http.StatusBadRequest, "invalid-price", "Invalid Price")
+	reg.Register(domain.ErrInvalidCategory, http.StatusBadRequest, "invalid-category", "Invalid Category")
+	reg.Register(domain.ErrInvalidStatus, http.StatusBadRequest, "invalid-status", "Invalid Product Status")
+	reg.Register(domain.ErrInvalidScope, http.StatusBadRequest, "invalid-scope", "Invalid API Key Scope")
+	reg.Register(domain.ErrDuplicateSKU, http.StatusConflict, "duplicate-sku", "Duplicate SKU")
+	reg.Register(domain.ErrTranslationNotFound, http.StatusNotFound, "translation-not-found", "Translation Not Found")
+	reg.Register(domain.ErrInvalidTranslation, http.StatusBadRequest, "invalid-translation", "Invalid Translation")
+	reg.Register(domain.ErrDefaultLocale, http.StatusBadRequest, "default-locale", "Default Locale")
+	reg.Register(domain.ErrRelationNotFound, http.StatusNotFound, "relation-not-found", "Product Relation Not Found")
+	reg.Register(domain.ErrInvalidRelation, http.StatusBadRequest, "invalid-relation", "Invalid Product Relation")
+	reg.Register(domain.ErrDuplicateRelation, http.StatusConflict, "duplicate-relation", "Duplicate Product Relation")
+	reg.Register(domain.ErrInvalidType, http.StatusBadRequest, "invalid-product-type", "Invalid Product Type")
+	reg.Register(domain.ErrBundleNotFound, http.StatusNotFound, "bundle-not-found", "Bundle Not Found")
+	reg.Register(domain.ErrInvalidBundle, http.StatusBadRequest, "invalid-bundle", "Invalid Bundle")
+	reg.RegisterCode(domain.ErrComponentInUse, http.StatusConflict, codes.FailedPrecondition, "component-in-use", "Component In Use")
+	reg.Register(domain.ErrPromotionNotFound, http.StatusNotFound, "promotion-not-found", "Promotion Not Found")
+	reg.Register(domain.ErrInvalidPromotion, http.StatusBadRequest, "invalid-promotion", "Invalid Promotion")
+	reg.Register(domain.ErrDuplicateCoupon, http.StatusConflict, "duplicate-coupon", "Duplicate Coupon Code")
+	reg.RegisterCode(domain.ErrCouponExhausted, http.StatusConflict, codes.FailedPrecondition, "coupon-exhausted", "Coupon Exhausted")
+	reg.Register(domain.ErrInvalidCart, http.StatusBadRequest, "invalid-cart", "Invalid Cart")
+	reg.Register(domain.ErrInvalidTaxClass, http.StatusBadRequest, "invalid-tax-class", "Invalid Tax Class")
+	reg.Register(domain.ErrInvalidTaxRate, http.StatusBadRequest, "invalid-tax-rate", "Invalid Tax Rate")
+	reg.Register(domain.ErrTaxRateNotFound, http.StatusUnprocessableEntity, "tax-rate-not-found", "Tax Rate Not Found")
+	reg.Register(domain.ErrInvalidTaxRequest, http.StatusBadRequest, "invalid-tax-calculation", "Invalid Tax Calculation")
+
+	reg.Register(locale.ErrInvalidLocale, http.StatusBadRequest, "invalid-locale", "Invalid Locale")
+
+	reg.Register(tenant.ErrMissingTenant, http.StatusBadRequest, "missing-tenant", "Missing Tenant")
+	reg.Register(tenant.ErrInvalidTenant, http.StatusBadRequest, "invalid-tenant", "Invalid Tenant")
+	reg.Register(tenant.ErrTenantMismatch, http.StatusForbidden, "tenant-mismatch", "Tenant Mismatch")
+	reg.Register(tenant.ErrUnboundCredentials, http.StatusForbidden, "unbound-credentials", "Unbound Credentials")
+
+	reg.Register(idempotency.ErrInvalidKey, http.StatusBadRequest, "invalid-idempotency-key", "Invalid Idempotency Key")
+	reg.RegisterCode(idempotency.ErrInProgress, http.StatusConflict, codes.Aborted, "idempotency-key-in-use", "Idempotency Key In Use")
+	reg.Register(idempotency.ErrMismatch, http.StatusUnprocessableEntity, "idempotency-key-reused", "Idempotency Key Reused")
+
+	// Unique violations not mapped to a domain error by the repositories
+	reg.RegisterFunc(isUniqueViolation, http.StatusConflict, "conflict", "Conflict",
+		"a resource with the same unique values already exists")
+
+	return reg
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// RespondWithProblem sends p as application/problem+json, identified by the ID of r
+func RespondWithProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Type == "" {
+		p.Type = ProblemTypeBlank
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance, _ = r.Context().Value(middleware.RequestIDKey).(string)
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		respondWithEncodingError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	w.Write(data)
+}
+
+// RespondWithError sends a problem described by its status code and detail
+func RespondWithError(w http.ResponseWriter, r *http.Request, detail string, statusCode int) {
+	RespondWithProblem(w, r, Problem{Status: statusCode, Detail: detail})
+}
+
+// RespondWithValidationErrors sends a validation problem listing the invalid fields,
+// with their messages in the locale of r
+func RespondWithValidationErrors(w http.ResponseWriter, r *http.Request, errors []validator.ValidationError) {
+	locale := validator.ContextLocale(r.Context())
+	w.Header().Set("Content-Language", locale.String())
+
+	RespondWithProblem(w, r, Problem{
+		Type:   ProblemTypeValidationError,
+		Title:  "Validation Failed",
+		Status: http.StatusBadRequest,
+		Detail: "the request has invalid fields",
+		Errors: validator.Localize(errors, locale),
+	})
+}
+
+// respondWithDomainError sends the problem err maps to. Authorization errors are
+// handled by respondWithAuthError and unmapped errors are logged and sent as
+// 500 Internal Server Error with the fallback detail, hiding their message.
+func respondWithDomainError(w http.ResponseWriter, r *http.Request, err error, fallback string, logger logger.Logger) {
+	if respondWithAuthError(w, r, err, logger) {
+		return
+	}
+
+	if p, ok := Problems.Problem(err); ok {
+		RespondWithProblem(w, r, p)
+		return
+	}
+
+	logger.Error("%s %s: %s: %v", r.Method, r.URL.Path, fallback, err)
+	RespondWithError(w, r, fallback, http.StatusInternalServerError)
+}
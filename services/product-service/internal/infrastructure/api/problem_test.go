@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"microservice/pkg/locale"
+	"microservice/pkg/logger"
+	"microservice/services/product-service/internal/infrastructure/validator"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRespondWithValidationErrorsUsesRequestLocale(t *testing.T) {
+	resolver := locale.NewResolver(locale.Config{Param: "locale", Default: "en"})
+	handler := Locale(resolver, logger.NewLogger(logger.Fatal, io.Discard, false))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := validator.New()
+		v.AddRuleError("name", "required", nil)
+		RespondWithValidationErrors(w, r, v.Errors)
+	}))
+
+	tests := []struct {
+		name           string
+		target         string
+		acceptLanguage string
+		wantLanguage   string
+		wantMessage    string
+	}{
+		{"header", "/", "de-AT, en;q=0.5", "de", "name ist erforderlich"},
+		// The locale parameter wins over the header, as it does for content
+		{"parameter", "/?locale=fr", "de", "fr", "name est obligatoire"},
+		{"unsupported", "/", "ja", "en", "name is required"},
+		{"none", "/", "", "en", "name is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.target, nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Language"); got != tt.wantLanguage {
+				t.Errorf("Content-Language = %q, want %q", got, tt.wantLanguage)
+			}
+			if vary := rec.Header().Values("Vary"); len(vary) != 1 || vary[0] != "Accept-Language" {
+				t.Errorf("Vary = %q, want Accept-Language once", vary)
+			}
+
+			var p Problem
+			if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if len(p.Errors) != 1 || p.Errors[0].Message != tt.wantMessage {
+				t.Errorf("errors = %+v, want %q", p.Errors, tt.wantMessage)
+			}
+		})
+	}
+}
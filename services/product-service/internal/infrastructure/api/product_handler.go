@@ -1,14 +1,19 @@
 package api
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
+	"fmt"
+	"microservice/pkg/auth"
+	"microservice/pkg/locale"
 	"microservice/pkg/logger"
 	"microservice/services/product-service/internal/domain"
 	"microservice/services/product-service/internal/infrastructure/validator"
 	"microservice/services/product-service/internal/interfaces"
 	"net/http"
 	"os"
+	"path"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -16,26 +21,74 @@ import (
 )
 
 type ProductHandler struct {
-	service   interfaces.Service
-	validator *validator.Validator
-	logger    logger.Logger
+	service      interfaces.Service
+	validation   *validator.Pipeline[ProductRequest]
+	policy       *auth.Policy
+	logger       logger.Logger
+	cacheControl CacheControlConfig
 }
 
-func NewProductHandler(service interfaces.Service, validator *validator.Validator, logger logger.Logger) *ProductHandler {
+func NewProductHandler(service interfaces.Service, policy *auth.Policy, logger logger.Logger, cacheControl CacheControlConfig) *ProductHandler {
 	return &ProductHandler{
-		service:   service,
-		validator: validator,
-		logger:    logger,
+		service:      service,
+		validation:   newProductValidation(service),
+		policy:       policy,
+		logger:       logger,
+		cacheControl: cacheControl,
 	}
 }
 
+// newProductValidation validates product requests and checks that their category exists
+func newProductValidation(service interfaces.Service) *validator.Pipeline[ProductRequest] {
+	return validator.NewPipeline[ProductRequest]().
+		Check(func(v *validator.Validator, req *ProductRequest) { req.Validate(v) }).
+		CheckAsync(func(ctx context.Context, v *validator.Validator, req *ProductRequest) error {
+			categoryID, err := uuid.Parse(req.CategoryID)
+			if err != nil {
+				// Already reported by the syntactic checks
+				return nil
+			}
+
+			exists, err := service.CategoryExists(ctx, categoryID)
+			if err != nil {
+				return fmt.Errorf("failed to check if category exists: %w", err)
+			}
+
+			v.CheckRule(exists, "category_id", "category_not_found", nil)
+			return nil
+		})
+}
+
+// decodeProduct decodes and validates the product in the body of r, sending the
+// error response and returning nil if it is invalid
+func (h *ProductHandler) decodeProduct(w http.ResponseWriter, r *http.Request) *ProductRequest {
+	defer r.Body.Close()
+
+	req, errs, err := h.validation.Run(r.Context(), validator.DecodeJSON[ProductRequest](r.Body))
+	switch {
+	case errors.Is(err, validator.ErrDecode):
+		RespondWithError(w, r, "failed to decode request body", http.StatusBadRequest)
+		return nil
+	case err != nil:
+		respondWithDomainError(w, r, err, "failed to validate product", h.logger)
+		return nil
+	case len(errs) > 0:
+		RespondWithValidationErrors(w, r, errs)
+		return nil
+	}
+
+	return req
+}
+
 func (h *ProductHandler) RegisterRoutes(r chi.Router) {
 	r.Route("/products", func(r chi.Router) {
 		r.Get("/", h.ListProducts)
-		r.Post("/", h.CreateProduct)
+		r.With(h.authorize(domain.PermissionCreateProduct)).Post("/", h.CreateProduct)
 		r.Get("/{id}", h.GetProduct)
-		r.Put("/{id}", h.UpdateProduct)
-		r.Delete("/{id}", h.DeleteProduct)
+		r.Get("/by-slug/{slug}", h.GetProductBySlug)
+		r.With(h.authorize(domain.PermissionUpdateProduct)).Put("/{id}", h.UpdateProduct)
+		r.With(h.authorize(domain.PermissionDeleteProduct)).Delete("/{id}", h.DeleteProduct)
+		r.With(h.authorize(domain.PermissionReadHistory)).Get("/{id}/history", h.GetProductHistory)
 		r.Get("/category/{categoryID}", h.GetProductsByCategory)
 		r.Get("/search", h.SearchProducts)
 		r.Get("/health", h.HealthCheck)
@@ -50,8 +103,14 @@ func (h *ProductHandler) RegisterRoutes(r chi.Router) {
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param perPage query int false "Items per page" default(10)
+// @Param If-None-Match header string false "ETag of the cached page"
 // @Success 200 {object} api.PaginatedResponse{items=[]domain.Product} "Success"
-// @Failure 500 {object} api.APIResponse{errors=string} "Internal Server Error"
+// @Success 304 "Not Modified"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /products [get]
 func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 
@@ -60,13 +119,17 @@ func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 	products, total, err := h.service.GetAll(r.Context(), params.GetLimit(), params.GetOffset())
 
 	if err != nil {
-		h.logger.Info(err.Error())
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"error": "failed to list products"}`))
+		respondWithDomainError(w, r, err, "failed to list products", h.logger)
 		return
 	}
 
-	RespondWithPagination(w, products, params.Page, params.PerPage, total)
+	// The page is validated by a hash of its body only. A time the page was last
+	// modified cannot be derived from its items: deleting or unpublishing a product
+	// drops it from the page without making any remaining item newer.
+	RespondWithConditionalJSON(w, r, http.StatusOK, PaginatedResponse{
+		Items:      products,
+		Pagination: NewPagination(params.Page, params.PerPage, total),
+	}, Validators{CacheControl: h.cacheControlFor(w, r, h.cacheControl.ProductList)})
 }
 
 // GetProduct godoc
@@ -76,110 +139,297 @@ func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID" format(uuid)
+// @Param If-None-Match header string false "ETag of the cached product"
+// @Param If-Modified-Since header string false "Time the cached product was last modified"
 // @Success 200 {object} api.APIResponse{data=api.ProductResponse} "Success"
-// @Failure 400 {object} api.APIResponse{errors=string} "Bad Request"
-// @Failure 404 {object} api.APIResponse{errors=string} "Not Found"
-// @Failure 500 {object} api.APIResponse{errors=string} "Internal Server Error"
+// @Success 304 "Not Modified"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /products/{id} [get]
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	idParam := chi.URLParam(r, "id")
 	id, err := uuid.Parse(idParam)
 
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(`{"error": "invalid product ID format"}`))
+		RespondWithError(w, r, "invalid product ID format", http.StatusBadRequest)
 		return
 	}
 
 	product, err := h.service.GetByID(r.Context(), id)
 
 	if err != nil {
-		if errors.Is(err, domain.ErrProductNotFound) {
-			w.WriteHeader(http.StatusNotFound)
-			w.Write([]byte(`{"error": "product not found"}`))
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(`{"error": "failed to get product"}`))
+		respondWithDomainError(w, r, err, "failed to get product", h.logger)
+		return
+	}
+
+	h.respondWithProduct(w, r, product)
+}
+
+// GetProductBySlug godoc
+// @Summary Get a product by slug
+// @Description Get a product by the slug of its storefront URL. Slugs a product had before being renamed answer with 301 Moved Permanently to its current slug.
+// @Tags products
+// @Produce json
+// @Param slug path string true "Product slug" example(smartphone-x)
+// @Param If-None-Match header string false "ETag of the cached product"
+// @Param If-Modified-Since header string false "Time the cached product was last modified"
+// @Success 200 {object} api.APIResponse{data=api.ProductResponse} "Success"
+// @Success 301 "Moved Permanently, Location names the current slug"
+// @Success 304 "Not Modified"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/by-slug/{slug} [get]
+func (h *ProductHandler) GetProductBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	product, err := h.service.GetBySlug(r.Context(), slug)
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to get product", h.logger)
+		return
+	}
+
+	if product.Slug != slug {
+		// Redirects expire like the product instead of being cached for good, as a
+		// renamed product may take back its old slug
+		if directive := h.cacheControlFor(w, r, h.cacheControl.Product); directive != "" {
+			w.Header().Set("Cache-Control", directive)
 		}
+
+		location := *r.URL
+		location.Path = path.Join(path.Dir(r.URL.Path), product.Slug)
+		location.RawPath = ""
+		http.Redirect(w, r, location.RequestURI(), http.StatusMovedPermanently)
 		return
 	}
 
-	response := ProductResponse{
-		ID:          product.ID.String(),
-		SKU:         product.SKU,
-		Name:        product.Name,
-		Price:       float64(product.Price),
-		Description: product.Description,
-		CategoryID:  product.CategoryID.String(),
-		CreatedAt:   product.CreatedAt.Format(time.RFC1123),
-		UpdatedAt:   product.UpdatedAt.Format(time.RFC1123),
+	h.respondWithProduct(w, r, product)
+}
+
+// respondWithProduct sends product with the validators of conditional requests
+func (h *ProductHandler) respondWithProduct(w http.ResponseWriter, r *http.Request, product *domain.Product) {
+	setContentLanguage(w, r, product)
+
+	// A product changes with its translation, and with the locale chosen for it. A
+	// bundle changes with its components too.
+	lastModified := product.UpdatedAt
+	if product.TranslatedAt.After(lastModified) {
+		lastModified = product.TranslatedAt
+	}
+	var bundledAt time.Time
+	if product.Bundle != nil {
+		bundledAt = product.Bundle.ChangedAt()
+	}
+	if bundledAt.After(lastModified) {
+		lastModified = bundledAt
 	}
 
-	RespondWithJSON(w, http.StatusOK, response)
+	RespondWithConditionalJSON(w, r, http.StatusOK, ProductResponseFromModel(product), Validators{
+		ETag:         VersionETag(product.ID.String(), strconv.FormatInt(product.UpdatedAt.UnixNano(), 10), product.Locale, strconv.FormatInt(product.TranslatedAt.UnixNano(), 10), strconv.FormatInt(bundledAt.UnixNano(), 10)),
+		LastModified: lastModified,
+		CacheControl: h.cacheControlFor(w, r, h.cacheControl.Product),
+	})
 }
 
 // CreateProduct godoc
 // @Summary Create a new product
-// @Description Create a new product with the provided details
+// @Description Create a new product with the provided details. Send an Idempotency-Key to retry safely: a retry with the same key and body replays the first response.
 // @Tags products
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Unique key of the request, at most 255 characters"
 // @Param product body api.ProductRequest true "Product details"
 // @Success 201 {object} api.APIResponse{data=api.ProductResponse} "Created"
-// @Failure 400 {object} api.APIResponse{errors=[]validator.ValidationError} "Validation Error"
-// @Failure 500 {object} api.APIResponse{errors=string} "Internal Server Error"
+// @Failure 400 {object} api.Problem "Validation Error"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 409 {object} api.Problem "SKU already exists, or a request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} api.Problem "Idempotency-Key reused with a different request"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /products [post]
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
-	d := json.NewDecoder(r.Body)
+	req := h.decodeProduct(w, r)
+	if req == nil {
+		return
+	}
 
-	var req ProductRequest
-	if err := d.Decode(&req); err != nil {
-		RespondWithError(w, "failed to decode request body", http.StatusBadRequest)
+	product, err := req.ToModel()
+	if err != nil {
+		RespondWithError(w, r, "invalid request data", http.StatusBadRequest)
 		return
 	}
 
-	defer r.Body.Close()
+	err = h.service.Create(r.Context(), product)
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to create product", h.logger)
+		return
+	}
 
-	v := h.validator
-	// check if category exists
-	categoryID := req.Validate(h.validator)
+	response := ProductResponseFromModel(product)
 
-	exists, err := h.service.CategoryExists(r.Context(), categoryID)
+	RespondWithJSON(w, http.StatusCreated, response)
+}
+
+// UpdateProduct godoc
+// @Summary Update a product
+// @Description Replace the details of an existing product
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Param product body api.ProductRequest true "Product details"
+// @Success 200 {object} api.APIResponse{data=api.ProductResponse} "Success"
+// @Failure 400 {object} api.Problem "Validation Error"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 409 {object} api.Problem "SKU already exists"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id} [put]
+func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		RespondWithError(w, "failed to check if category exists", http.StatusInternalServerError)
+		RespondWithError(w, r, "invalid product ID format", http.StatusBadRequest)
 		return
 	}
 
-	v.Check(exists, "category_id", "Category does not exist")
-
-	if !v.Valid() {
-		RespondWithValidationErrors(w, v.Errors)
+	req := h.decodeProduct(w, r)
+	if req == nil {
 		return
 	}
 
 	product, err := req.ToModel()
 	if err != nil {
-		RespondWithError(w, "invalid request data", http.StatusBadRequest)
+		RespondWithError(w, r, "invalid request data", http.StatusBadRequest)
 		return
 	}
+	product.ID = id
 
-	err = h.service.Create(r.Context(), product)
+	err = h.service.Update(r.Context(), product)
 	if err != nil {
-		RespondWithError(w, "failed to create product", http.StatusInternalServerError)
+		respondWithDomainError(w, r, err, "failed to update product", h.logger)
 		return
 	}
 
-	response := ProductResponseFromModel(product)
-
-	RespondWithJSON(w, http.StatusCreated, response)
+	RespondWithJSON(w, http.StatusOK, ProductResponseFromModel(product))
 }
 
-func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+// DeleteProduct godoc
+// @Summary Delete a product
+// @Description Delete a product by its UUID. A component of a bundle that is not archived cannot be deleted.
+// @Tags products
+// @Param id path string true "Product ID" format(uuid)
+// @Success 204 "No Content"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 409 {object} api.Problem "The product is a component of an active bundle"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id} [delete]
+func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		RespondWithError(w, r, "invalid product ID format", http.StatusBadRequest)
+		return
+	}
+
+	err = h.service.Delete(r.Context(), id)
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to delete product", h.logger)
+		return
+	}
 
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+// GetProductHistory godoc
+// @Summary Get the change history of a product
+// @Description List the audit trail of a product, newest first. When as_of is given, the product is instead reconstructed as it was at that time.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Param page query int false "Page number" default(1)
+// @Param perPage query int false "Items per page" default(10)
+// @Param as_of query string false "Point in time to reconstruct the product at" format(date-time)
+// @Success 200 {object} api.PaginatedResponse{items=[]api.AuditEntryResponse} "Success"
+// @Success 200 {object} api.APIResponse{data=api.ProductResponse} "Product as of the given time"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/history [get]
+func (h *ProductHandler) GetProductHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		RespondWithError(w, r, "invalid product ID format", http.StatusBadRequest)
+		return
+	}
+
+	if asOfParam := r.URL.Query().Get("as_of"); asOfParam != "" {
+		asOf, err := time.Parse(time.RFC3339, asOfParam)
+		if err != nil {
+			RespondWithError(w, r, "as_of must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+
+		product, err := h.service.GetAsOf(r.Context(), id, asOf)
+		if err != nil {
+			if errors.Is(err, domain.ErrProductNotFound) {
+				p, _ := Problems.Problem(err)
+				p.Detail = "product did not exist at the given time"
+				RespondWithProblem(w, r, p)
+			} else {
+				respondWithDomainError(w, r, err, "failed to reconstruct product", h.logger)
+			}
+			return
+		}
+
+		RespondWithJSON(w, http.StatusOK, ProductResponseFromModel(product))
+		return
+	}
+
+	params := ParseQueryParams(r)
+
+	entries, total, err := h.service.GetHistory(r.Context(), id, params.GetLimit(), params.GetOffset())
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to get product history", h.logger)
+		return
+	}
+
+	if total == 0 {
+		respondWithDomainError(w, r, domain.ErrProductNotFound, "failed to get product history", h.logger)
+		return
+	}
 
+	items := make([]AuditEntryResponse, len(entries))
+	for i, e := range entries {
+		items[i] = AuditEntryResponseFromModel(e)
+	}
+
+	RespondWithPagination(w, items, params.Page, params.PerPage, total)
 }
 
 func (h *ProductHandler) GetProductsByCategory(w http.ResponseWriter, r *http.Request) {
@@ -190,6 +440,36 @@ func (h *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request)
 
 }
 
+// authorize returns middleware letting only callers holding permission through
+func (h *ProductHandler) authorize(permission string) MiddlewareFunc {
+	return Authorize(h.policy, permission, h.logger)
+}
+
+// cacheControlFor returns the Cache-Control directive of a read. Authenticated callers
+// may see unpublished products, so their responses must not be stored by shared caches.
+func (h *ProductHandler) cacheControlFor(w http.ResponseWriter, r *http.Request, directive string) string {
+	w.Header().Add("Vary", "Authorization")
+
+	if _, ok := auth.PrincipalFromContext(r.Context()); ok && directive != "" {
+		return "private, no-cache"
+	}
+	return directive
+}
+
+// setContentLanguage sets the Content-Language of a response carrying product to the
+// locale of its translation, or the default locale if it is untranslated
+func setContentLanguage(w http.ResponseWriter, r *http.Request, product *domain.Product) {
+	language := product.Locale
+	if language == "" {
+		chain, ok := locale.FromContext(r.Context())
+		if !ok {
+			return
+		}
+		language = chain[len(chain)-1]
+	}
+	w.Header().Set("Content-Language", language)
+}
+
 // HealthCheck godoc
 // @Summary Health check endpoint
 // @Description Returns the health status of the product service
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"io"
+	"microservice/pkg/auth"
+	"microservice/pkg/logger"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/interfaces"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// memoryProductService serves products from memory in insertion order
+type memoryProductService struct {
+	interfaces.Service
+	products []*domain.Product
+}
+
+func (s *memoryProductService) GetAll(ctx context.Context, limit, offset int) ([]*domain.Product, int, error) {
+	page := s.products[min(offset, len(s.products)):min(offset+limit, len(s.products))]
+	return page, len(s.products), nil
+}
+
+func (s *memoryProductService) Delete(ctx context.Context, id uuid.UUID) error {
+	before := len(s.products)
+	s.products = slices.DeleteFunc(s.products, func(p *domain.Product) bool { return p.ID == id })
+	if len(s.products) == before {
+		return domain.ErrProductNotFound
+	}
+	return nil
+}
+
+func newTestProductRouter(service interfaces.Service) http.Handler {
+	h := NewProductHandler(service, auth.AllowAll(), logger.NewLogger(logger.Fatal, io.Discard, false), CacheControlConfig{})
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+	return r
+}
+
+func TestListProductsRevalidatesAfterDelete(t *testing.T) {
+	updated := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	service := &memoryProductService{}
+	for _, name := range []string{"Kettle", "Toaster", "Blender"} {
+		service.products = append(service.products, &domain.Product{
+			ID:        uuid.New(),
+			Name:      name,
+			Status:    domain.StatusPublished,
+			Type:      domain.TypeSimple,
+			CreatedAt: updated,
+			UpdatedAt: updated,
+		})
+	}
+	router := newTestProductRouter(service)
+
+	list := httptest.NewRecorder()
+	router.ServeHTTP(list, httptest.NewRequest(http.MethodGet, "/products", nil))
+	if list.Code != http.StatusOK {
+		t.Fatalf("list status %d, want 200", list.Code)
+	}
+	if lastModified := list.Header().Get("Last-Modified"); lastModified != "" {
+		t.Errorf("list sent Last-Modified %s", lastModified)
+	}
+	etag := list.Header().Get("ETag")
+
+	deleted := httptest.NewRecorder()
+	router.ServeHTTP(deleted, httptest.NewRequest(http.MethodDelete, "/products/"+service.products[1].ID.String(), nil))
+	if deleted.Code != http.StatusNoContent {
+		t.Fatalf("delete status %d, want 204", deleted.Code)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{"If-Modified-Since", "If-Modified-Since", updated.Add(time.Hour).Format(http.TimeFormat)},
+		{"If-None-Match", "If-None-Match", etag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/products", nil)
+			req.Header.Set(tt.header, tt.value)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("conditional list status %d, want 200", rec.Code)
+			}
+		})
+	}
+}
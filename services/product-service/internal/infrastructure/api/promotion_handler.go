@@ -0,0 +1,288 @@
+package api
+
+import (
+	"microservice/pkg/auth"
+	"microservice/pkg/logger"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/infrastructure/validator"
+	"microservice/services/product-service/internal/interfaces"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// PromotionHandler serves the administration of promotions and the pricing of carts
+type PromotionHandler struct {
+	service interfaces.PromotionService
+	policy  *auth.Policy
+	logger  logger.Logger
+}
+
+func NewPromotionHandler(service interfaces.PromotionService, policy *auth.Policy, logger logger.Logger) *PromotionHandler {
+	return &PromotionHandler{
+		service: service,
+		policy:  policy,
+		logger:  logger,
+	}
+}
+
+// promotionValidation validates promotion requests
+var promotionValidation = validator.NewPipeline[PromotionRequest]().
+	Check(func(v *validator.Validator, req *PromotionRequest) { req.Validate(v) })
+
+// evaluateValidation validates cart evaluation requests
+var evaluateValidation = validator.NewPipeline[EvaluateRequest]().
+	Check(func(v *validator.Validator, req *EvaluateRequest) { req.Validate(v) })
+
+func (h *PromotionHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/promotions", func(r chi.Router) {
+		r.Use(Authorize(h.policy, domain.PermissionManagePromotions, h.logger))
+		r.Get("/", h.ListPromotions)
+		r.Post("/", h.CreatePromotion)
+		r.Get("/{id}", h.GetPromotion)
+		r.Put("/{id}", h.UpdatePromotion)
+		r.Delete("/{id}", h.DeletePromotion)
+	})
+	r.Post("/pricing/evaluate", h.Evaluate)
+}
+
+// ListPromotions godoc
+// @Summary List promotions
+// @Description List the promotions, oldest first, including inactive and expired ones
+// @Tags promotions
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param perPage query int false "Items per page" default(10)
+// @Success 200 {object} api.PaginatedResponse{items=[]api.PromotionResponse} "Success"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /promotions [get]
+func (h *PromotionHandler) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	params := ParseQueryParams(r)
+
+	promotions, total, err := h.service.List(r.Context(), params.GetLimit(), params.GetOffset())
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to list promotions", h.logger)
+		return
+	}
+
+	items := make([]PromotionResponse, len(promotions))
+	for i, p := range promotions {
+		items[i] = PromotionResponseFromModel(p)
+	}
+
+	RespondWithPagination(w, items, params.Page, params.PerPage, total)
+}
+
+// GetPromotion godoc
+// @Summary Get a promotion
+// @Description Get a promotion with its usage count
+// @Tags promotions
+// @Produce json
+// @Param id path string true "Promotion ID" format(uuid)
+// @Success 200 {object} api.APIResponse{data=api.PromotionResponse} "Success"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /promotions/{id} [get]
+func (h *PromotionHandler) GetPromotion(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePromotionID(w, r)
+	if !ok {
+		return
+	}
+
+	promotion, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to get promotion", h.logger)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, PromotionResponseFromModel(promotion))
+}
+
+// CreatePromotion godoc
+// @Summary Create a promotion
+// @Description Create a percentage, fixed or buy-X-get-Y discount, optionally targeted at categories or SKUs, behind a coupon code or a minimum spend, and limited in time or number of redemptions
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param promotion body api.PromotionRequest true "Promotion"
+// @Success 201 {object} api.APIResponse{data=api.PromotionResponse} "Created"
+// @Failure 400 {object} api.Problem "Validation Error"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 409 {object} api.Problem "Conflict"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /promotions [post]
+func (h *PromotionHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	req, errs, err := promotionValidation.Run(r.Context(), validator.DecodeJSON[PromotionRequest](r.Body))
+	if err != nil {
+		RespondWithError(w, r, "failed to decode request body", http.StatusBadRequest)
+		return
+	}
+	if len(errs) > 0 {
+		RespondWithValidationErrors(w, r, errs)
+		return
+	}
+
+	promotion := req.ToModel()
+	if err := h.service.Create(r.Context(), promotion); err != nil {
+		respondWithDomainError(w, r, err, "failed to create promotion", h.logger)
+		return
+	}
+
+	h.logger.Info("Promotion %s (%s) created", promotion.ID, promotion.Name)
+
+	RespondWithJSON(w, http.StatusCreated, PromotionResponseFromModel(promotion))
+}
+
+// UpdatePromotion godoc
+// @Summary Update a promotion
+// @Description Replace the rules of a promotion. Its usage count is kept, so the usage limit cannot go below it.
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param id path string true "Promotion ID" format(uuid)
+// @Param promotion body api.PromotionRequest true "Promotion"
+// @Success 200 {object} api.APIResponse{data=api.PromotionResponse} "Success"
+// @Failure 400 {object} api.Problem "Validation Error"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 409 {object} api.Problem "Conflict"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /promotions/{id} [put]
+func (h *PromotionHandler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, ok := parsePromotionID(w, r)
+	if !ok {
+		return
+	}
+
+	req, errs, err := promotionValidation.Run(r.Context(), validator.DecodeJSON[PromotionRequest](r.Body))
+	if err != nil {
+		RespondWithError(w, r, "failed to decode request body", http.StatusBadRequest)
+		return
+	}
+	if len(errs) > 0 {
+		RespondWithValidationErrors(w, r, errs)
+		return
+	}
+
+	promotion := req.ToModel()
+	promotion.ID = id
+	if err := h.service.Update(r.Context(), promotion); err != nil {
+		respondWithDomainError(w, r, err, "failed to update promotion", h.logger)
+		return
+	}
+
+	h.logger.Info("Promotion %s (%s) updated", promotion.ID, promotion.Name)
+
+	RespondWithJSON(w, http.StatusOK, PromotionResponseFromModel(promotion))
+}
+
+// DeletePromotion godoc
+// @Summary Delete a promotion
+// @Description Delete a promotion; its coupon code stops applying and can be reused
+// @Tags promotions
+// @Param id path string true "Promotion ID" format(uuid)
+// @Success 204 "No Content"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /promotions/{id} [delete]
+func (h *PromotionHandler) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePromotionID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		respondWithDomainError(w, r, err, "failed to delete promotion", h.logger)
+		return
+	}
+
+	h.logger.Info("Promotion %s deleted", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Evaluate godoc
+// @Summary Price a cart
+// @Description Price the items at the current price of their product and apply the running promotions in priority order. Each item lists the adjustments taken off it with the reason for each one; presented coupons that did not apply are listed with the reason why. Setting redeem counts a use of the applied promotions and requires the promotion.redeem permission.
+// @Tags pricing
+// @Accept json
+// @Produce json
+// @Param cart body api.EvaluateRequest true "Cart"
+// @Success 200 {object} api.APIResponse{data=api.QuoteResponse} "Success"
+// @Failure 400 {object} api.Problem "Validation Error"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 409 {object} api.Problem "Conflict"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /pricing/evaluate [post]
+func (h *PromotionHandler) Evaluate(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	req, errs, err := evaluateValidation.Run(r.Context(), validator.DecodeJSON[EvaluateRequest](r.Body))
+	if err != nil {
+		RespondWithError(w, r, "failed to decode request body", http.StatusBadRequest)
+		return
+	}
+	if len(errs) > 0 {
+		RespondWithValidationErrors(w, r, errs)
+		return
+	}
+
+	quote, err := h.service.Evaluate(r.Context(), req.ToModel(), req.Coupons, req.Redeem)
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to evaluate cart", h.logger)
+		return
+	}
+
+	if req.Redeem {
+		h.logger.Info("Cart redeemed %d promotions for a discount of %.2f", len(quote.Applied), float64(quote.Discount))
+	}
+
+	// Quotes depend on the caller and on the time
+	w.Header().Set("Cache-Control", "no-store")
+	RespondWithJSON(w, http.StatusOK, QuoteResponseFromModel(quote))
+}
+
+// parsePromotionID parses the promotion ID of the path, responding with an error if
+// it is invalid
+func parsePromotionID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		RespondWithError(w, r, "invalid promotion ID format", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
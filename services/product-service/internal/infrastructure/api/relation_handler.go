@@ -0,0 +1,275 @@
+package api
+
+import (
+	"microservice/pkg/auth"
+	"microservice/pkg/logger"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/infrastructure/validator"
+	"microservice/services/product-service/internal/interfaces"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// RelationHandler serves the curated lists of related, cross-sell, up-sell and
+// accessory products of products
+type RelationHandler struct {
+	service interfaces.RelationService
+	policy  *auth.Policy
+	logger  logger.Logger
+}
+
+func NewRelationHandler(service interfaces.RelationService, policy *auth.Policy, logger logger.Logger) *RelationHandler {
+	return &RelationHandler{
+		service: service,
+		policy:  policy,
+		logger:  logger,
+	}
+}
+
+// relationValidation validates requests relating products
+var relationValidation = validator.NewPipeline[RelationRequest]().
+	Check(func(v *validator.Validator, req *RelationRequest) { req.Validate(v) })
+
+// relationUpdateValidation validates requests updating relations
+var relationUpdateValidation = validator.NewPipeline[RelationUpdateRequest]().
+	Check(func(v *validator.Validator, req *RelationUpdateRequest) { req.Validate(v) })
+
+func (h *RelationHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/products/{id}/relations", func(r chi.Router) {
+		r.Get("/", h.ListRelations)
+		r.With(h.authorize()).Post("/", h.CreateRelation)
+		r.Get("/{type}/{relatedID}", h.GetRelation)
+		r.With(h.authorize()).Put("/{type}/{relatedID}", h.UpdateRelation)
+		r.With(h.authorize()).Delete("/{type}/{relatedID}", h.DeleteRelation)
+	})
+}
+
+// ListRelations godoc
+// @Summary List the relations of a product
+// @Description List the related products of a product in list order, grouped by type. Related products the caller may not see are left out.
+// @Tags relations
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Param type query string false "Relation type" Enums(related, cross_sell, up_sell, accessory)
+// @Success 200 {object} api.APIResponse{data=[]api.RelationResponse} "Success"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/relations [get]
+func (h *RelationHandler) ListRelations(w http.ResponseWriter, r *http.Request) {
+	productID, ok := parseProductID(w, r)
+	if !ok {
+		return
+	}
+
+	relations, err := h.service.List(r.Context(), productID, domain.RelationType(r.URL.Query().Get("type")))
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to list relations", h.logger)
+		return
+	}
+
+	items := make([]RelationResponse, len(relations))
+	for i, rel := range relations {
+		items[i] = RelationResponseFromModel(rel)
+	}
+
+	RespondWithJSON(w, http.StatusOK, items)
+}
+
+// CreateRelation godoc
+// @Summary Relate a product to another
+// @Description Add a product to a list of related products of the product. A bidirectional relation also adds the product to the same list of the related product, at its end. Archived products cannot be related.
+// @Tags relations
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Param relation body api.RelationRequest true "Relation"
+// @Success 201 {object} api.APIResponse{data=api.RelationResponse} "Created"
+// @Failure 400 {object} api.Problem "Validation Error"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 409 {object} api.Problem "The products are already related with this type"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/relations [post]
+func (h *RelationHandler) CreateRelation(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	productID, ok := parseProductID(w, r)
+	if !ok {
+		return
+	}
+
+	req, errs, err := relationValidation.Run(r.Context(), validator.DecodeJSON[RelationRequest](r.Body))
+	if err != nil {
+		RespondWithError(w, r, "failed to decode request body", http.StatusBadRequest)
+		return
+	}
+	if len(errs) > 0 {
+		RespondWithValidationErrors(w, r, errs)
+		return
+	}
+
+	relation := req.ToModel(productID)
+	if err := h.service.Create(r.Context(), relation); err != nil {
+		respondWithDomainError(w, r, err, "failed to create relation", h.logger)
+		return
+	}
+
+	h.logger.Info("Product %s related to %s as %s", relation.ProductID, relation.RelatedID, relation.Type)
+
+	RespondWithJSON(w, http.StatusCreated, RelationResponseFromModel(relation))
+}
+
+// GetRelation godoc
+// @Summary Get a relation of a product
+// @Description Get a relation of a product with the related product
+// @Tags relations
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Param type path string true "Relation type" Enums(related, cross_sell, up_sell, accessory)
+// @Param relatedID path string true "Related product ID" format(uuid)
+// @Success 200 {object} api.APIResponse{data=api.RelationResponse} "Success"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/relations/{type}/{relatedID} [get]
+func (h *RelationHandler) GetRelation(w http.ResponseWriter, r *http.Request) {
+	productID, relType, relatedID, ok := parseRelationKey(w, r)
+	if !ok {
+		return
+	}
+
+	relation, err := h.service.Get(r.Context(), productID, relType, relatedID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to get relation", h.logger)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, RelationResponseFromModel(relation))
+}
+
+// UpdateRelation godoc
+// @Summary Update a relation of a product
+// @Description Move a related product within its list, renumbering the list, and make the relation bidirectional or not
+// @Tags relations
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Param type path string true "Relation type" Enums(related, cross_sell, up_sell, accessory)
+// @Param relatedID path string true "Related product ID" format(uuid)
+// @Param relation body api.RelationUpdateRequest true "Relation"
+// @Success 200 {object} api.APIResponse{data=api.RelationResponse} "Success"
+// @Failure 400 {object} api.Problem "Validation Error"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/relations/{type}/{relatedID} [put]
+func (h *RelationHandler) UpdateRelation(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	productID, relType, relatedID, ok := parseRelationKey(w, r)
+	if !ok {
+		return
+	}
+
+	req, errs, err := relationUpdateValidation.Run(r.Context(), validator.DecodeJSON[RelationUpdateRequest](r.Body))
+	if err != nil {
+		RespondWithError(w, r, "failed to decode request body", http.StatusBadRequest)
+		return
+	}
+	if len(errs) > 0 {
+		RespondWithValidationErrors(w, r, errs)
+		return
+	}
+
+	relation := &domain.ProductRelation{
+		ProductID:     productID,
+		RelatedID:     relatedID,
+		Type:          relType,
+		Position:      *req.Position,
+		Bidirectional: req.Bidirectional,
+	}
+	if err := h.service.Update(r.Context(), relation); err != nil {
+		respondWithDomainError(w, r, err, "failed to update relation", h.logger)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, RelationResponseFromModel(relation))
+}
+
+// DeleteRelation godoc
+// @Summary Delete a relation of a product
+// @Description Remove a product from a list of related products of the product, and the product from the same list of the related product if the relation is bidirectional
+// @Tags relations
+// @Param id path string true "Product ID" format(uuid)
+// @Param type path string true "Relation type" Enums(related, cross_sell, up_sell, accessory)
+// @Param relatedID path string true "Related product ID" format(uuid)
+// @Success 204 "No Content"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/relations/{type}/{relatedID} [delete]
+func (h *RelationHandler) DeleteRelation(w http.ResponseWriter, r *http.Request) {
+	productID, relType, relatedID, ok := parseRelationKey(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), productID, relType, relatedID); err != nil {
+		respondWithDomainError(w, r, err, "failed to delete relation", h.logger)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorize returns middleware letting only callers who may update products through
+func (h *RelationHandler) authorize() MiddlewareFunc {
+	return Authorize(h.policy, domain.PermissionUpdateProduct, h.logger)
+}
+
+// parseRelationKey parses the product ID, relation type and related product ID in the
+// path of r, sending the error response if one is malformed
+func parseRelationKey(w http.ResponseWriter, r *http.Request) (uuid.UUID, domain.RelationType, uuid.UUID, bool) {
+	productID, ok := parseProductID(w, r)
+	if !ok {
+		return uuid.Nil, "", uuid.Nil, false
+	}
+
+	relType := domain.RelationType(chi.URLParam(r, "type"))
+	if !relType.Valid() {
+		RespondWithError(w, r, "invalid relation type", http.StatusBadRequest)
+		return uuid.Nil, "", uuid.Nil, false
+	}
+
+	relatedID, err := uuid.Parse(chi.URLParam(r, "relatedID"))
+	if err != nil {
+		RespondWithError(w, r, "invalid related product ID format", http.StatusBadRequest)
+		return uuid.Nil, "", uuid.Nil, false
+	}
+
+	return productID, relType, relatedID, true
+}
@@ -2,7 +2,6 @@ package api
 
 import (
 	"encoding/json"
-	"microservice/services/product-service/internal/infrastructure/validator"
 	"net/http"
 )
 
@@ -23,6 +22,8 @@ var statusMessages = map[int]string{
 	http.StatusForbidden:           "Forbidden",
 	http.StatusNotFound:            "Not Found",
 	http.StatusConflict:            "Conflict",
+	http.StatusUnprocessableEntity: "Unprocessable Entity",
+	http.StatusTooManyRequests:     "Too Many Requests",
 	http.StatusInternalServerError: "Internal Server Error",
 }
 
@@ -69,6 +70,21 @@ func NewPagination(currentPage, perPage, totalItems int) Pagination {
 
 // RespondWithJSON sends a JSON response with the given status code
 func RespondWithJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	jsonData, err := marshalResponse(statusCode, data)
+	if err != nil {
+		// If marshaling fails, send a simple error response
+		respondWithEncodingError(w)
+		return
+	}
+
+	// Set content type and status code
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(jsonData)
+}
+
+// marshalResponse wraps data in the standard APIResponse and encodes it
+func marshalResponse(statusCode int, data interface{}) ([]byte, error) {
 	// Get default message for status code or use "Success" as fallback
 	message, ok := statusMessages[statusCode]
 	if !ok {
@@ -94,29 +110,13 @@ func RespondWithJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	}
 
 	// Marshal response to JSON
-	jsonData, err := json.Marshal(response)
-	if err != nil {
-		// If marshaling fails, send a simple error response
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"type":"error","message":"Failed to encode response"}`))
-		return
-	}
-
-	// Set content type and status code
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	w.Write(jsonData)
-}
-
-// RespondWithError sends an error response
-func RespondWithError(w http.ResponseWriter, message string, statusCode int) {
-	RespondWithJSON(w, statusCode, map[string]string{"message": message})
+	return json.Marshal(response)
 }
 
-// RespondWithValidationErrors sends validation errors
-func RespondWithValidationErrors(w http.ResponseWriter, errors []validator.ValidationError) {
-	RespondWithJSON(w, http.StatusBadRequest, errors)
+func respondWithEncodingError(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte(`{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"failed to encode response"}`))
 }
 
 // RespondWithPagination sends a paginated response
@@ -0,0 +1,381 @@
+package api
+
+import (
+	"microservice/pkg/auth"
+	"microservice/pkg/logger"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/infrastructure/taxcsv"
+	"microservice/services/product-service/internal/infrastructure/validator"
+	"microservice/services/product-service/internal/interfaces"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// maxTaxRatesSize caps the size of uploaded rate tables
+const maxTaxRatesSize = 4 << 20
+
+// TaxHandler serves tax rates, the tax classes of products and categories, and tax
+// calculations
+type TaxHandler struct {
+	service interfaces.TaxService
+	policy  *auth.Policy
+	logger  logger.Logger
+}
+
+func NewTaxHandler(service interfaces.TaxService, policy *auth.Policy, logger logger.Logger) *TaxHandler {
+	return &TaxHandler{
+		service: service,
+		policy:  policy,
+		logger:  logger,
+	}
+}
+
+// taxClassValidation validates tax class requests
+var taxClassValidation = validator.NewPipeline[TaxClassRequest]().
+	Check(func(v *validator.Validator, req *TaxClassRequest) { req.Validate(v) })
+
+// taxCalculationValidation validates tax calculation requests
+var taxCalculationValidation = validator.NewPipeline[TaxCalculationRequest]().
+	Check(func(v *validator.Validator, req *TaxCalculationRequest) { req.Validate(v) })
+
+func (h *TaxHandler) RegisterRoutes(r chi.Router) {
+	manage := Authorize(h.policy, domain.PermissionManageTax, h.logger)
+
+	r.Route("/tax", func(r chi.Router) {
+		r.With(manage).Get("/rates", h.ListTaxRates)
+		r.With(manage).Put("/rates", h.ReplaceTaxRates)
+		r.Post("/calculate", h.CalculateTax)
+	})
+	r.Route("/products/{id}/tax-class", func(r chi.Router) {
+		r.Get("/", h.GetProductTaxClass)
+		r.With(manage).Put("/", h.PutProductTaxClass)
+		r.With(manage).Delete("/", h.DeleteProductTaxClass)
+	})
+	r.Route("/categories/{id}/tax-class", func(r chi.Router) {
+		r.Use(manage)
+		r.Put("/", h.PutCategoryTaxClass)
+		r.Delete("/", h.DeleteCategoryTaxClass)
+	})
+}
+
+// ListTaxRates godoc
+// @Summary List tax rates
+// @Description List the rate table, ordered by country, region, tax class and start. Sending Accept: text/csv returns it in the CSV format PUT /tax/rates loads.
+// @Tags tax
+// @Produce json
+// @Produce text/csv
+// @Success 200 {object} api.APIResponse{data=[]api.TaxRateResponse} "Success"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tax/rates [get]
+func (h *TaxHandler) ListTaxRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.service.ListRates(r.Context())
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to list tax rates", h.logger)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+
+	if strings.Contains(r.Header.Get("Accept"), taxcsv.ContentType) {
+		w.Header().Set("Content-Type", taxcsv.ContentType+"; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="tax_rates.csv"`)
+		if err := taxcsv.Write(w, rates); err != nil {
+			h.logger.Error("Failed to write tax rates: %v", err)
+		}
+		return
+	}
+
+	items := make([]TaxRateResponse, len(rates))
+	for i, rate := range rates {
+		items[i] = TaxRateResponseFromModel(rate)
+	}
+
+	RespondWithJSON(w, http.StatusOK, items)
+}
+
+// ReplaceTaxRates godoc
+// @Summary Load tax rates from CSV
+// @Description Replace the whole rate table with a CSV file. The header names the columns country, region, tax_class, rate, valid_from and valid_to, in any order; region and valid_to may be left out or empty. Countries are ISO 3166-1 alpha-2 codes, regions the subdivision part of ISO 3166-2 codes, rates percentages of the price without tax, and dates YYYY-MM-DD, valid_to being the first day a rate no longer applies. Rates of a class and place must not overlap. Errors name the line at fault and leave the table unchanged.
+// @Tags tax
+// @Accept text/csv
+// @Produce json
+// @Param rates body string true "Rate table"
+// @Success 200 {object} api.APIResponse{data=[]api.TaxRateResponse} "Success"
+// @Failure 400 {object} api.Problem "Invalid Tax Rate"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tax/rates [put]
+func (h *TaxHandler) ReplaceTaxRates(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	rates, err := taxcsv.Read(http.MaxBytesReader(w, r.Body, maxTaxRatesSize))
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to read tax rates", h.logger)
+		return
+	}
+
+	if err := h.service.ReplaceRates(r.Context(), rates); err != nil {
+		respondWithDomainError(w, r, err, "failed to replace tax rates", h.logger)
+		return
+	}
+
+	h.logger.Info("Tax rates replaced with %d rates by %s", len(rates), domain.AuditContextFromContext(r.Context()).Actor)
+
+	items := make([]TaxRateResponse, len(rates))
+	for i, rate := range rates {
+		items[i] = TaxRateResponseFromModel(rate)
+	}
+
+	RespondWithJSON(w, http.StatusOK, items)
+}
+
+// CalculateTax godoc
+// @Summary Calculate tax
+// @Description Tax the items at the current price of their product, which is stored without tax, at the rates applying at the destination on the date. Products take the tax class assigned to them, else the one of their category, else standard; a region's rate wins over the country's. Tax is rounded per line, or once per rate on the total and shared out to the lines.
+// @Tags tax
+// @Accept json
+// @Produce json
+// @Param calculation body api.TaxCalculationRequest true "Items and destination"
+// @Success 200 {object} api.APIResponse{data=api.TaxCalculationResponse} "Success"
+// @Failure 400 {object} api.Problem "Validation Error"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 422 {object} api.Problem "Tax Rate Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /tax/calculate [post]
+func (h *TaxHandler) CalculateTax(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	req, errs, err := taxCalculationValidation.Run(r.Context(), validator.DecodeJSON[TaxCalculationRequest](r.Body))
+	if err != nil {
+		RespondWithError(w, r, "failed to decode request body", http.StatusBadRequest)
+		return
+	}
+	if len(errs) > 0 {
+		RespondWithValidationErrors(w, r, errs)
+		return
+	}
+
+	calc, err := h.service.Calculate(r.Context(), req.ToModel(), req.Destination(), req.Date,
+		domain.PriceDisplay(req.Display), domain.TaxRounding(req.Rounding))
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to calculate tax", h.logger)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, TaxCalculationResponseFromModel(calc))
+}
+
+// GetProductTaxClass godoc
+// @Summary Get the tax class of a product
+// @Description Get the tax class a product is taxed in: its own, else the one of its category, else standard
+// @Tags tax
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Success 200 {object} api.APIResponse{data=api.TaxClassResponse} "Success"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/tax-class [get]
+func (h *TaxHandler) GetProductTaxClass(w http.ResponseWriter, r *http.Request) {
+	productID, ok := parseProductID(w, r)
+	if !ok {
+		return
+	}
+
+	class, source, err := h.service.ProductTaxClass(r.Context(), productID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to get tax class", h.logger)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, TaxClassResponse{TaxClass: class, Source: string(source)})
+}
+
+// PutProductTaxClass godoc
+// @Summary Set the tax class of a product
+// @Description Assign a tax class to a product, overriding the one of its category
+// @Tags tax
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Param class body api.TaxClassRequest true "Tax class"
+// @Success 200 {object} api.APIResponse{data=api.TaxClassResponse} "Success"
+// @Failure 400 {object} api.Problem "Validation Error"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/tax-class [put]
+func (h *TaxHandler) PutProductTaxClass(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	productID, ok := parseProductID(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := h.decodeTaxClass(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.SetProductTaxClass(r.Context(), productID, req.TaxClass); err != nil {
+		respondWithDomainError(w, r, err, "failed to set tax class", h.logger)
+		return
+	}
+
+	h.logger.Info("Product %s assigned tax class %s", productID, req.TaxClass)
+
+	RespondWithJSON(w, http.StatusOK, TaxClassResponse{TaxClass: req.TaxClass, Source: string(domain.TaxClassFromProduct)})
+}
+
+// DeleteProductTaxClass godoc
+// @Summary Remove the tax class of a product
+// @Description Remove the tax class assigned to a product, which then takes the one of its category
+// @Tags tax
+// @Param id path string true "Product ID" format(uuid)
+// @Success 204 "No Content"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/tax-class [delete]
+func (h *TaxHandler) DeleteProductTaxClass(w http.ResponseWriter, r *http.Request) {
+	productID, ok := parseProductID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.SetProductTaxClass(r.Context(), productID, ""); err != nil {
+		respondWithDomainError(w, r, err, "failed to remove tax class", h.logger)
+		return
+	}
+
+	h.logger.Info("Product %s tax class removed", productID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PutCategoryTaxClass godoc
+// @Summary Set the tax class of a category
+// @Description Assign a tax class to the products of a category that have none of their own
+// @Tags tax
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID" format(uuid)
+// @Param class body api.TaxClassRequest true "Tax class"
+// @Success 200 {object} api.APIResponse{data=api.TaxClassResponse} "Success"
+// @Failure 400 {object} api.Problem "Validation Error"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /categories/{id}/tax-class [put]
+func (h *TaxHandler) PutCategoryTaxClass(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	categoryID, ok := parseCategoryID(w, r)
+	if !ok {
+		return
+	}
+
+	req, ok := h.decodeTaxClass(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.SetCategoryTaxClass(r.Context(), categoryID, req.TaxClass); err != nil {
+		respondWithDomainError(w, r, err, "failed to set tax class", h.logger)
+		return
+	}
+
+	h.logger.Info("Category %s assigned tax class %s", categoryID, req.TaxClass)
+
+	RespondWithJSON(w, http.StatusOK, TaxClassResponse{TaxClass: req.TaxClass, Source: string(domain.TaxClassFromCategory)})
+}
+
+// DeleteCategoryTaxClass godoc
+// @Summary Remove the tax class of a category
+// @Description Remove the tax class assigned to a category; its products without a class of their own fall back to standard
+// @Tags tax
+// @Param id path string true "Category ID" format(uuid)
+// @Success 204 "No Content"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /categories/{id}/tax-class [delete]
+func (h *TaxHandler) DeleteCategoryTaxClass(w http.ResponseWriter, r *http.Request) {
+	categoryID, ok := parseCategoryID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.SetCategoryTaxClass(r.Context(), categoryID, ""); err != nil {
+		respondWithDomainError(w, r, err, "failed to remove tax class", h.logger)
+		return
+	}
+
+	h.logger.Info("Category %s tax class removed", categoryID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeTaxClass decodes and validates a tax class request, responding with an error
+// if it is invalid
+func (h *TaxHandler) decodeTaxClass(w http.ResponseWriter, r *http.Request) (*TaxClassRequest, bool) {
+	req, errs, err := taxClassValidation.Run(r.Context(), validator.DecodeJSON[TaxClassRequest](r.Body))
+	if err != nil {
+		RespondWithError(w, r, "failed to decode request body", http.StatusBadRequest)
+		return nil, false
+	}
+	if len(errs) > 0 {
+		RespondWithValidationErrors(w, r, errs)
+		return nil, false
+	}
+	return req, true
+}
+
+// parseCategoryID parses the category ID of the path, responding with an error if it
+// is invalid
+func parseCategoryID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		RespondWithError(w, r, "invalid category ID format", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
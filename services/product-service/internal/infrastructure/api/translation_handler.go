@@ -0,0 +1,203 @@
+package api
+
+import (
+	"microservice/pkg/auth"
+	"microservice/pkg/logger"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/infrastructure/validator"
+	"microservice/services/product-service/internal/interfaces"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// TranslationHandler serves the translations of the name and description of products
+type TranslationHandler struct {
+	service interfaces.TranslationService
+	policy  *auth.Policy
+	logger  logger.Logger
+}
+
+func NewTranslationHandler(service interfaces.TranslationService, policy *auth.Policy, logger logger.Logger) *TranslationHandler {
+	return &TranslationHandler{
+		service: service,
+		policy:  policy,
+		logger:  logger,
+	}
+}
+
+// translationValidation validates translation requests
+var translationValidation = validator.NewPipeline[TranslationRequest]().
+	Check(func(v *validator.Validator, req *TranslationRequest) { req.Validate(v) })
+
+func (h *TranslationHandler) RegisterRoutes(r chi.Router) {
+	r.Route("/products/{id}/translations", func(r chi.Router) {
+		r.Get("/", h.ListTranslations)
+		r.Get("/{locale}", h.GetTranslation)
+		r.With(Authorize(h.policy, domain.PermissionUpdateProduct, h.logger)).Put("/{locale}", h.PutTranslation)
+		r.With(Authorize(h.policy, domain.PermissionUpdateProduct, h.logger)).Delete("/{locale}", h.DeleteTranslation)
+	})
+}
+
+// ListTranslations godoc
+// @Summary List the translations of a product
+// @Description List the translations of the name and description of a product, ordered by locale
+// @Tags translations
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Success 200 {object} api.APIResponse{data=[]api.TranslationResponse} "Success"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/translations [get]
+func (h *TranslationHandler) ListTranslations(w http.ResponseWriter, r *http.Request) {
+	productID, ok := parseProductID(w, r)
+	if !ok {
+		return
+	}
+
+	translations, err := h.service.List(r.Context(), productID)
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to list translations", h.logger)
+		return
+	}
+
+	items := make([]TranslationResponse, len(translations))
+	for i, t := range translations {
+		items[i] = TranslationResponseFromModel(t)
+	}
+
+	RespondWithJSON(w, http.StatusOK, items)
+}
+
+// GetTranslation godoc
+// @Summary Get a translation of a product
+// @Description Get the translation of a product in a locale. The locale must match exactly; no fallback applies.
+// @Tags translations
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Param locale path string true "BCP 47 language tag" example(de-AT)
+// @Success 200 {object} api.APIResponse{data=api.TranslationResponse} "Success"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/translations/{locale} [get]
+func (h *TranslationHandler) GetTranslation(w http.ResponseWriter, r *http.Request) {
+	productID, ok := parseProductID(w, r)
+	if !ok {
+		return
+	}
+
+	translation, err := h.service.Get(r.Context(), productID, chi.URLParam(r, "locale"))
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to get translation", h.logger)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, TranslationResponseFromModel(translation))
+}
+
+// PutTranslation godoc
+// @Summary Create or replace a translation of a product
+// @Description Create or replace the translation of the name and description of a product in a locale. The locale is stored in its canonical form, as de-AT for de_at; the default locale cannot be translated into.
+// @Tags translations
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID" format(uuid)
+// @Param locale path string true "BCP 47 language tag" example(de-AT)
+// @Param translation body api.TranslationRequest true "Translation"
+// @Success 200 {object} api.APIResponse{data=api.TranslationResponse} "Replaced"
+// @Success 201 {object} api.APIResponse{data=api.TranslationResponse} "Created"
+// @Failure 400 {object} api.Problem "Validation Error"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/translations/{locale} [put]
+func (h *TranslationHandler) PutTranslation(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	productID, ok := parseProductID(w, r)
+	if !ok {
+		return
+	}
+
+	req, errs, err := translationValidation.Run(r.Context(), validator.DecodeJSON[TranslationRequest](r.Body))
+	if err != nil {
+		RespondWithError(w, r, "failed to decode request body", http.StatusBadRequest)
+		return
+	}
+	if len(errs) > 0 {
+		RespondWithValidationErrors(w, r, errs)
+		return
+	}
+
+	translation := req.ToModel(productID, chi.URLParam(r, "locale"))
+	created, err := h.service.Save(r.Context(), translation)
+	if err != nil {
+		respondWithDomainError(w, r, err, "failed to save translation", h.logger)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+
+	h.logger.Info("Translation %s of product %s saved", translation.Locale, translation.ProductID)
+
+	RespondWithJSON(w, status, TranslationResponseFromModel(translation))
+}
+
+// DeleteTranslation godoc
+// @Summary Delete a translation of a product
+// @Description Delete the translation of a product in a locale
+// @Tags translations
+// @Param id path string true "Product ID" format(uuid)
+// @Param locale path string true "BCP 47 language tag" example(de-AT)
+// @Success 204 "No Content"
+// @Failure 400 {object} api.Problem "Bad Request"
+// @Failure 401 {object} api.Problem "Unauthorized"
+// @Failure 403 {object} api.Problem "Forbidden"
+// @Failure 404 {object} api.Problem "Not Found"
+// @Failure 429 {object} api.Problem "Too Many Requests"
+// @Failure 500 {object} api.Problem "Internal Server Error"
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /products/{id}/translations/{locale} [delete]
+func (h *TranslationHandler) DeleteTranslation(w http.ResponseWriter, r *http.Request) {
+	productID, ok := parseProductID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), productID, chi.URLParam(r, "locale")); err != nil {
+		respondWithDomainError(w, r, err, "failed to delete translation", h.logger)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseProductID parses the product ID in the path of r, sending the error response
+// if it is malformed
+func parseProductID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		RespondWithError(w, r, "invalid product ID format", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	return id, true
+}
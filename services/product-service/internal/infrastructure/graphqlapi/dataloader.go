@@ -0,0 +1,88 @@
+package graphqlapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// batchFunc fetches the values of several keys at once. Keys missing from the
+// returned map resolve to the zero value.
+type batchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// dataLoader collects the keys loaded within a short window and fetches them in
+// one batch, so resolving a field on every element of a list costs one query
+// instead of one per element. Results are cached for the life of the loader,
+// which is a single request.
+type dataLoader[K comparable, V any] struct {
+	ctx      context.Context
+	fetch    batchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	pending *loaderBatch[K, V]
+	results map[K]*loaderBatch[K, V]
+}
+
+type loaderBatch[K comparable, V any] struct {
+	keys   []K
+	once   sync.Once
+	done   chan struct{}
+	values map[K]V
+	err    error
+}
+
+func newDataLoader[K comparable, V any](ctx context.Context, wait time.Duration, maxBatch int, fetch batchFunc[K, V]) *dataLoader[K, V] {
+	return &dataLoader[K, V]{
+		ctx:      ctx,
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		results:  make(map[K]*loaderBatch[K, V]),
+	}
+}
+
+// Load returns the value of key once its batch has been fetched
+func (l *dataLoader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	b, ok := l.results[key]
+	if !ok {
+		if l.pending == nil {
+			pending := &loaderBatch[K, V]{done: make(chan struct{})}
+			l.pending = pending
+			time.AfterFunc(l.wait, func() { l.dispatch(pending) })
+		}
+		b = l.pending
+		b.keys = append(b.keys, key)
+		l.results[key] = b
+
+		if len(b.keys) >= l.maxBatch {
+			l.pending = nil
+			go l.dispatch(b)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return b.values[key], b.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// dispatch fetches the batch; it runs once even when both the timer and the size limit fire
+func (l *dataLoader[K, V]) dispatch(b *loaderBatch[K, V]) {
+	b.once.Do(func() {
+		l.mu.Lock()
+		if l.pending == b {
+			l.pending = nil
+		}
+		l.mu.Unlock()
+
+		b.values, b.err = l.fetch(l.ctx, b.keys)
+		close(b.done)
+	})
+}
@@ -0,0 +1,89 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"microservice/pkg/auth"
+	"microservice/pkg/logger"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/infrastructure/validator"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error codes reported in the "code" extension of errors
+const (
+	CodeBadUserInput        = "BAD_USER_INPUT"
+	CodeNotFound            = "NOT_FOUND"
+	CodeConflict            = "CONFLICT"
+	CodeUnauthenticated     = "UNAUTHENTICATED"
+	CodeForbidden           = "FORBIDDEN"
+	CodeInternalServerError = "INTERNAL_SERVER_ERROR"
+)
+
+// validationError carries the field errors of a rejected input
+type validationError struct {
+	errors []validator.ValidationError
+}
+
+func newValidationError(errs []validator.ValidationError) error {
+	return &validationError{errors: errs}
+}
+
+func (e *validationError) Error() string {
+	v := validator.Validator{Errors: e.errors}
+	return v.ErrorMessage()
+}
+
+// errorPresenter adds a code to resolver errors and hides the message of unexpected ones
+func errorPresenter(logger logger.Logger) graphql.ErrorPresenterFunc {
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+		var gerr *gqlerror.Error
+		if errors.As(err, &gerr) && gerr.Err == nil {
+			// Parsing, validation and limit errors already describe the problem
+			return gqlErr
+		}
+
+		var verr *validationError
+		switch {
+		case errors.As(err, &verr):
+			fields := validator.Localize(verr.errors, validator.ContextLocale(ctx))
+			gqlErr.Message = (&validator.Validator{Errors: fields}).ErrorMessage()
+			setExtension(gqlErr, "code", CodeBadUserInput)
+			setExtension(gqlErr, "fields", fields)
+		case errors.Is(err, domain.ErrProductNotFound), errors.Is(err, domain.ErrCategoryNotFound):
+			setExtension(gqlErr, "code", CodeNotFound)
+		case errors.Is(err, domain.ErrInvalidProduct), errors.Is(err, domain.ErrInvalidPrice), errors.Is(err, domain.ErrInvalidCategory), errors.Is(err, domain.ErrInvalidStatus), errors.Is(err, domain.ErrInvalidType):
+			setExtension(gqlErr, "code", CodeBadUserInput)
+		case errors.Is(err, domain.ErrDuplicateSKU), errors.Is(err, domain.ErrComponentInUse):
+			setExtension(gqlErr, "code", CodeConflict)
+		case errors.Is(err, auth.ErrMissingToken):
+			gqlErr.Message = auth.Message(err)
+			setExtension(gqlErr, "code", CodeUnauthenticated)
+		case errors.Is(err, auth.ErrForbidden):
+			principal, _ := auth.PrincipalFromContext(ctx)
+			logger.Warn("Access denied: path=%s subject=%s roles=%v scopes=%v error=%q",
+				gqlErr.Path, principal.Subject, principal.Roles, principal.Scopes, err.Error())
+			gqlErr.Message = "you do not have permission to perform this action"
+			setExtension(gqlErr, "code", CodeForbidden)
+		case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+			setExtension(gqlErr, "code", CodeInternalServerError)
+		default:
+			logger.Error("GraphQL resolver failed at %s: %v", gqlErr.Path, err)
+			gqlErr.Message = "internal error"
+			setExtension(gqlErr, "code", CodeInternalServerError)
+		}
+
+		return gqlErr
+	}
+}
+
+func setExtension(err *gqlerror.Error, key string, value any) {
+	if err.Extensions == nil {
+		err.Extensions = make(map[string]any)
+	}
+	err.Extensions[key] = value
+}
@@ -0,0 +1,107 @@
+package graphqlapi
+
+import (
+	"context"
+	"fmt"
+	"microservice/pkg/logger"
+	"microservice/pkg/telemetry"
+	"microservice/services/product-service/internal/interfaces"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const queryCacheSize = 1000
+
+// Options holds the limits of the GraphQL endpoint
+type Options struct {
+	MaxDepth      int
+	MaxComplexity int
+	Introspection bool
+}
+
+// NewHandler returns the handler of the GraphQL endpoint
+func NewHandler(products interfaces.Service, categories interfaces.CategoryService, opts Options, logger logger.Logger) http.Handler {
+	schema := NewExecutableSchema(Config{
+		Resolvers: &Resolver{
+			products:   products,
+			categories: categories,
+			validation: newProductValidation(products),
+		},
+		Complexity: complexity(),
+	})
+
+	srv := handler.New(schema)
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](queryCacheSize))
+
+	if opts.Introspection {
+		srv.Use(extension.Introspection{})
+	}
+	srv.Use(depthLimit{max: opts.MaxDepth})
+	srv.Use(extension.FixedComplexityLimit(opts.MaxComplexity))
+
+	srv.AroundOperations(traceOperation)
+	srv.AroundFields(traceResolver)
+	srv.SetErrorPresenter(errorPresenter(logger))
+	srv.SetRecoverFunc(func(ctx context.Context, err any) error {
+		logger.Error("GraphQL resolver panicked: %v", err)
+		return fmt.Errorf("panic: %v", err)
+	})
+
+	return withLoaders(products, categories, srv)
+}
+
+// PlaygroundHandler serves an interactive GraphQL client for endpoint
+func PlaygroundHandler(endpoint string) http.Handler {
+	return playground.Handler("Product Service GraphQL", endpoint)
+}
+
+// traceOperation names the request span after the operation
+func traceOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	oc := graphql.GetOperationContext(ctx)
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.String("graphql.operation.name", oc.OperationName))
+	if oc.Operation != nil {
+		span.SetAttributes(attribute.String("graphql.operation.type", string(oc.Operation.Operation)))
+	}
+
+	return next(ctx)
+}
+
+// traceResolver opens a span for every field backed by a resolver. Fields read
+// straight from a struct are not traced, they would only add noise.
+func traceResolver(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if !fc.IsResolver {
+		return next(ctx)
+	}
+
+	ctx, span := telemetry.Tracer().Start(ctx, fc.Object+"."+fc.Field.Name,
+		trace.WithAttributes(
+			attribute.String("graphql.field.path", fc.Path().String()),
+			attribute.String("graphql.field.type", fc.Field.Definition.Type.String()),
+		),
+	)
+	defer span.End()
+
+	res, err := next(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return res, err
+}
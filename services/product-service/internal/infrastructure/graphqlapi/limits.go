@@ -0,0 +1,102 @@
+package graphqlapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const errDepthLimit = "DEPTH_LIMIT_EXCEEDED"
+
+// Estimated sizes of the lists whose length is not bounded by an argument
+const (
+	productsPerCategoryEstimate = 20
+	searchResultsEstimate       = 50
+)
+
+// depthLimit rejects operations that nest selections deeper than max. Introspection
+// fields are not counted so tools can still fetch the schema.
+type depthLimit struct {
+	max int
+}
+
+func (d depthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (d depthLimit) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (d depthLimit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if depth := selectionDepth(rc.Operation.SelectionSet); depth > d.max {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.max)
+		err.Extensions = map[string]any{"code": errDepthLimit}
+		return err
+	}
+
+	return nil
+}
+
+// selectionDepth returns the number of nested field levels in set. Fragment cycles are
+// rejected by validation before the operation context is mutated.
+func selectionDepth(set ast.SelectionSet) int {
+	depth := 0
+	for _, selection := range set {
+		var d int
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			d = 1 + selectionDepth(s.SelectionSet)
+		case *ast.InlineFragment:
+			d = selectionDepth(s.SelectionSet)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				d = selectionDepth(s.Definition.SelectionSet)
+			}
+		}
+		depth = max(depth, d)
+	}
+
+	return depth
+}
+
+// complexity weighs list fields by the number of elements they may return, so the
+// complexity limit accounts for the rows a query can fetch
+func complexity() ComplexityRoot {
+	var c ComplexityRoot
+
+	c.Query.Products = func(childComplexity int, limit int, offset int) int {
+		return 1 + pageSize(limit)*childComplexity
+	}
+	c.Query.Categories = func(childComplexity int, limit int, offset int) int {
+		return 1 + pageSize(limit)*childComplexity
+	}
+	c.Query.SearchProducts = func(childComplexity int, query string) int {
+		return 1 + searchResultsEstimate*childComplexity
+	}
+	c.Category.Products = func(childComplexity int) int {
+		return 1 + productsPerCategoryEstimate*childComplexity
+	}
+	c.Mutation.CreateProduct = func(childComplexity int, input ProductInput) int {
+		return 10 + childComplexity
+	}
+	c.Mutation.UpdateProduct = func(childComplexity int, id uuid.UUID, input ProductInput) int {
+		return 10 + childComplexity
+	}
+
+	return c
+}
+
+// pageSize clamps a limit argument to the page sizes the resolvers accept. Complexity
+// is computed before the arguments are validated, so a negative limit would otherwise
+// lower the cost of a query and a huge one overflow it.
+func pageSize(limit int) int {
+	return min(max(limit, 1), maxPageSize)
+}
@@ -0,0 +1,99 @@
+package graphqlapi
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"microservice/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testMaxComplexity = 1000
+
+// operationErrors posts query and returns the codes of the errors in the response
+func operationErrors(t *testing.T, query string) []string {
+	t.Helper()
+
+	h := NewHandler(nil, nil, Options{MaxDepth: 10, MaxComplexity: testMaxComplexity}, logger.NewLogger(logger.Fatal, io.Discard, false))
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var resp struct {
+		Errors []struct {
+			Extensions struct {
+				Code string `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %s: %v", rec.Body, err)
+	}
+
+	codes := make([]string, len(resp.Errors))
+	for i, e := range resp.Errors {
+		codes[i] = e.Extensions.Code
+	}
+	return codes
+}
+
+func TestComplexityClampsLimit(t *testing.T) {
+	// A full page of categories with their products costs 1 + 100 * (1 + 1 + 20 * 2),
+	// well over the limit, which a negative limit elsewhere must not offset
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{
+			name:  "huge limit",
+			query: `{ categories(limit: 2147483647) { items { products { id name } } } }`,
+		},
+		{
+			name: "negative limit offsetting another field",
+			query: `{
+				cheap: categories(limit: -1000) { items { products { id name } } }
+				costly: categories(limit: 100) { items { products { id name } } }
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes := operationErrors(t, tt.query)
+			if len(codes) != 1 || codes[0] != "COMPLEXITY_LIMIT_EXCEEDED" {
+				t.Errorf("error codes = %v, want COMPLEXITY_LIMIT_EXCEEDED", codes)
+			}
+		})
+	}
+}
+
+func TestComplexityOfPages(t *testing.T) {
+	c := complexity()
+	const child = 42
+
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{-1000, 1 + child},
+		{0, 1 + child},
+		{20, 1 + 20*child},
+		{maxPageSize, 1 + maxPageSize*child},
+		{2147483647, 1 + maxPageSize*child},
+		{math.MaxInt, 1 + maxPageSize*child},
+	}
+
+	for _, tt := range tests {
+		if got := c.Query.Products(child, tt.limit, 0); got != tt.want {
+			t.Errorf("products(limit: %d) complexity = %d, want %d", tt.limit, got, tt.want)
+		}
+		if got := c.Query.Categories(child, tt.limit, 0); got != tt.want {
+			t.Errorf("categories(limit: %d) complexity = %d, want %d", tt.limit, got, tt.want)
+		}
+	}
+}
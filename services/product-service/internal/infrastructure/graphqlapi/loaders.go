@@ -0,0 +1,67 @@
+package graphqlapi
+
+import (
+	"context"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/interfaces"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	loaderWait     = 2 * time.Millisecond
+	loaderMaxBatch = 100
+)
+
+type loadersKey struct{}
+
+// loaders holds the data loaders of a single request
+type loaders struct {
+	categoryByID       *dataLoader[uuid.UUID, *domain.Category]
+	productsByCategory *dataLoader[uuid.UUID, []*domain.Product]
+}
+
+func newLoaders(ctx context.Context, products interfaces.Service, categories interfaces.CategoryService) *loaders {
+	return &loaders{
+		categoryByID: newDataLoader(ctx, loaderWait, loaderMaxBatch, func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*domain.Category, error) {
+			found, err := categories.GetByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byID := make(map[uuid.UUID]*domain.Category, len(found))
+			for _, category := range found {
+				byID[category.ID] = category
+			}
+			return byID, nil
+		}),
+		productsByCategory: newDataLoader(ctx, loaderWait, loaderMaxBatch, func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]*domain.Product, error) {
+			found, err := products.GetByCategoryIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+
+			byCategory := make(map[uuid.UUID][]*domain.Product, len(ids))
+			for _, product := range found {
+				byCategory[product.CategoryID] = append(byCategory[product.CategoryID], product)
+			}
+			return byCategory, nil
+		}),
+	}
+}
+
+// withLoaders gives every request its own loaders, so cached results never leak between requests
+func withLoaders(products interfaces.Service, categories interfaces.CategoryService, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		ctx = context.WithValue(ctx, loadersKey{}, newLoaders(ctx, products, categories))
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func loadersFromContext(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
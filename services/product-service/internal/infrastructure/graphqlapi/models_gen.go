@@ -0,0 +1,37 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graphqlapi
+
+import (
+	"microservice/services/product-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type CategoryPage struct {
+	Items []*domain.Category `json:"items"`
+	Total int                `json:"total"`
+}
+
+type Mutation struct {
+}
+
+type ProductInput struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Price       float64   `json:"price"`
+	Sku         string    `json:"sku"`
+	CategoryID  uuid.UUID `json:"categoryId"`
+	// Defaults to PUBLISHED on create and is left unchanged on update when omitted
+	Status *domain.ProductStatus `json:"status,omitempty"`
+	// Defaults to SIMPLE on create and cannot change
+	Type *domain.ProductType `json:"type,omitempty"`
+}
+
+type ProductPage struct {
+	Items []*domain.Product `json:"items"`
+	Total int               `json:"total"`
+}
+
+type Query struct {
+}
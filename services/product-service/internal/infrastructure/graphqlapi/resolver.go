@@ -0,0 +1,103 @@
+package graphqlapi
+
+import (
+	"context"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/infrastructure/validator"
+	"microservice/services/product-service/internal/interfaces"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// This file will not be regenerated automatically.
+
+// maxPageSize caps the limit argument of the paginated queries
+const maxPageSize = 100
+
+// Resolver is the root resolver of the catalog schema
+type Resolver struct {
+	products   interfaces.Service
+	categories interfaces.CategoryService
+	validation *validator.Pipeline[ProductInput]
+}
+
+// newProductValidation applies the rules of the REST API to product inputs and checks
+// that their category exists
+func newProductValidation(products interfaces.Service) *validator.Pipeline[ProductInput] {
+	return validator.NewPipeline[ProductInput]().
+		Check(func(v *validator.Validator, input *ProductInput) {
+			v.Required("name", input.Name)
+			v.Required("description", input.Description)
+			v.Required("sku", input.Sku)
+			v.MinValue("price", input.Price, 0.01)
+			v.CheckRule(input.CategoryID != uuid.Nil, "categoryId", "required", nil)
+		}).
+		CheckAsync(func(ctx context.Context, v *validator.Validator, input *ProductInput) error {
+			if input.CategoryID == uuid.Nil {
+				return nil
+			}
+
+			exists, err := products.CategoryExists(ctx, input.CategoryID)
+			if err != nil {
+				return err
+			}
+
+			v.CheckRule(exists, "categoryId", "category_not_found", nil)
+			return nil
+		})
+}
+
+func (r *Resolver) validate(ctx context.Context, input ProductInput) error {
+	errs, err := r.validation.Validate(ctx, &input)
+	if err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return newValidationError(errs)
+	}
+
+	return nil
+}
+
+func validatePage(limit, offset int) error {
+	v := validator.New()
+	v.CheckRule(limit >= 1 && limit <= maxPageSize, "limit", "between", validator.Params{"min": "1", "max": strconv.Itoa(maxPageSize)})
+	v.CheckRule(offset >= 0, "offset", "gte", validator.Params{"param": "0"})
+
+	if !v.Valid() {
+		return newValidationError(v.Errors)
+	}
+
+	return nil
+}
+
+func validateQuery(query string) error {
+	v := validator.New()
+	v.Required("query", strings.TrimSpace(query))
+
+	if !v.Valid() {
+		return newValidationError(v.Errors)
+	}
+
+	return nil
+}
+
+func (in ProductInput) toModel() *domain.Product {
+	product := &domain.Product{
+		Name:        in.Name,
+		Description: in.Description,
+		Price:       domain.Money(in.Price),
+		SKU:         in.Sku,
+		CategoryID:  in.CategoryID,
+	}
+	if in.Status != nil {
+		product.Status = *in.Status
+	}
+	if in.Type != nil {
+		product.Type = *in.Type
+	}
+	return product
+}
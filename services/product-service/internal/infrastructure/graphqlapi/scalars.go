@@ -0,0 +1,51 @@
+package graphqlapi
+
+import (
+	"io"
+	"microservice/services/product-service/internal/domain"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// MarshalUUID writes an ID as a string
+func MarshalUUID(id uuid.UUID) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		_, _ = io.WriteString(w, strconv.Quote(id.String()))
+	})
+}
+
+// UnmarshalUUID parses an ID argument, which must be a UUID
+func UnmarshalUUID(v any) (uuid.UUID, error) {
+	s, ok := v.(string)
+	if !ok {
+		return uuid.Nil, badUserInput("ID must be a string")
+	}
+
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, badUserInput("ID is not a valid uuid")
+	}
+
+	return id, nil
+}
+
+func badUserInput(message string) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message:    message,
+		Extensions: map[string]any{"code": CodeBadUserInput},
+	}
+}
+
+// MarshalMoney writes a price as a Float
+func MarshalMoney(m domain.Money) graphql.Marshaler {
+	return graphql.MarshalFloat(float64(m))
+}
+
+// UnmarshalMoney parses a Float argument as a price
+func UnmarshalMoney(v any) (domain.Money, error) {
+	f, err := graphql.UnmarshalFloat(v)
+	return domain.Money(f), err
+}
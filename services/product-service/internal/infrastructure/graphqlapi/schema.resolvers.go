@@ -0,0 +1,162 @@
+package graphqlapi
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.70
+
+import (
+	"context"
+	"errors"
+	"microservice/services/product-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Products is the resolver for the products field.
+func (r *categoryResolver) Products(ctx context.Context, obj *domain.Category) ([]*domain.Product, error) {
+	products, err := loadersFromContext(ctx).productsByCategory.Load(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if products == nil {
+		products = []*domain.Product{}
+	}
+
+	return products, nil
+}
+
+// CreateProduct is the resolver for the createProduct field.
+func (r *mutationResolver) CreateProduct(ctx context.Context, input ProductInput) (*domain.Product, error) {
+	if err := r.validate(ctx, input); err != nil {
+		return nil, err
+	}
+
+	product := input.toModel()
+	if err := r.products.Create(ctx, product); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// UpdateProduct is the resolver for the updateProduct field.
+func (r *mutationResolver) UpdateProduct(ctx context.Context, id uuid.UUID, input ProductInput) (*domain.Product, error) {
+	if err := r.validate(ctx, input); err != nil {
+		return nil, err
+	}
+
+	product := input.toModel()
+	product.ID = id
+	if err := r.products.Update(ctx, product); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// Category is the resolver for the category field.
+func (r *productResolver) Category(ctx context.Context, obj *domain.Product) (*domain.Category, error) {
+	return loadersFromContext(ctx).categoryByID.Load(ctx, obj.CategoryID)
+}
+
+// Product is the resolver for the product field.
+func (r *queryResolver) Product(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	product, err := r.products.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrProductNotFound) {
+		return nil, nil
+	}
+
+	return product, err
+}
+
+// ProductBySlug is the resolver for the productBySlug field.
+func (r *queryResolver) ProductBySlug(ctx context.Context, slug string) (*domain.Product, error) {
+	product, err := r.products.GetBySlug(ctx, slug)
+	if errors.Is(err, domain.ErrProductNotFound) {
+		return nil, nil
+	}
+
+	return product, err
+}
+
+// Products is the resolver for the products field.
+func (r *queryResolver) Products(ctx context.Context, limit int, offset int) (*ProductPage, error) {
+	if err := validatePage(limit, offset); err != nil {
+		return nil, err
+	}
+
+	products, total, err := r.products.GetAll(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if products == nil {
+		products = []*domain.Product{}
+	}
+
+	return &ProductPage{Items: products, Total: total}, nil
+}
+
+// SearchProducts is the resolver for the searchProducts field.
+func (r *queryResolver) SearchProducts(ctx context.Context, query string) ([]*domain.Product, error) {
+	if err := validateQuery(query); err != nil {
+		return nil, err
+	}
+
+	products, err := r.products.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if products == nil {
+		products = []*domain.Product{}
+	}
+
+	return products, nil
+}
+
+// Category is the resolver for the category field.
+func (r *queryResolver) Category(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	category, err := r.categories.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrCategoryNotFound) {
+		return nil, nil
+	}
+
+	return category, err
+}
+
+// Categories is the resolver for the categories field.
+func (r *queryResolver) Categories(ctx context.Context, limit int, offset int) (*CategoryPage, error) {
+	if err := validatePage(limit, offset); err != nil {
+		return nil, err
+	}
+
+	categories, total, err := r.categories.GetAll(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if categories == nil {
+		categories = []*domain.Category{}
+	}
+
+	return &CategoryPage{Items: categories, Total: total}, nil
+}
+
+// Category returns CategoryResolver implementation.
+func (r *Resolver) Category() CategoryResolver { return &categoryResolver{r} }
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Product returns ProductResolver implementation.
+func (r *Resolver) Product() ProductResolver { return &productResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+type categoryResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
+type productResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
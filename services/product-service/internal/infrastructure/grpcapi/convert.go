@@ -0,0 +1,89 @@
+package grpcapi
+
+import (
+	productv1 "microservice/services/product-service/api/gen/product/v1"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/infrastructure/validator"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var statusToProto = map[domain.ProductStatus]productv1.ProductStatus{
+	domain.StatusDraft:     productv1.ProductStatus_PRODUCT_STATUS_DRAFT,
+	domain.StatusPublished: productv1.ProductStatus_PRODUCT_STATUS_PUBLISHED,
+	domain.StatusArchived:  productv1.ProductStatus_PRODUCT_STATUS_ARCHIVED,
+}
+
+// statusFromProto maps UNSPECIFIED to the empty status, which means the default
+var statusFromProto = map[productv1.ProductStatus]domain.ProductStatus{
+	productv1.ProductStatus_PRODUCT_STATUS_DRAFT:     domain.StatusDraft,
+	productv1.ProductStatus_PRODUCT_STATUS_PUBLISHED: domain.StatusPublished,
+	productv1.ProductStatus_PRODUCT_STATUS_ARCHIVED:  domain.StatusArchived,
+}
+
+var typeToProto = map[domain.ProductType]productv1.ProductType{
+	domain.TypeSimple: productv1.ProductType_PRODUCT_TYPE_SIMPLE,
+	domain.TypeBundle: productv1.ProductType_PRODUCT_TYPE_BUNDLE,
+}
+
+// typeFromProto maps UNSPECIFIED to the empty type, which means the default
+var typeFromProto = map[productv1.ProductType]domain.ProductType{
+	productv1.ProductType_PRODUCT_TYPE_SIMPLE: domain.TypeSimple,
+	productv1.ProductType_PRODUCT_TYPE_BUNDLE: domain.TypeBundle,
+}
+
+// productToProto converts a domain.Product to its protobuf message
+func productToProto(product *domain.Product) *productv1.Product {
+	return &productv1.Product{
+		Id:          product.ID.String(),
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       float64(product.Price),
+		Sku:         product.SKU,
+		CategoryId:  product.CategoryID.String(),
+		CreatedAt:   timestamppb.New(product.CreatedAt),
+		UpdatedAt:   timestamppb.New(product.UpdatedAt),
+		Status:      statusToProto[product.Status],
+		Slug:        product.Slug,
+		Type:        typeToProto[product.Type],
+		Available:   product.IsAvailable(),
+	}
+}
+
+func productsToProto(products []*domain.Product) []*productv1.Product {
+	messages := make([]*productv1.Product, len(products))
+	for i, product := range products {
+		messages[i] = productToProto(product)
+	}
+	return messages
+}
+
+// validateInput applies the rules of the REST API to a product input
+func validateInput(v *validator.Validator, in *productv1.ProductInput) {
+	v.Required("name", in.GetName())
+	v.Required("description", in.GetDescription())
+	v.Required("sku", in.GetSku())
+	v.MinValue("price", in.GetPrice(), 0.01)
+
+	v.ValidUUID("category_id", in.GetCategoryId())
+
+	_, known := statusFromProto[in.GetStatus()]
+	v.CheckRule(known || in.GetStatus() == productv1.ProductStatus_PRODUCT_STATUS_UNSPECIFIED, "status", "oneof", validator.Params{"values": "draft, published, archived"})
+
+	_, known = typeFromProto[in.GetType()]
+	v.CheckRule(known || in.GetType() == productv1.ProductType_PRODUCT_TYPE_UNSPECIFIED, "type", "oneof", validator.Params{"values": "simple, bundle"})
+}
+
+// inputToModel converts a validated product input to a domain.Product
+func inputToModel(in *productv1.ProductInput, categoryID uuid.UUID) *domain.Product {
+	return &domain.Product{
+		Name:        in.GetName(),
+		Description: in.GetDescription(),
+		Price:       domain.Money(in.GetPrice()),
+		SKU:         in.GetSku(),
+		CategoryID:  categoryID,
+		Status:      statusFromProto[in.GetStatus()],
+		Type:        typeFromProto[in.GetType()],
+	}
+}
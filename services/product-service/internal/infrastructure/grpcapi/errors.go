@@ -0,0 +1,64 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"microservice/pkg/auth"
+	"microservice/services/product-service/internal/infrastructure/api"
+	"microservice/services/product-service/internal/infrastructure/validator"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatusError maps errors to status codes through the problems of the REST API.
+// Status errors are returned unchanged and unknown errors become Internal without
+// exposing their message.
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, auth.ErrMissingToken):
+		return status.Error(codes.Unauthenticated, auth.Message(err))
+	case errors.Is(err, auth.ErrForbidden):
+		return status.Error(codes.PermissionDenied, "you do not have permission to perform this action")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	if p, ok := api.Problems.Problem(err); ok {
+		return status.Error(p.Code, p.Detail)
+	}
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+// invalidArgument returns an InvalidArgument error carrying the validation errors as field violations
+func invalidArgument(errs []validator.ValidationError) error {
+	v := validator.Validator{Errors: errs}
+	st := status.New(codes.InvalidArgument, v.ErrorMessage())
+
+	details := &errdetails.BadRequest{}
+	for _, e := range errs {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       e.Field,
+			Description: e.Message,
+			Reason:      e.Code,
+		})
+	}
+
+	if withDetails, err := st.WithDetails(details); err == nil {
+		st = withDetails
+	}
+
+	return st.Err()
+}
@@ -0,0 +1,99 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"microservice/pkg/auth"
+	"microservice/pkg/idempotency"
+	"microservice/pkg/locale"
+	"microservice/pkg/logger"
+	"microservice/pkg/tenant"
+	"microservice/services/product-service/internal/domain"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToStatusError(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{domain.ErrProductNotFound, codes.NotFound},
+		{domain.ErrCategoryNotFound, codes.NotFound},
+		{domain.ErrAPIKeyNotFound, codes.NotFound},
+		{domain.ErrInvalidProduct, codes.InvalidArgument},
+		{domain.ErrInvalidPrice, codes.InvalidArgument},
+		{domain.ErrInvalidCategory, codes.InvalidArgument},
+		{domain.ErrInvalidStatus, codes.InvalidArgument},
+		{domain.ErrInvalidScope, codes.InvalidArgument},
+		{domain.ErrDuplicateSKU, codes.AlreadyExists},
+		{domain.ErrTranslationNotFound, codes.NotFound},
+		{domain.ErrInvalidTranslation, codes.InvalidArgument},
+		{domain.ErrDefaultLocale, codes.InvalidArgument},
+		{domain.ErrRelationNotFound, codes.NotFound},
+		{domain.ErrInvalidRelation, codes.InvalidArgument},
+		{domain.ErrDuplicateRelation, codes.AlreadyExists},
+		{domain.ErrInvalidType, codes.InvalidArgument},
+		{domain.ErrBundleNotFound, codes.NotFound},
+		{domain.ErrInvalidBundle, codes.InvalidArgument},
+		{domain.ErrComponentInUse, codes.FailedPrecondition},
+		{domain.ErrPromotionNotFound, codes.NotFound},
+		{domain.ErrInvalidPromotion, codes.InvalidArgument},
+		{domain.ErrDuplicateCoupon, codes.AlreadyExists},
+		{domain.ErrCouponExhausted, codes.FailedPrecondition},
+		{domain.ErrInvalidCart, codes.InvalidArgument},
+		{domain.ErrInvalidTaxClass, codes.InvalidArgument},
+		{domain.ErrInvalidTaxRate, codes.InvalidArgument},
+		{domain.ErrTaxRateNotFound, codes.FailedPrecondition},
+		{domain.ErrInvalidTaxRequest, codes.InvalidArgument},
+		{locale.ErrInvalidLocale, codes.InvalidArgument},
+		{tenant.ErrMissingTenant, codes.InvalidArgument},
+		{tenant.ErrInvalidTenant, codes.InvalidArgument},
+		{tenant.ErrTenantMismatch, codes.PermissionDenied},
+		{tenant.ErrUnboundCredentials, codes.PermissionDenied},
+		{idempotency.ErrInvalidKey, codes.InvalidArgument},
+		{idempotency.ErrInProgress, codes.Aborted},
+		{idempotency.ErrMismatch, codes.FailedPrecondition},
+		{&pgconn.PgError{Code: "23505"}, codes.AlreadyExists},
+		{auth.ErrMissingToken, codes.Unauthenticated},
+		{auth.ErrForbidden, codes.PermissionDenied},
+		{context.Canceled, codes.Canceled},
+		{context.DeadlineExceeded, codes.DeadlineExceeded},
+		{status.Error(codes.Unavailable, "try again"), codes.Unavailable},
+		{errors.New("connection reset"), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			// Wrapped as the services return them
+			got := status.Code(toStatusError(fmt.Errorf("handling call: %w", tt.err)))
+			if got != tt.code {
+				t.Errorf("code = %s, want %s", got, tt.code)
+			}
+		})
+	}
+}
+
+func TestToStatusErrorHidesUnknownErrors(t *testing.T) {
+	st, _ := status.FromError(toStatusError(errors.New("dial tcp 10.0.0.5:5432: connection refused")))
+	if st.Message() != "internal error" {
+		t.Errorf("message = %q, want internal error", st.Message())
+	}
+}
+
+func TestInterceptorsRecoverFirst(t *testing.T) {
+	chain := interceptors(nil, nil, nil, nil, nil, false, logger.NewLogger(logger.Fatal, io.Discard, false))
+
+	// Recovery is outermost, so a panic anywhere inside it becomes an error
+	err := chain[0](context.Background(), "/grpc.health.v1.Health/Check", func(ctx context.Context) error {
+		panic("interceptor bug")
+	})
+	if code := status.Code(err); code != codes.Internal {
+		t.Errorf("code = %s, want Internal", code)
+	}
+}
@@ -0,0 +1,468 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"microservice/pkg/auth"
+	"microservice/pkg/locale"
+	"microservice/pkg/logger"
+	"microservice/pkg/ratelimit"
+	"microservice/pkg/telemetry"
+	"microservice/pkg/tenant"
+	productv1 "microservice/services/product-service/api/gen/product/v1"
+	"microservice/services/product-service/internal/domain"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Metadata keys, matching the HTTP headers of the REST API
+const (
+	RequestIDKey      = "x-request-id"
+	AuthorizationKey  = "authorization"
+	APIKeyKey         = "x-api-key"
+	TenantKey         = "x-tenant-id"
+	AcceptLanguageKey = "accept-language"
+	authorityKey      = ":authority"
+	forwardedForKey   = "x-forwarded-for"
+)
+
+// interceptor wraps a call. It is adapted to both unary and streaming RPCs so every
+// concern is written once.
+type interceptor func(ctx context.Context, method string, next func(ctx context.Context) error) error
+
+// interceptors returns the interceptors of every RPC, outermost first, starting with
+// recovery so a panic in any of them is turned into an error. Bearer tokens
+// are only accepted when a verifier is given and calls are only rate limited when a
+// limiter is: per address before authentication, so failed attempts count, and per
+// client after it.
+func interceptors(verifier *auth.Verifier, keys auth.APIKeyAuthenticator, tenants *tenant.Resolver, locales *locale.Resolver, limiter *ratelimit.Limiter, trustForwarded bool, logger logger.Logger) []interceptor {
+	chain := []interceptor{
+		recovery(logger),
+		tracing,
+		requestContext,
+		logging(logger),
+		metrics,
+	}
+	if limiter != nil {
+		chain = append(chain, addressRateLimit(limiter, trustForwarded, logger))
+	}
+	chain = append(chain,
+		authenticate(verifier, keys, logger),
+		tenancy(tenants, logger),
+		localization(locales),
+	)
+	if limiter != nil {
+		chain = append(chain, rateLimit(limiter, trustForwarded, logger))
+	}
+	return append(chain, mapErrors)
+}
+
+func unaryInterceptors(verifier *auth.Verifier, keys auth.APIKeyAuthenticator, tenants *tenant.Resolver, locales *locale.Resolver, limiter *ratelimit.Limiter, trustForwarded bool, logger logger.Logger) []grpc.UnaryServerInterceptor {
+	var unary []grpc.UnaryServerInterceptor
+	for _, i := range interceptors(verifier, keys, tenants, locales, limiter, trustForwarded, logger) {
+		unary = append(unary, unaryInterceptor(i))
+	}
+	return unary
+}
+
+func streamInterceptors(verifier *auth.Verifier, keys auth.APIKeyAuthenticator, tenants *tenant.Resolver, locales *locale.Resolver, limiter *ratelimit.Limiter, trustForwarded bool, logger logger.Logger) []grpc.StreamServerInterceptor {
+	var stream []grpc.StreamServerInterceptor
+	for _, i := range interceptors(verifier, keys, tenants, locales, limiter, trustForwarded, logger) {
+		stream = append(stream, streamInterceptor(i))
+	}
+	return stream
+}
+
+func unaryInterceptor(i interceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var resp any
+		err := i(ctx, info.FullMethod, func(ctx context.Context) error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+func streamInterceptor(i interceptor) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return i(ss.Context(), info.FullMethod, func(ctx context.Context) error {
+			return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		})
+	}
+}
+
+// serverStream overrides the context of a stream
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// tracing continues the trace propagated in the request metadata with a server span
+func tracing(ctx context.Context, method string, next func(ctx context.Context) error) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	service, rpc := splitMethod(method)
+	ctx, span := telemetry.Tracer().Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.service", service),
+			attribute.String("rpc.method", rpc),
+		),
+	)
+	defer span.End()
+
+	err := next(ctx)
+
+	st := status.Convert(err)
+	span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(st.Code())))
+	if isServerError(st.Code()) {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, st.Message())
+	}
+
+	return err
+}
+
+// requestContext adds the request ID to the context for the audit trail and echoes it
+// back in the response header. The acting user is anonymous until authenticate
+// records the subject of the call.
+func requestContext(ctx context.Context, method string, next func(ctx context.Context) error) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := firstValue(md, RequestIDKey)
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, requestID))
+
+	ctx = domain.WithAuditContext(ctx, domain.AuditContext{
+		RequestID: requestID,
+	})
+
+	return next(ctx)
+}
+
+// authenticate rejects product service calls with an invalid API key or bearer token
+// with Unauthenticated and records the subject of valid ones as the acting user. The
+// x-api-key metadata takes precedence over the authorization metadata, and bearer
+// tokens are ignored when verifier is nil. Calls without credentials continue
+// anonymously for the policy of the service to decide. Health checks and reflection
+// are not authenticated. Denied calls are logged with the principal.
+func authenticate(verifier *auth.Verifier, keys auth.APIKeyAuthenticator, logger logger.Logger) interceptor {
+	return func(ctx context.Context, method string, next func(ctx context.Context) error) error {
+		if service, _ := splitMethod(method); service != productv1.ProductService_ServiceDesc.ServiceName {
+			return next(ctx)
+		}
+
+		principal, err := authenticateCall(ctx, verifier, keys)
+		if err != nil {
+			if !errors.Is(err, auth.ErrInvalidAPIKey) && !errors.Is(err, auth.ErrInvalidToken) && !errors.Is(err, auth.ErrMissingToken) {
+				logger.Error("Failed to authenticate %s: %v", method, err)
+				return status.Error(codes.Internal, "failed to authenticate call")
+			}
+			return status.Error(codes.Unauthenticated, auth.Message(err))
+		}
+		if principal == nil {
+			return next(ctx)
+		}
+
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("enduser.id", principal.Subject))
+
+		auditCtx := domain.AuditContextFromContext(ctx)
+		auditCtx.Actor = principal.Subject
+		ctx = domain.WithAuditContext(auth.WithPrincipal(ctx, principal), auditCtx)
+
+		err = next(ctx)
+		if status.Code(err) == codes.PermissionDenied {
+			logger.Warn("Access denied: method=%s subject=%s roles=%v scopes=%v",
+				method, principal.Subject, principal.Roles, principal.Scopes)
+		}
+
+		return err
+	}
+}
+
+// authenticateCall returns the principal of the API key or bearer token of a call, or
+// nil if it carries neither
+func authenticateCall(ctx context.Context, verifier *auth.Verifier, keys auth.APIKeyAuthenticator) (*auth.Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if key := firstValue(md, APIKeyKey); key != "" {
+		return keys.AuthenticateAPIKey(ctx, key)
+	}
+
+	header := firstValue(md, AuthorizationKey)
+	if header == "" || verifier == nil {
+		return nil, nil
+	}
+
+	token, err := auth.ParseBearer(header)
+	if err != nil {
+		return nil, err
+	}
+
+	return verifier.Verify(ctx, token)
+}
+
+// tenancy adds the tenant of product service calls to the context, taken from the
+// x-tenant-id metadata or the subdomain of the authority. Calls naming an invalid
+// tenant or none fail with InvalidArgument and calls naming another tenant than
+// their credentials, or with credentials bound to none, with PermissionDenied. It
+// must run after authenticate.
+func tenancy(resolver *tenant.Resolver, logger logger.Logger) interceptor {
+	return func(ctx context.Context, method string, next func(ctx context.Context) error) error {
+		if service, _ := splitMethod(method); service != productv1.ProductService_ServiceDesc.ServiceName {
+			return next(ctx)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		tenantID, err := resolver.Resolve(ctx, firstValue(md, TenantKey), firstValue(md, authorityKey))
+		switch {
+		case errors.Is(err, tenant.ErrMissingTenant):
+			return status.Errorf(codes.InvalidArgument, "tenant is required, set the %s metadata", TenantKey)
+		case errors.Is(err, tenant.ErrInvalidTenant):
+			return status.Error(codes.InvalidArgument, "tenant must be a lowercase DNS label of at most 63 characters")
+		case errors.Is(err, tenant.ErrTenantMismatch), errors.Is(err, tenant.ErrUnboundCredentials):
+			principal, _ := auth.PrincipalFromContext(ctx)
+			logger.Warn("Tenant denied: method=%s subject=%s tenant=%s", method, principal.Subject, principal.Tenant)
+			return status.Error(codes.PermissionDenied, err.Error())
+		case err != nil:
+			return status.Error(codes.Internal, "internal error")
+		}
+
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("tenant.id", tenantID))
+
+		return next(tenant.WithID(ctx, tenantID))
+	}
+}
+
+// localization adds the locale chain of each call to the context, negotiated from the
+// accept-language metadata
+func localization(resolver *locale.Resolver) interceptor {
+	return func(ctx context.Context, method string, next func(ctx context.Context) error) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		// Without a locale parameter, the header cannot be invalid
+		chain, _ := resolver.Resolve("", firstValue(md, AcceptLanguageKey))
+
+		return next(locale.WithChain(ctx, chain))
+	}
+}
+
+// rateLimit fails product service calls of clients over their quota with
+// ResourceExhausted, counting them like HTTP requests: for their principal in their
+// tenant, or else their address. Calls match the rules of the route
+// POST /<service>/<method>, and the response metadata carries the ratelimit-* and
+// retry-after headers of the REST API. It must run after authenticate and tenancy.
+func rateLimit(limiter *ratelimit.Limiter, trustForwarded bool, logger logger.Logger) interceptor {
+	return func(ctx context.Context, method string, next func(ctx context.Context) error) error {
+		if service, _ := splitMethod(method); service != productv1.ProductService_ServiceDesc.ServiceName {
+			return next(ctx)
+		}
+
+		remoteAddr, forwarded := callAddress(ctx, trustForwarded)
+		client := ratelimit.Client(ctx, remoteAddr, forwarded)
+
+		// gRPC calls are HTTP/2 POST requests to the method path
+		result, err := limiter.AllowRoute(ctx, "POST", method, client)
+		return limitCall(ctx, method, client, result, err, logger, next)
+	}
+}
+
+// addressRateLimit fails product service calls from addresses over the address limit
+// with ResourceExhausted, as rateLimit does. It must run before authenticate, so that
+// calls with wrong credentials are counted.
+func addressRateLimit(limiter *ratelimit.Limiter, trustForwarded bool, logger logger.Logger) interceptor {
+	return func(ctx context.Context, method string, next func(ctx context.Context) error) error {
+		if service, _ := splitMethod(method); service != productv1.ProductService_ServiceDesc.ServiceName {
+			return next(ctx)
+		}
+
+		address := ratelimit.Address(callAddress(ctx, trustForwarded))
+		result, err := limiter.AllowAddress(ctx, address)
+		return limitCall(ctx, method, address, result, err, logger, next)
+	}
+}
+
+// callAddress returns the peer address of a call and, if trusted, the
+// x-forwarded-for metadata
+func callAddress(ctx context.Context, trustForwarded bool) (string, []string) {
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+	var forwarded []string
+	if trustForwarded {
+		md, _ := metadata.FromIncomingContext(ctx)
+		forwarded = md.Get(forwardedForKey)
+	}
+	return remoteAddr, forwarded
+}
+
+// limitCall sets the rate limit metadata of a call counted with result, and fails it
+// if it is over the limit. Calls are let through when the store failed.
+func limitCall(ctx context.Context, method, client string, result ratelimit.Result, err error, logger logger.Logger, next func(ctx context.Context) error) error {
+	if err != nil {
+		logger.Error("Failed to apply rate limit to %s: %v", method, err)
+		return next(ctx)
+	}
+
+	header := metadata.Pairs(
+		"ratelimit-limit", strconv.Itoa(result.Limit.Requests),
+		"ratelimit-remaining", strconv.Itoa(result.Remaining),
+		"ratelimit-reset", strconv.Itoa(ratelimit.Seconds(result.Reset)),
+		"ratelimit-policy", result.Limit.Policy(),
+	)
+
+	if !result.Allowed {
+		retryAfter := ratelimit.Seconds(result.RetryAfter)
+		header.Set("retry-after", strconv.Itoa(retryAfter))
+		_ = grpc.SetHeader(ctx, header)
+
+		logger.Warn("Rate limit exceeded: method=%s client=%s limit=%s", method, client, result.Limit)
+
+		st := status.Newf(codes.ResourceExhausted, "rate limit exceeded, retry in %ds", retryAfter)
+		if detailed, err := st.WithDetails(&errdetails.RetryInfo{
+			RetryDelay: durationpb.New(time.Duration(retryAfter) * time.Second),
+		}); err == nil {
+			st = detailed
+		}
+		return st.Err()
+	}
+
+	_ = grpc.SetHeader(ctx, header)
+	return next(ctx)
+}
+
+// logging logs information about each call
+func logging(logger logger.Logger) interceptor {
+	return func(ctx context.Context, method string, next func(ctx context.Context) error) error {
+		start := time.Now()
+
+		err := next(ctx)
+
+		code := status.Code(err)
+		remoteAddr := "unknown"
+		if p, ok := peer.FromContext(ctx); ok {
+			remoteAddr = p.Addr.String()
+		}
+
+		logFmts := fmt.Sprintf(
+			"request_id=%s method=%s code=%s remote_ip=%s duration=%s",
+			domain.AuditContextFromContext(ctx).RequestID,
+			method,
+			code,
+			remoteAddr,
+			time.Since(start).String(),
+		)
+
+		if code == codes.OK {
+			logger.Info(logFmts)
+		} else if !isServerError(code) {
+			logger.Warn("%s error=%q", logFmts, status.Convert(err).Message())
+		} else {
+			logger.Error("%s error=%q", logFmts, status.Convert(err).Message())
+		}
+
+		return err
+	}
+}
+
+// metrics records the number, outcome and latency of calls
+func metrics(ctx context.Context, method string, next func(ctx context.Context) error) error {
+	telemetry.IncreaseActiveRequests()
+	defer telemetry.DecreaseActiveRequests()
+
+	start := time.Now()
+	err := next(ctx)
+
+	telemetry.RecordGRPCRequest(method, status.Code(err).String(), time.Since(start))
+
+	return err
+}
+
+// mapErrors converts the errors returned by the handlers into status errors
+func mapErrors(ctx context.Context, method string, next func(ctx context.Context) error) error {
+	return toStatusError(next(ctx))
+}
+
+// recovery turns a panic in an interceptor or handler into an Internal error
+func recovery(logger logger.Logger) interceptor {
+	return func(ctx context.Context, method string, next func(ctx context.Context) error) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("Panic in %s: %v\n%s", method, r, debug.Stack())
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+
+		return next(ctx)
+	}
+}
+
+// splitMethod splits "/package.Service/Method" into its service and method names
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", fullMethod
+	}
+	return service, method
+}
+
+// isServerError reports whether code means the server, rather than the caller, is at fault
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return false
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// metadataCarrier adapts incoming metadata to the OpenTelemetry propagators
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	return firstValue(metadata.MD(c), key)
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,204 @@
+package grpcapi
+
+import (
+	"context"
+	productv1 "microservice/services/product-service/api/gen/product/v1"
+	"microservice/services/product-service/internal/infrastructure/validator"
+	"microservice/services/product-service/internal/interfaces"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+)
+
+const (
+	defaultStreamPageSize = 100
+	maxStreamPageSize     = 1000
+)
+
+// ProductServer implements the product.v1.ProductService on top of the application service
+type ProductServer struct {
+	productv1.UnimplementedProductServiceServer
+
+	service    interfaces.Service
+	validation *validator.Pipeline[productv1.ProductInput]
+}
+
+func NewProductServer(service interfaces.Service) *ProductServer {
+	return &ProductServer{
+		service:    service,
+		validation: newProductValidation(service),
+	}
+}
+
+// newProductValidation validates product inputs and checks that their category exists
+func newProductValidation(service interfaces.Service) *validator.Pipeline[productv1.ProductInput] {
+	return validator.NewPipeline[productv1.ProductInput]().
+		Check(validateInput).
+		CheckAsync(func(ctx context.Context, v *validator.Validator, in *productv1.ProductInput) error {
+			categoryID, err := uuid.Parse(in.GetCategoryId())
+			if err != nil {
+				// Already reported by validateInput
+				return nil
+			}
+
+			exists, err := service.CategoryExists(ctx, categoryID)
+			if err != nil {
+				return err
+			}
+
+			v.CheckRule(exists, "category_id", "category_not_found", nil)
+			return nil
+		})
+}
+
+func (s *ProductServer) GetProduct(ctx context.Context, req *productv1.GetProductRequest) (*productv1.GetProductResponse, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.service.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.GetProductResponse{Product: productToProto(product)}, nil
+}
+
+// ListProducts streams the products page by page, so the whole catalog is never held in memory
+func (s *ProductServer) ListProducts(req *productv1.ListProductsRequest, stream grpc.ServerStreamingServer[productv1.ListProductsResponse]) error {
+	v := validator.New()
+	v.CheckRule(req.GetPageSize() >= 0 && req.GetPageSize() <= maxStreamPageSize, "page_size", "between", validator.Params{"min": "0", "max": strconv.Itoa(maxStreamPageSize)})
+	v.CheckRule(req.GetOffset() >= 0, "offset", "gte", validator.Params{"param": "0"})
+	v.CheckRule(req.GetLimit() >= 0, "limit", "gte", validator.Params{"param": "0"})
+	if !v.Valid() {
+		return invalidArgument(v.Errors)
+	}
+
+	pageSize := int(req.GetPageSize())
+	if pageSize == 0 {
+		pageSize = defaultStreamPageSize
+	}
+	offset := int(req.GetOffset())
+	remaining := int(req.GetLimit())
+
+	ctx := stream.Context()
+	for {
+		size := pageSize
+		if remaining > 0 && remaining < size {
+			size = remaining
+		}
+
+		products, _, err := s.service.GetAll(ctx, size, offset)
+		if err != nil {
+			return err
+		}
+
+		for _, product := range products {
+			if err := stream.Send(&productv1.ListProductsResponse{Product: productToProto(product)}); err != nil {
+				return err
+			}
+		}
+
+		offset += len(products)
+		if req.GetLimit() > 0 {
+			remaining -= len(products)
+			if remaining <= 0 {
+				return nil
+			}
+		}
+
+		if len(products) < size {
+			return nil
+		}
+	}
+}
+
+func (s *ProductServer) CreateProduct(ctx context.Context, req *productv1.CreateProductRequest) (*productv1.CreateProductResponse, error) {
+	categoryID, err := s.validate(ctx, req.GetProduct())
+	if err != nil {
+		return nil, err
+	}
+
+	product := inputToModel(req.GetProduct(), categoryID)
+	if err := s.service.Create(ctx, product); err != nil {
+		return nil, err
+	}
+
+	return &productv1.CreateProductResponse{Product: productToProto(product)}, nil
+}
+
+func (s *ProductServer) UpdateProduct(ctx context.Context, req *productv1.UpdateProductRequest) (*productv1.UpdateProductResponse, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	categoryID, err := s.validate(ctx, req.GetProduct())
+	if err != nil {
+		return nil, err
+	}
+
+	product := inputToModel(req.GetProduct(), categoryID)
+	product.ID = id
+	if err := s.service.Update(ctx, product); err != nil {
+		return nil, err
+	}
+
+	return &productv1.UpdateProductResponse{Product: productToProto(product)}, nil
+}
+
+func (s *ProductServer) DeleteProduct(ctx context.Context, req *productv1.DeleteProductRequest) (*productv1.DeleteProductResponse, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.service.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return &productv1.DeleteProductResponse{}, nil
+}
+
+func (s *ProductServer) SearchProducts(ctx context.Context, req *productv1.SearchProductsRequest) (*productv1.SearchProductsResponse, error) {
+	query := strings.TrimSpace(req.GetQuery())
+
+	v := validator.New()
+	v.Required("query", query)
+	if !v.Valid() {
+		return nil, invalidArgument(v.Errors)
+	}
+
+	products, err := s.service.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &productv1.SearchProductsResponse{Products: productsToProto(products)}, nil
+}
+
+// validate checks the product input and that its category exists
+func (s *ProductServer) validate(ctx context.Context, in *productv1.ProductInput) (uuid.UUID, error) {
+	errs, err := s.validation.Validate(ctx, in)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if len(errs) > 0 {
+		return uuid.Nil, invalidArgument(errs)
+	}
+
+	return uuid.MustParse(in.GetCategoryId()), nil
+}
+
+func parseID(value string) (uuid.UUID, error) {
+	v := validator.New()
+	id, _ := v.ValidUUID("id", value)
+	if !v.Valid() {
+		return uuid.Nil, invalidArgument(v.Errors)
+	}
+	return id, nil
+}
@@ -0,0 +1,74 @@
+package grpcapi
+
+import (
+	"context"
+	"microservice/pkg/auth"
+	"microservice/pkg/locale"
+	"microservice/pkg/logger"
+	"microservice/pkg/ratelimit"
+	"microservice/pkg/tenant"
+	productv1 "microservice/services/product-service/api/gen/product/v1"
+	"microservice/services/product-service/internal/interfaces"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server serves the product service, health checks and reflection over gRPC
+type Server struct {
+	server *grpc.Server
+	health *health.Server
+	logger logger.Logger
+}
+
+// NewServer returns a server for service. Product service calls are authenticated
+// with an API key checked by keys or, unless verifier is nil, a bearer token, and run
+// in the tenant resolved by tenants. Clients are held to their quota by limiter unless
+// it is nil; trustForwarded identifies anonymous clients by x-forwarded-for.
+func NewServer(service interfaces.Service, verifier *auth.Verifier, keys auth.APIKeyAuthenticator, tenants *tenant.Resolver, locales *locale.Resolver, limiter *ratelimit.Limiter, trustForwarded bool, logger logger.Logger) *Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryInterceptors(verifier, keys, tenants, locales, limiter, trustForwarded, logger)...),
+		grpc.ChainStreamInterceptor(streamInterceptors(verifier, keys, tenants, locales, limiter, trustForwarded, logger)...),
+	)
+
+	productv1.RegisterProductServiceServer(server, NewProductServer(service))
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	healthServer.SetServingStatus(productv1.ProductService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	reflection.Register(server)
+
+	return &Server{
+		server: server,
+		health: healthServer,
+		logger: logger,
+	}
+}
+
+// Serve accepts connections on lis until the server is stopped
+func (s *Server) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Shutdown reports the server as not serving and waits for in-flight calls to finish.
+// Remaining calls are cancelled when ctx expires.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.logger.Warn("gRPC server did not stop in time, cancelling in-flight calls")
+		s.server.Stop()
+	}
+}
@@ -0,0 +1,217 @@
+package cached
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"hash/maphash"
+	"microservice/pkg/cache"
+	"microservice/pkg/telemetry"
+	"microservice/pkg/tenant"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/interfaces"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+// cacheName labels the metrics of the product cache
+const cacheName = "products"
+
+// notFoundMarker is cached for products that do not exist
+var notFoundMarker = []byte("!notfound")
+
+// Options holds the cache settings
+type Options struct {
+	TTL         time.Duration
+	NegativeTTL time.Duration
+}
+
+// ProductRepository is a read-through cache around a product repository.
+// GetByID results, including ErrProductNotFound, are cached; concurrent misses
+// for the same product share a single load; writes invalidate the entry.
+type ProductRepository struct {
+	interfaces.ProductRepository
+	cache       cache.Cache
+	opts        Options
+	group       singleflight.Group
+	generations generations
+	tracer      trace.Tracer
+}
+
+// generations counts the invalidations of the keys hashing to each stripe. A load
+// only stores its result if no invalidation of its key happened since it started,
+// so a product read before a write commits is not cached after the write
+// invalidated it.
+type generations struct {
+	seed    maphash.Seed
+	stripes [256]generationStripe
+}
+
+type generationStripe struct {
+	// mu is held for reading while a result is stored, so an invalidation waits for
+	// the stores it does not stop
+	mu sync.RWMutex
+	n  uint64
+}
+
+func (g *generations) stripe(key string) *generationStripe {
+	return &g.stripes[maphash.String(g.seed, key)%uint64(len(g.stripes))]
+}
+
+func (s *generationStripe) current() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.n
+}
+
+func (s *generationStripe) bump() {
+	s.mu.Lock()
+	s.n++
+	s.mu.Unlock()
+}
+
+func NewProductRepository(repo interfaces.ProductRepository, c cache.Cache, opts Options, tracer trace.Tracer) *ProductRepository {
+	return &ProductRepository{
+		ProductRepository: repo,
+		cache:             c,
+		opts:              opts,
+		generations:       generations{seed: maphash.MakeSeed()},
+		tracer:            tracer,
+	}
+}
+
+func (r *ProductRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	ctx, span := r.tracer.Start(ctx, "CachedProductRepository.GetByID")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", id.String()))
+
+	key := productKey(ctx, id)
+
+	if product, found, err := r.lookup(ctx, key); found {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		return product, err
+	}
+
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+	telemetry.RecordCacheLookup(cacheName, telemetry.CacheMiss)
+
+	v, err, _ := r.group.Do(key, func() (any, error) {
+		// Detach from the caller so one cancelled request does not fail the others sharing the load
+		loadCtx := context.WithoutCancel(ctx)
+		stripe := r.generations.stripe(key)
+		generation := stripe.current()
+
+		product, err := r.ProductRepository.GetByID(loadCtx, id)
+		if err != nil {
+			if errors.Is(err, domain.ErrProductNotFound) {
+				r.store(loadCtx, stripe, generation, key, notFoundMarker, r.opts.NegativeTTL)
+			}
+			return nil, err
+		}
+
+		if data, err := json.Marshal(product); err == nil {
+			r.store(loadCtx, stripe, generation, key, data, r.opts.TTL)
+		}
+
+		return product, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Callers sharing the load must not share the pointer
+	product := *v.(*domain.Product)
+	return &product, nil
+}
+
+func (r *ProductRepository) Create(ctx context.Context, product *domain.Product) error {
+	if err := r.ProductRepository.Create(ctx, product); err != nil {
+		return err
+	}
+
+	// Drop a cached not-found for the new ID
+	return r.Invalidate(ctx, product.ID)
+}
+
+func (r *ProductRepository) Update(ctx context.Context, id uuid.UUID, change func(*domain.Product) error) (*domain.Product, error) {
+	product, err := r.ProductRepository.Update(ctx, id, change)
+	if err != nil {
+		return nil, err
+	}
+
+	return product, r.Invalidate(ctx, id)
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := r.ProductRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	return r.Invalidate(ctx, id)
+}
+
+// Invalidate removes the cached product of the tenant of ctx, e.g. when another replica
+// changed it. Loads in flight keep their result out of the cache.
+func (r *ProductRepository) Invalidate(ctx context.Context, id uuid.UUID) error {
+	key := productKey(ctx, id)
+	r.generations.stripe(key).bump()
+	r.group.Forget(key)
+	return r.cache.Delete(ctx, key)
+}
+
+// InvalidateAll empties the cache
+func (r *ProductRepository) InvalidateAll(ctx context.Context) error {
+	for i := range r.generations.stripes {
+		r.generations.stripes[i].bump()
+	}
+	return r.cache.Clear(ctx)
+}
+
+// lookup returns the cached result for key and whether there was one.
+// A cached not-found is returned as ErrProductNotFound.
+func (r *ProductRepository) lookup(ctx context.Context, key string) (*domain.Product, bool, error) {
+	data, found, err := r.cache.Get(ctx, key)
+	if err != nil || !found {
+		// A failing cache must not fail reads
+		return nil, false, nil
+	}
+
+	if bytes.Equal(data, notFoundMarker) {
+		telemetry.RecordCacheLookup(cacheName, telemetry.CacheNegativeHit)
+		return nil, true, domain.ErrProductNotFound
+	}
+
+	var product domain.Product
+	if err := json.Unmarshal(data, &product); err != nil {
+		return nil, false, nil
+	}
+
+	telemetry.RecordCacheLookup(cacheName, telemetry.CacheHit)
+	return &product, true, nil
+}
+
+// store caches the result of a load started at generation, unless the key was
+// invalidated since
+func (r *ProductRepository) store(ctx context.Context, stripe *generationStripe, generation uint64, key string, value []byte, ttl time.Duration) {
+	stripe.mu.RLock()
+	defer stripe.mu.RUnlock()
+
+	if stripe.n != generation {
+		return
+	}
+	_ = r.cache.Set(ctx, key, value, ttl)
+}
+
+// productKey namespaces the cached products by tenant, so one tenant never reads
+// another's entry for the same ID
+func productKey(ctx context.Context, id uuid.UUID) string {
+	tenantID, _ := tenant.FromContext(ctx)
+	return "product:" + tenantID + ":" + id.String()
+}
@@ -0,0 +1,129 @@
+package cached
+
+import (
+	"context"
+	"microservice/pkg/cache"
+	"microservice/services/product-service/internal/domain"
+	"microservice/services/product-service/internal/interfaces"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// slowProductRepository holds one product. Reads take a copy and, while paused,
+// wait for resume before returning it.
+type slowProductRepository struct {
+	interfaces.ProductRepository
+
+	mu      sync.Mutex
+	product domain.Product
+	paused  chan struct{}
+	read    chan struct{}
+}
+
+func (r *slowProductRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Product, error) {
+	r.mu.Lock()
+	product := r.product
+	paused := r.paused
+	r.mu.Unlock()
+
+	if paused != nil {
+		r.read <- struct{}{}
+		<-paused
+	}
+	return &product, nil
+}
+
+func (r *slowProductRepository) Update(ctx context.Context, id uuid.UUID, change func(*domain.Product) error) (*domain.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := change(&r.product); err != nil {
+		return nil, err
+	}
+	product := r.product
+	return &product, nil
+}
+
+func (r *slowProductRepository) pause() chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.paused = make(chan struct{})
+	r.read = make(chan struct{})
+	return r.paused
+}
+
+func (r *slowProductRepository) resume(paused chan struct{}) {
+	r.mu.Lock()
+	r.paused = nil
+	r.mu.Unlock()
+	close(paused)
+}
+
+func TestInvalidateDuringLoadKeepsStaleProductOut(t *testing.T) {
+	id := uuid.New()
+	repo := &slowProductRepository{product: domain.Product{ID: id, Name: "Kettle"}}
+	cached := NewProductRepository(repo, cache.NewMemoryCache(100, time.Minute), Options{TTL: time.Minute, NegativeTTL: time.Minute}, noop.NewTracerProvider().Tracer(""))
+	ctx := context.Background()
+
+	// A load reads the product before the update commits and stores it after
+	paused := repo.pause()
+	loaded := make(chan *domain.Product)
+	go func() {
+		product, _ := cached.GetByID(ctx, id)
+		loaded <- product
+	}()
+	<-repo.read
+
+	if _, err := cached.Update(ctx, id, func(p *domain.Product) error {
+		p.Name = "Electric Kettle"
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	repo.resume(paused)
+	if product := <-loaded; product.Name != "Kettle" {
+		t.Fatalf("load in flight got %q, want the product it read", product.Name)
+	}
+
+	product, err := cached.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if product.Name != "Electric Kettle" {
+		t.Errorf("GetByID after the update = %q, want Electric Kettle", product.Name)
+	}
+}
+
+func TestGetByIDCachesLoads(t *testing.T) {
+	id := uuid.New()
+	repo := &slowProductRepository{product: domain.Product{ID: id, Name: "Kettle"}}
+	cached := NewProductRepository(repo, cache.NewMemoryCache(100, time.Minute), Options{TTL: time.Minute, NegativeTTL: time.Minute}, noop.NewTracerProvider().Tracer(""))
+	ctx := context.Background()
+
+	if _, err := cached.GetByID(ctx, id); err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+
+	// Changed behind the cache's back, so only a cache miss sees it
+	repo.product.Name = "Toaster"
+	product, err := cached.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if product.Name != "Kettle" {
+		t.Errorf("GetByID = %q, want the cached Kettle", product.Name)
+	}
+
+	if err := cached.Invalidate(ctx, id); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	if product, _ := cached.GetByID(ctx, id); product.Name != "Toaster" {
+		t.Errorf("GetByID after Invalidate = %q, want Toaster", product.Name)
+	}
+}
@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"microservice/pkg/tenant"
+	"microservice/services/product-service/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// apiKeyColumns lists the API key columns in the order scanAPIKey expects them
+const apiKeyColumns = "id, tenant_id, name, prefix, key_hash, scopes, created_by, expires_at, last_used_at, revoked_at, created_at"
+
+type PostgresAPIKeyRepository struct {
+	DB     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewAPIKeyRepository(db *pgxpool.Pool, tracer trace.Tracer) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{
+		DB:     db,
+		tracer: tracer,
+	}
+}
+
+func (r *PostgresAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	ctx, span := r.tracer.Start(ctx, "APIKeyRepository.Create")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("api_key.id", key.ID.String()))
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return tenant.ErrMissingTenant
+	}
+	key.TenantID = tenantID
+
+	err := r.DB.QueryRow(ctx,
+		`INSERT INTO api_keys (id, tenant_id, name, prefix, key_hash, scopes, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at`,
+		key.ID, key.TenantID, key.Name, key.Prefix, key.Hash, key.Scopes, key.CreatedBy, key.ExpiresAt,
+	).Scan(&key.CreatedAt)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresAPIKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	ctx, span := r.tracer.Start(ctx, "APIKeyRepository.GetByPrefix")
+	defer span.End()
+
+	key, err := scanAPIKey(r.DB.QueryRow(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = $1", prefix))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// List returns a page of the API keys of the tenant, newest first, including revoked and expired ones
+func (r *PostgresAPIKeyRepository) List(ctx context.Context, limit, offset int) ([]*domain.APIKey, int, error) {
+	ctx, span := r.tracer.Start(ctx, "APIKeyRepository.List")
+	defer span.End()
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, 0, tenant.ErrMissingTenant
+	}
+
+	rows, err := r.DB.Query(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE tenant_id = $1 ORDER BY created_at DESC, id LIMIT $2 OFFSET $3", tenantID, limit, offset)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, 0, err
+	}
+
+	keys, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.APIKey, error) {
+		return scanAPIKey(row)
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, 0, err
+	}
+
+	var total int
+	if err := r.DB.QueryRow(ctx, "SELECT count(*) FROM api_keys WHERE tenant_id = $1", tenantID).Scan(&total); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, 0, err
+	}
+
+	return keys, total, nil
+}
+
+// Revoke marks the key as revoked; revoking a revoked key keeps the original time
+func (r *PostgresAPIKeyRepository) Revoke(ctx context.Context, id uuid.UUID) (*domain.APIKey, error) {
+	ctx, span := r.tracer.Start(ctx, "APIKeyRepository.Revoke")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("api_key.id", id.String()))
+
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, tenant.ErrMissingTenant
+	}
+
+	key, err := scanAPIKey(r.DB.QueryRow(ctx,
+		"UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1 AND tenant_id = $2 RETURNING "+apiKeyColumns, id, tenantID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *PostgresAPIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	_, err := r.DB.Exec(ctx, "UPDATE api_keys SET last_used_at = $2 WHERE id = $1", id, at)
+	return err
+}
+
+// scanAPIKey scans a row selected with apiKeyColumns into an API key
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	var key domain.APIKey
+
+	err := row.Scan(
+		&key.ID,
+		&key.TenantID,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		&key.Scopes,
+		&key.CreatedBy,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
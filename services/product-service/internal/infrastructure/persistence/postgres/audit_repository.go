@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"microservice/services/product-service/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const auditColumns = "id, product_id, action, actor, request_id, changes, created_at"
+
+type PostgresAuditRepository struct {
+	DB     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewAuditRepository(db *pgxpool.Pool, tracer trace.Tracer) *PostgresAuditRepository {
+	return &PostgresAuditRepository{
+		DB:     db,
+		tracer: tracer,
+	}
+}
+
+// ListByProduct returns a page of audit entries for a product, newest first
+func (r *PostgresAuditRepository) ListByProduct(ctx context.Context, productID uuid.UUID, limit, offset int) ([]*domain.AuditEntry, int, error) {
+	ctx, span := r.tracer.Start(ctx, "AuditRepository.ListByProduct")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()))
+
+	var (
+		entries []*domain.AuditEntry
+		total   int
+	)
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx,
+			"SELECT "+auditColumns+" FROM product_audit_log WHERE tenant_id = $1 AND product_id = $2 ORDER BY created_at DESC, id LIMIT $3 OFFSET $4",
+			tenantID, productID, limit, offset,
+		)
+		if err != nil {
+			return err
+		}
+
+		entries, err = collectAuditEntries(rows)
+		if err != nil {
+			return err
+		}
+
+		return tx.QueryRow(ctx, "SELECT count(*) FROM product_audit_log WHERE tenant_id = $1 AND product_id = $2", tenantID, productID).Scan(&total)
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// ListUntil returns every audit entry for a product recorded at or before asOf, oldest first
+func (r *PostgresAuditRepository) ListUntil(ctx context.Context, productID uuid.UUID, asOf time.Time) ([]*domain.AuditEntry, error) {
+	ctx, span := r.tracer.Start(ctx, "AuditRepository.ListUntil")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("product.id", productID.String()),
+		attribute.String("audit.as_of", asOf.Format(time.RFC3339)),
+	)
+
+	var entries []*domain.AuditEntry
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx,
+			"SELECT "+auditColumns+" FROM product_audit_log WHERE tenant_id = $1 AND product_id = $2 AND created_at <= $3 ORDER BY created_at, id",
+			tenantID, productID, asOf,
+		)
+		if err != nil {
+			return err
+		}
+
+		entries, err = collectAuditEntries(rows)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// insertAuditEntry writes an audit entry of the tenant using the caller's transaction
+func insertAuditEntry(ctx context.Context, tx pgx.Tx, tenantID string, entry *domain.AuditEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		"INSERT INTO product_audit_log (tenant_id, "+auditColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		tenantID, entry.ID, entry.ProductID, entry.Action, entry.Actor, entry.RequestID, changes, entry.CreatedAt,
+	)
+
+	return err
+}
+
+func collectAuditEntries(rows pgx.Rows) ([]*domain.AuditEntry, error) {
+	defer rows.Close()
+
+	var entries []*domain.AuditEntry
+	for rows.Next() {
+		var (
+			entry     domain.AuditEntry
+			requestID *string
+			changes   []byte
+		)
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ProductID,
+			&entry.Action,
+			&entry.Actor,
+			&requestID,
+			&changes,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if requestID != nil {
+			entry.RequestID = *requestID
+		}
+
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
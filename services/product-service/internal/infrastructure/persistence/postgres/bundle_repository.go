@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"microservice/services/product-service/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type PostgresBundleRepository struct {
+	DB     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewBundleRepository(db *pgxpool.Pool, tracer trace.Tracer) *PostgresBundleRepository {
+	return &PostgresBundleRepository{
+		DB:     db,
+		tracer: tracer,
+	}
+}
+
+func (r *PostgresBundleRepository) Get(ctx context.Context, productID uuid.UUID) (*domain.Bundle, error) {
+	ctx, span := r.tracer.Start(ctx, "BundleRepository.Get")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()))
+
+	var bundles map[uuid.UUID]*domain.Bundle
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		var err error
+		bundles, err = findBundles(ctx, tx, tenantID, []uuid.UUID{productID})
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	bundle, ok := bundles[productID]
+	if !ok {
+		return nil, domain.ErrBundleNotFound
+	}
+
+	return bundle, nil
+}
+
+// FindByProducts returns the bundles of the products having one, by product, with
+// the price and availability of their components
+func (r *PostgresBundleRepository) FindByProducts(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID]*domain.Bundle, error) {
+	ctx, span := r.tracer.Start(ctx, "BundleRepository.FindByProducts")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("product.count", len(productIDs)))
+
+	var bundles map[uuid.UUID]*domain.Bundle
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		var err error
+		bundles, err = findBundles(ctx, tx, tenantID, productIDs)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return bundles, nil
+}
+
+// Save creates or replaces the bundle of its product, keeping the components in order
+func (r *PostgresBundleRepository) Save(ctx context.Context, bundle *domain.Bundle) error {
+	ctx, span := r.tracer.Start(ctx, "BundleRepository.Save")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", bundle.ProductID.String()), attribute.Int("bundle.components", len(bundle.Components)))
+
+	componentIDs := make([]uuid.UUID, len(bundle.Components))
+	quantities := make([]int32, len(bundle.Components))
+	for i, c := range bundle.Components {
+		componentIDs[i] = c.ProductID
+		quantities[i] = int32(c.Quantity)
+	}
+
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		err := tx.QueryRow(ctx,
+			`INSERT INTO bundles (product_id, tenant_id, pricing, discount_percent)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (product_id) DO UPDATE SET pricing = $3, discount_percent = $4, updated_at = NOW()
+			RETURNING updated_at`,
+			bundle.ProductID, tenantID, bundle.Pricing, bundle.DiscountPercent,
+		).Scan(&bundle.UpdatedAt)
+		if err != nil {
+			if isForeignKeyViolation(err) {
+				return domain.ErrProductNotFound
+			}
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, "DELETE FROM bundle_components WHERE bundle_id = $1 AND tenant_id = $2", bundle.ProductID, tenantID); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO bundle_components (bundle_id, component_id, tenant_id, quantity, position)
+			SELECT $1, c.component_id, $2, c.quantity, c.position - 1
+			FROM unnest($3::uuid[], $4::int[]) WITH ORDINALITY AS c(component_id, quantity, position)`,
+			bundle.ProductID, tenantID, componentIDs, quantities)
+		if isForeignKeyViolation(err) {
+			// A component was deleted meanwhile
+			return domain.ErrInvalidBundle
+		}
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrProductNotFound) && !errors.Is(err, domain.ErrInvalidBundle) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// findBundles loads the bundles of productIDs and their components, in order
+func findBundles(ctx context.Context, tx pgx.Tx, tenantID string, productIDs []uuid.UUID) (map[uuid.UUID]*domain.Bundle, error) {
+	rows, err := tx.Query(ctx,
+		"SELECT product_id, pricing, discount_percent, updated_at FROM bundles WHERE product_id = ANY($1) AND tenant_id = $2",
+		productIDs, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Bundle, error) {
+		var b domain.Bundle
+		err := row.Scan(&b.ProductID, &b.Pricing, &b.DiscountPercent, &b.UpdatedAt)
+		return &b, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	bundles := make(map[uuid.UUID]*domain.Bundle, len(list))
+	for _, b := range list {
+		bundles[b.ProductID] = b
+	}
+	if len(bundles) == 0 {
+		return bundles, nil
+	}
+
+	rows, err = tx.Query(ctx,
+		`SELECT c.bundle_id, c.component_id, c.quantity, p.price, p.status, p.updated_at
+		FROM bundle_components c
+		JOIN products p ON p.tenant_id = c.tenant_id AND p.id = c.component_id
+		WHERE c.bundle_id = ANY($1) AND c.tenant_id = $2
+		ORDER BY c.bundle_id, c.position`,
+		productIDs, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		bundleID  uuid.UUID
+		component domain.BundleComponent
+		status    domain.ProductStatus
+	)
+	_, err = pgx.ForEachRow(rows, []any{&bundleID, &component.ProductID, &component.Quantity, &component.Price, &status, &component.UpdatedAt}, func() error {
+		// Components are simple products, available when published
+		component.Available = status == domain.StatusPublished
+
+		b := bundles[bundleID]
+		b.Components = append(b.Components, component)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bundles, nil
+}
+
+// isForeignKeyViolation reports whether err is the violation of a foreign key constraint
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"microservice/services/product-service/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// categoryColumns lists the category columns in the order scanCategory expects them
+const categoryColumns = "id, name, COALESCE(description, ''), created_at, updated_at, slug"
+
+type PostgresCategoryRepository struct {
+	DB     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewCategoryRepository(db *pgxpool.Pool, tracer trace.Tracer) *PostgresCategoryRepository {
+	return &PostgresCategoryRepository{
+		DB:     db,
+		tracer: tracer,
+	}
+}
+
+func (r *PostgresCategoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	ctx, span := r.tracer.Start(ctx, "CategoryRepository.GetByID")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("category.id", id.String()))
+
+	var category *domain.Category
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		var err error
+		category, err = scanCategory(tx.QueryRow(ctx, "SELECT "+categoryColumns+" FROM categories WHERE id = $1 AND tenant_id = $2", id, tenantID))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrCategoryNotFound
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return category, nil
+}
+
+// GetByIDs returns the categories with the given IDs in a single query; unknown IDs are skipped
+func (r *PostgresCategoryRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Category, error) {
+	ctx, span := r.tracer.Start(ctx, "CategoryRepository.GetByIDs")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("category.count", len(ids)))
+
+	var categories []*domain.Category
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx, "SELECT "+categoryColumns+" FROM categories WHERE id = ANY($1) AND tenant_id = $2", ids, tenantID)
+		if err != nil {
+			return err
+		}
+
+		categories, err = collectCategories(rows)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (r *PostgresCategoryRepository) GetAll(ctx context.Context, limit, offset int) ([]*domain.Category, int, error) {
+	ctx, span := r.tracer.Start(ctx, "CategoryRepository.GetAll")
+	defer span.End()
+
+	var (
+		categories []*domain.Category
+		total      int
+	)
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx, "SELECT "+categoryColumns+" FROM categories WHERE tenant_id = $1 ORDER BY name, id LIMIT $2 OFFSET $3", tenantID, limit, offset)
+		if err != nil {
+			return err
+		}
+
+		categories, err = collectCategories(rows)
+		if err != nil {
+			return err
+		}
+
+		return tx.QueryRow(ctx, "SELECT count(*) FROM categories WHERE tenant_id = $1", tenantID).Scan(&total)
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, 0, err
+	}
+
+	return categories, total, nil
+}
+
+// scanCategory scans a row selected with categoryColumns into a category
+func scanCategory(row pgx.Row) (*domain.Category, error) {
+	var category domain.Category
+
+	err := row.Scan(
+		&category.ID,
+		&category.Name,
+		&category.Description,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+		&category.Slug,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func collectCategories(rows pgx.Rows) ([]*domain.Category, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Category, error) {
+		return scanCategory(row)
+	})
+}
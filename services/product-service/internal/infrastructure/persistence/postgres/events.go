@@ -0,0 +1,23 @@
+package postgres
+
+import (
+	"context"
+	"microservice/pkg/outbox"
+	"microservice/services/product-service/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// insertEvents stores the domain events in the outbox using the caller's transaction
+func insertEvents(ctx context.Context, tx pgx.Tx, events []domain.Event) error {
+	messages := make([]outbox.Message, 0, len(events))
+	for _, e := range events {
+		msg, err := outbox.NewMessage(ctx, e.ID, domain.ProductAggregate, e.AggregateID, string(e.Type), e.OccurredAt, e.Payload)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, msg)
+	}
+
+	return outbox.Insert(ctx, tx, messages...)
+}
@@ -0,0 +1,10 @@
+package postgres
+
+// CatalogChangesChannel is the channel the catalog notify triggers publish on
+const CatalogChangesChannel = "catalog_changes"
+
+// Tables that publish change notifications
+const (
+	ProductsTable   = "products"
+	CategoriesTable = "categories"
+)
@@ -2,18 +2,35 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"microservice/pkg/telemetry"
 	"microservice/services/product-service/internal/domain"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// productColumns lists the product columns in the order scanProduct expects them
+const productColumns = "id, name, description, price, sku, category_id, status, type, created_at, updated_at, slug"
+
+// uniqueViolation is the SQLSTATE of a unique constraint violation
+const uniqueViolation = "23505"
+
+// statusCondition matches the statuses of a ProductFilter passed as param by statusArgs
+func statusCondition(param string) string {
+	return "(" + param + "::text[] IS NULL OR status = ANY(" + param + "))"
+}
+
+// likeEscaper escapes the LIKE wildcards in user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type PostgresProductRepository struct {
 	DB     *pgxpool.Pool
 	tracer trace.Tracer
@@ -26,41 +43,26 @@ func NewProductRepository(db *pgxpool.Pool, tracer trace.Tracer) *PostgresProduc
 	}
 }
 
-func (r *PostgresProductRepository) GetAll(ctx context.Context, limit, offset int) ([]*domain.Product, int, error) {
-	rows, err := r.DB.Query(ctx, "SELECT * FROM products LIMIT $1 OFFSET $2", limit, offset)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer rows.Close()
+func (r *PostgresProductRepository) GetAll(ctx context.Context, filter domain.ProductFilter, limit, offset int) ([]*domain.Product, int, error) {
+	statuses := statusArgs(filter)
 
-	var products []*domain.Product
-	for rows.Next() {
-		var product domain.Product
-		err := rows.Scan(
-			&product.ID,
-			&product.Name,
-			&product.Description,
-			&product.Price,
-			&product.SKU,
-			&product.CategoryID,
-			&product.CreatedAt,
-			&product.UpdatedAt,
-		)
+	var (
+		products []*domain.Product
+		total    int
+	)
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx, "SELECT "+productColumns+" FROM products WHERE tenant_id = $1 AND "+statusCondition("$2")+" ORDER BY created_at, id LIMIT $3 OFFSET $4", tenantID, statuses, limit, offset)
 		if err != nil {
-			return nil, 0, err
+			return err
 		}
-		products = append(products, &product)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, 0, err
-	}
-
-	var total int
-	row := r.DB.QueryRow(ctx, "SELECT count(*) FROM  products")
 
-	err = row.Scan(&total)
+		products, err = collectProducts(rows)
+		if err != nil {
+			return err
+		}
 
+		return tx.QueryRow(ctx, "SELECT count(*) FROM products WHERE tenant_id = $1 AND "+statusCondition("$2"), tenantID, statuses).Scan(&total)
+	})
 	if err != nil {
 		return nil, 0, err
 	}
@@ -84,25 +86,19 @@ func (r *PostgresProductRepository) GetByID(ctx context.Context, id uuid.UUID) (
 	// Measure operation duration
 	startTime := time.Now()
 
-	var product domain.Product
-
-	err := r.DB.QueryRow(ctx, "SELECT * FROM products WHERE id = $1", id).Scan(
-		&product.ID,
-		&product.Name,
-		&product.Description,
-		&product.Price,
-		&product.SKU,
-		&product.CategoryID,
-		&product.CreatedAt,
-		&product.UpdatedAt,
-	)
+	var product *domain.Product
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		var err error
+		product, err = scanProduct(tx.QueryRow(ctx, "SELECT "+productColumns+" FROM products WHERE id = $1 AND tenant_id = $2", id, tenantID))
+		return err
+	})
 
 	// Record duration
 	duration := time.Since(startTime).Seconds()
 	telemetry.RequestDuration.Record(ctx, duration)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, domain.ErrProductNotFound
 		}
 
@@ -111,39 +107,304 @@ func (r *PostgresProductRepository) GetByID(ctx context.Context, id uuid.UUID) (
 		return nil, err
 	}
 
-	return &product, nil
+	return product, nil
 }
 
+// ResolveSlug returns the ID of the product having or having had slug
+func (r *PostgresProductRepository) ResolveSlug(ctx context.Context, slug string) (uuid.UUID, error) {
+	ctx, span := r.tracer.Start(ctx, "ProductRepository.ResolveSlug")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.slug", slug))
+
+	var id uuid.UUID
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		// The history holds the current slugs too
+		return tx.QueryRow(ctx, "SELECT product_id FROM product_slugs WHERE slug = $1 AND tenant_id = $2", slug, tenantID).Scan(&id)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, domain.ErrProductNotFound
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return uuid.Nil, err
+	}
+
+	return id, nil
+}
+
+// Create inserts the product and records the audit entry and pending events in the same transaction
 func (r *PostgresProductRepository) Create(ctx context.Context, product *domain.Product) error {
+	ctx, span := r.tracer.Start(ctx, "ProductRepository.Create")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", product.ID.String()))
+
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		err := tx.QueryRow(ctx,
+			`INSERT INTO products (id, tenant_id, name, description, price, sku, category_id, status, type)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING created_at, updated_at, slug`,
+			product.ID, tenantID, product.Name, product.Description, product.Price, product.SKU, product.CategoryID, product.Status, product.Type,
+		).Scan(&product.CreatedAt, &product.UpdatedAt, &product.Slug)
+		if err != nil {
+			return mapSKUConflict(err)
+		}
+
+		if err := insertAuditEntry(ctx, tx, tenantID, domain.NewAuditEntry(ctx, domain.AuditActionCreate, nil, product)); err != nil {
+			return err
+		}
+
+		return insertEvents(ctx, tx, product.PullEvents())
+	})
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
 	return nil
 }
 
-func (r *PostgresProductRepository) Update(ctx context.Context, product *domain.Product) error {
-	return nil
+// Update applies change to the product locked for update and saves it, recording the
+// changed fields and the events change raised in the same transaction
+func (r *PostgresProductRepository) Update(ctx context.Context, id uuid.UUID, change func(*domain.Product) error) (*domain.Product, error) {
+	ctx, span := r.tracer.Start(ctx, "ProductRepository.Update")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", id.String()))
+
+	var (
+		product  *domain.Product
+		rejected error
+	)
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		before, err := scanProduct(tx.QueryRow(ctx, "SELECT "+productColumns+" FROM products WHERE id = $1 AND tenant_id = $2 FOR UPDATE", id, tenantID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrProductNotFound
+			}
+			return err
+		}
+
+		product = new(domain.Product)
+		*product = *before
+		if err := change(product); err != nil {
+			rejected = err
+			return err
+		}
+
+		err = tx.QueryRow(ctx,
+			`UPDATE products
+			SET name = $3, description = $4, price = $5, sku = $6, category_id = $7, status = $8, updated_at = NOW()
+			WHERE id = $1 AND tenant_id = $2
+			RETURNING created_at, updated_at, slug`,
+			id, tenantID, product.Name, product.Description, product.Price, product.SKU, product.CategoryID, product.Status,
+		).Scan(&product.CreatedAt, &product.UpdatedAt, &product.Slug)
+		if err != nil {
+			return mapSKUConflict(err)
+		}
+
+		entry := domain.NewAuditEntry(ctx, domain.AuditActionUpdate, before, product)
+		if len(entry.Changes) > 0 {
+			if err := insertAuditEntry(ctx, tx, tenantID, entry); err != nil {
+				return err
+			}
+		}
+
+		return insertEvents(ctx, tx, product.PullEvents())
+	})
+
+	if err != nil {
+		if err != rejected && !errors.Is(err, domain.ErrProductNotFound) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return nil, err
+	}
+
+	return product, nil
 }
 
+// Delete removes the product and records its last state and a ProductDeleted event in
+// the same transaction. Components of bundles that are not archived cannot be removed.
 func (r *PostgresProductRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, span := r.tracer.Start(ctx, "ProductRepository.Delete")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", id.String()))
+
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		before, err := scanProduct(tx.QueryRow(ctx, "DELETE FROM products WHERE id = $1 AND tenant_id = $2 RETURNING "+productColumns, id, tenantID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrProductNotFound
+			}
+			return mapComponentInUse(err)
+		}
+
+		if err := insertAuditEntry(ctx, tx, tenantID, domain.NewAuditEntry(ctx, domain.AuditActionDelete, before, nil)); err != nil {
+			return err
+		}
+
+		before.MarkDeleted()
+		return insertEvents(ctx, tx, before.PullEvents())
+	})
+
+	if err != nil {
+		if !errors.Is(err, domain.ErrComponentInUse) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+
 	return nil
 }
 
-func (r *PostgresProductRepository) Search(ctx context.Context, query string) ([]*domain.Product, error) {
-	return nil, nil
+// Search returns the products whose name, description or SKU contain query, ignoring
+// case, or whose translation in one of locales matches query in the text search
+// configuration of its language
+func (r *PostgresProductRepository) Search(ctx context.Context, query string, locales []string, filter domain.ProductFilter) ([]*domain.Product, error) {
+	ctx, span := r.tracer.Start(ctx, "ProductRepository.Search")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("search.query", query), attribute.StringSlice("search.locales", locales))
+
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+
+	var products []*domain.Product
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx, "SELECT "+productColumns+` FROM products
+			WHERE tenant_id = $1 AND `+statusCondition("$3")+` AND (
+				name ILIKE $2 OR description ILIKE $2 OR sku ILIKE $2
+				OR id IN (
+					SELECT t.product_id FROM product_translations t
+					JOIN unnest($4::text[]) AS l(locale) ON t.locale = l.locale
+					WHERE t.tenant_id = $1 AND t.search_vector @@ websearch_to_tsquery(product_search_config(l.locale), $5)
+				)
+			)
+			ORDER BY name`, tenantID, pattern, statusArgs(filter), locales, query)
+		if err != nil {
+			return err
+		}
+
+		products, err = collectProducts(rows)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return products, nil
 }
 
-func (r *PostgresProductRepository) GetByCategory(ctx context.Context, categoryID uuid.UUID) ([]*domain.Product, error) {
-	return nil, nil
+func (r *PostgresProductRepository) GetByCategory(ctx context.Context, categoryID uuid.UUID, filter domain.ProductFilter) ([]*domain.Product, error) {
+	return r.GetByCategoryIDs(ctx, []uuid.UUID{categoryID}, filter)
 }
 
-func (r *PostgresProductRepository) CategoryExists(ctx context.Context, id uuid.UUID) (bool, error) {
-	var exists bool
+// GetByCategoryIDs returns the products of several categories in a single query
+func (r *PostgresProductRepository) GetByCategoryIDs(ctx context.Context, categoryIDs []uuid.UUID, filter domain.ProductFilter) ([]*domain.Product, error) {
+	ctx, span := r.tracer.Start(ctx, "ProductRepository.GetByCategoryIDs")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("category.count", len(categoryIDs)))
+
+	var products []*domain.Product
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx, "SELECT "+productColumns+" FROM products WHERE tenant_id = $1 AND category_id = ANY($2) AND "+statusCondition("$3")+" ORDER BY name, id", tenantID, categoryIDs, statusArgs(filter))
+		if err != nil {
+			return err
+		}
+
+		products, err = collectProducts(rows)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
 
-	err := r.DB.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1)", id).Scan(&exists)
+	return products, nil
+}
 
-	defer r.DB.Close()
+func (r *PostgresProductRepository) CategoryExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	var exists bool
 
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		return tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1 AND tenant_id = $2)", id, tenantID).Scan(&exists)
+	})
 	if err != nil {
 		return false, err
 	}
 
 	return exists, nil
 }
+
+// scanProduct scans a row selected with productColumns into a product
+func scanProduct(row pgx.Row) (*domain.Product, error) {
+	var product domain.Product
+
+	err := row.Scan(
+		&product.ID,
+		&product.Name,
+		&product.Description,
+		&product.Price,
+		&product.SKU,
+		&product.CategoryID,
+		&product.Status,
+		&product.Type,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+		&product.Slug,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// statusArgs returns the statuses of filter as a query argument, nil matching any status
+func statusArgs(filter domain.ProductFilter) []string {
+	if len(filter.Statuses) == 0 {
+		return nil
+	}
+
+	statuses := make([]string, len(filter.Statuses))
+	for i, status := range filter.Statuses {
+		statuses[i] = string(status)
+	}
+	return statuses
+}
+
+func collectProducts(rows pgx.Rows) ([]*domain.Product, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Product, error) {
+		return scanProduct(row)
+	})
+}
+
+// mapSKUConflict turns a violation of the unique SKU constraint into domain.ErrDuplicateSKU
+func mapSKUConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == "products_tenant_id_sku_key" {
+		return domain.ErrDuplicateSKU
+	}
+	return err
+}
+
+// mapComponentInUse turns the violation raised when deleting a component of an active
+// bundle into domain.ErrComponentInUse
+func mapComponentInUse(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation && pgErr.ConstraintName == "bundle_components_component_in_use" {
+		return domain.ErrComponentInUse
+	}
+	return err
+}
@@ -0,0 +1,324 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"microservice/services/product-service/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// promotionColumns lists the promotion columns in the order scanPromotion expects them
+const promotionColumns = "id, name, kind, value, buy_quantity, get_quantity, category_ids, skus, min_spend, COALESCE(coupon_code, ''), usage_limit, usage_count, priority, exclusive, active, starts_at, ends_at, created_at, updated_at"
+
+// checkViolation is the SQLSTATE of a check constraint violation
+const checkViolation = "23514"
+
+type PostgresPromotionRepository struct {
+	DB     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewPromotionRepository(db *pgxpool.Pool, tracer trace.Tracer) *PostgresPromotionRepository {
+	return &PostgresPromotionRepository{
+		DB:     db,
+		tracer: tracer,
+	}
+}
+
+// List returns a page of the promotions of the tenant, oldest first
+func (r *PostgresPromotionRepository) List(ctx context.Context, limit, offset int) ([]*domain.Promotion, int, error) {
+	ctx, span := r.tracer.Start(ctx, "PromotionRepository.List")
+	defer span.End()
+
+	var (
+		promotions []*domain.Promotion
+		total      int
+	)
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx, "SELECT "+promotionColumns+" FROM promotions WHERE tenant_id = $1 ORDER BY created_at, id LIMIT $2 OFFSET $3", tenantID, limit, offset)
+		if err != nil {
+			return err
+		}
+
+		promotions, err = collectPromotions(rows)
+		if err != nil {
+			return err
+		}
+
+		return tx.QueryRow(ctx, "SELECT count(*) FROM promotions WHERE tenant_id = $1", tenantID).Scan(&total)
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, 0, err
+	}
+
+	return promotions, total, nil
+}
+
+func (r *PostgresPromotionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Promotion, error) {
+	ctx, span := r.tracer.Start(ctx, "PromotionRepository.GetByID")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("promotion.id", id.String()))
+
+	var promotion *domain.Promotion
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		var err error
+		promotion, err = scanPromotion(tx.QueryRow(ctx, "SELECT "+promotionColumns+" FROM promotions WHERE id = $1 AND tenant_id = $2", id, tenantID))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrPromotionNotFound
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return promotion, nil
+}
+
+// FindRunning returns the enabled promotions running at the given time that apply
+// automatically or have one of coupons as code
+func (r *PostgresPromotionRepository) FindRunning(ctx context.Context, coupons []string, at time.Time) ([]*domain.Promotion, error) {
+	ctx, span := r.tracer.Start(ctx, "PromotionRepository.FindRunning")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("promotion.coupons", len(coupons)))
+
+	var promotions []*domain.Promotion
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx, "SELECT "+promotionColumns+` FROM promotions
+			WHERE tenant_id = $1 AND active
+				AND (starts_at IS NULL OR starts_at <= $2) AND (ends_at IS NULL OR ends_at > $2)
+				AND (coupon_code IS NULL OR coupon_code = ANY($3))`,
+			tenantID, at, coupons)
+		if err != nil {
+			return err
+		}
+
+		promotions, err = collectPromotions(rows)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+func (r *PostgresPromotionRepository) Create(ctx context.Context, promotion *domain.Promotion) error {
+	ctx, span := r.tracer.Start(ctx, "PromotionRepository.Create")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("promotion.id", promotion.ID.String()))
+
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		saved, err := scanPromotion(tx.QueryRow(ctx,
+			`INSERT INTO promotions (id, tenant_id, name, kind, value, buy_quantity, get_quantity, category_ids, skus,
+				min_spend, coupon_code, usage_limit, priority, exclusive, active, starts_at, ends_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12, $13, $14, $15, $16, $17)
+			RETURNING `+promotionColumns,
+			promotion.ID, tenantID, promotion.Name, promotion.Kind, promotion.Value, promotion.BuyQuantity, promotion.GetQuantity,
+			promotionCategories(promotion), promotionSKUs(promotion), promotion.MinSpend, promotion.CouponCode, promotion.UsageLimit,
+			promotion.Priority, promotion.Exclusive, promotion.Active, promotion.StartsAt, promotion.EndsAt,
+		))
+		if err != nil {
+			return mapPromotionConflict(err)
+		}
+
+		*promotion = *saved
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrDuplicateCoupon) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Update replaces the rules of the promotion, keeping its usage count. A usage limit
+// below the count fails with domain.ErrInvalidPromotion.
+func (r *PostgresPromotionRepository) Update(ctx context.Context, promotion *domain.Promotion) error {
+	ctx, span := r.tracer.Start(ctx, "PromotionRepository.Update")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("promotion.id", promotion.ID.String()))
+
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		saved, err := scanPromotion(tx.QueryRow(ctx,
+			`UPDATE promotions
+			SET name = $3, kind = $4, value = $5, buy_quantity = $6, get_quantity = $7, category_ids = $8, skus = $9,
+				min_spend = $10, coupon_code = NULLIF($11, ''), usage_limit = $12, priority = $13, exclusive = $14,
+				active = $15, starts_at = $16, ends_at = $17, updated_at = NOW()
+			WHERE id = $1 AND tenant_id = $2
+			RETURNING `+promotionColumns,
+			promotion.ID, tenantID, promotion.Name, promotion.Kind, promotion.Value, promotion.BuyQuantity, promotion.GetQuantity,
+			promotionCategories(promotion), promotionSKUs(promotion), promotion.MinSpend, promotion.CouponCode, promotion.UsageLimit,
+			promotion.Priority, promotion.Exclusive, promotion.Active, promotion.StartsAt, promotion.EndsAt,
+		))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrPromotionNotFound
+			}
+			if isUsageLimitViolation(err) {
+				return domain.ErrInvalidPromotion
+			}
+			return mapPromotionConflict(err)
+		}
+
+		*promotion = *saved
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrPromotionNotFound) && !errors.Is(err, domain.ErrInvalidPromotion) && !errors.Is(err, domain.ErrDuplicateCoupon) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostgresPromotionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, span := r.tracer.Start(ctx, "PromotionRepository.Delete")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("promotion.id", id.String()))
+
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		tag, err := tx.Exec(ctx, "DELETE FROM promotions WHERE id = $1 AND tenant_id = $2", id, tenantID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrPromotionNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrPromotionNotFound) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Redeem counts a use of each of the promotions, all or none of them. It fails with
+// domain.ErrCouponExhausted if one of them reached its usage limit.
+func (r *PostgresPromotionRepository) Redeem(ctx context.Context, ids []uuid.UUID) error {
+	ctx, span := r.tracer.Start(ctx, "PromotionRepository.Redeem")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("promotion.count", len(ids)))
+
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		_, err := tx.Exec(ctx, "UPDATE promotions SET usage_count = usage_count + 1 WHERE id = ANY($1) AND tenant_id = $2", ids, tenantID)
+		if isUsageLimitViolation(err) {
+			return domain.ErrCouponExhausted
+		}
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrCouponExhausted) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// scanPromotion scans a row selected with promotionColumns into a promotion
+func scanPromotion(row pgx.Row) (*domain.Promotion, error) {
+	var p domain.Promotion
+
+	err := row.Scan(
+		&p.ID,
+		&p.Name,
+		&p.Kind,
+		&p.Value,
+		&p.BuyQuantity,
+		&p.GetQuantity,
+		&p.CategoryIDs,
+		&p.SKUs,
+		&p.MinSpend,
+		&p.CouponCode,
+		&p.UsageLimit,
+		&p.UsageCount,
+		&p.Priority,
+		&p.Exclusive,
+		&p.Active,
+		&p.StartsAt,
+		&p.EndsAt,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func collectPromotions(rows pgx.Rows) ([]*domain.Promotion, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Promotion, error) {
+		return scanPromotion(row)
+	})
+}
+
+// promotionCategories returns the targeted categories as a query argument, the
+// column not taking NULL
+func promotionCategories(p *domain.Promotion) []uuid.UUID {
+	if p.CategoryIDs == nil {
+		return []uuid.UUID{}
+	}
+	return p.CategoryIDs
+}
+
+// promotionSKUs returns the targeted SKUs as a query argument, the column not taking NULL
+func promotionSKUs(p *domain.Promotion) []string {
+	if p.SKUs == nil {
+		return []string{}
+	}
+	return p.SKUs
+}
+
+// mapPromotionConflict turns a violation of the unique coupon code constraint into
+// domain.ErrDuplicateCoupon
+func mapPromotionConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == "promotions_tenant_id_coupon_code_key" {
+		return domain.ErrDuplicateCoupon
+	}
+	return err
+}
+
+// isUsageLimitViolation reports whether err is the violation of the constraint keeping
+// the usage count of promotions within their limit
+func isUsageLimitViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == checkViolation && pgErr.ConstraintName == "promotions_usage_limit_check"
+}
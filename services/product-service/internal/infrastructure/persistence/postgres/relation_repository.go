@@ -0,0 +1,357 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"microservice/services/product-service/internal/domain"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// relationColumns lists the relation columns in the order scanRelation expects them
+const relationColumns = "product_id, related_id, type, position, bidirectional, created_at, updated_at"
+
+type PostgresRelationRepository struct {
+	DB     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewRelationRepository(db *pgxpool.Pool, tracer trace.Tracer) *PostgresRelationRepository {
+	return &PostgresRelationRepository{
+		DB:     db,
+		tracer: tracer,
+	}
+}
+
+// List returns the relations of a product of relType, or of any type if empty, in
+// list order with their related product. Relations to products not matching filter
+// are skipped.
+func (r *PostgresRelationRepository) List(ctx context.Context, productID uuid.UUID, relType domain.RelationType, filter domain.ProductFilter) ([]*domain.ProductRelation, error) {
+	ctx, span := r.tracer.Start(ctx, "RelationRepository.List")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()), attribute.String("relation.type", string(relType)))
+
+	var relations []*domain.ProductRelation
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx, "SELECT "+relationColumns+` FROM product_relations
+			WHERE product_id = $1 AND tenant_id = $2 AND ($3 = '' OR type = $3)
+			ORDER BY type, position, created_at`, productID, tenantID, string(relType))
+		if err != nil {
+			return err
+		}
+
+		all, err := collectRelations(rows)
+		if err != nil || len(all) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(all))
+		for i, rel := range all {
+			ids[i] = rel.RelatedID
+		}
+
+		rows, err = tx.Query(ctx, "SELECT "+productColumns+" FROM products WHERE id = ANY($1) AND tenant_id = $2 AND "+statusCondition("$3"), ids, tenantID, statusArgs(filter))
+		if err != nil {
+			return err
+		}
+
+		products, err := collectProducts(rows)
+		if err != nil {
+			return err
+		}
+
+		byID := make(map[uuid.UUID]*domain.Product, len(products))
+		for _, p := range products {
+			byID[p.ID] = p
+		}
+
+		for _, rel := range all {
+			if related, ok := byID[rel.RelatedID]; ok {
+				rel.Related = related
+				relations = append(relations, rel)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return relations, nil
+}
+
+func (r *PostgresRelationRepository) Get(ctx context.Context, productID uuid.UUID, relType domain.RelationType, relatedID uuid.UUID) (*domain.ProductRelation, error) {
+	ctx, span := r.tracer.Start(ctx, "RelationRepository.Get")
+	defer span.End()
+
+	span.SetAttributes(relationAttributes(productID, relType, relatedID)...)
+
+	var relation *domain.ProductRelation
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		var err error
+		relation, err = getRelation(ctx, tx, tenantID, productID, relType, relatedID, false)
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrRelationNotFound) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return nil, err
+	}
+
+	return relation, nil
+}
+
+// Create adds the relation at its position in the list of its product and type and,
+// if bidirectional, the reverse relation at the end of the list of the related product
+func (r *PostgresRelationRepository) Create(ctx context.Context, relation *domain.ProductRelation) error {
+	ctx, span := r.tracer.Start(ctx, "RelationRepository.Create")
+	defer span.End()
+
+	span.SetAttributes(relationAttributes(relation.ProductID, relation.Type, relation.RelatedID)...)
+
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		err := tx.QueryRow(ctx,
+			`INSERT INTO product_relations (product_id, related_id, tenant_id, type, bidirectional)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT DO NOTHING
+			RETURNING created_at, updated_at`,
+			relation.ProductID, relation.RelatedID, tenantID, relation.Type, relation.Bidirectional,
+		).Scan(&relation.CreatedAt, &relation.UpdatedAt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrDuplicateRelation
+			}
+			return err
+		}
+
+		if relation.Position, err = placeRelation(ctx, tx, tenantID, relation, relation.Position); err != nil {
+			return err
+		}
+
+		if relation.Bidirectional {
+			return saveReverse(ctx, tx, tenantID, relation)
+		}
+		return nil
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return domain.ErrProductNotFound
+		}
+
+		if !errors.Is(err, domain.ErrDuplicateRelation) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Update moves the relation to its position and adds or removes the reverse relation
+// when it becomes bidirectional or stops being so
+func (r *PostgresRelationRepository) Update(ctx context.Context, relation *domain.ProductRelation) error {
+	ctx, span := r.tracer.Start(ctx, "RelationRepository.Update")
+	defer span.End()
+
+	span.SetAttributes(relationAttributes(relation.ProductID, relation.Type, relation.RelatedID)...)
+
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		before, err := getRelation(ctx, tx, tenantID, relation.ProductID, relation.Type, relation.RelatedID, true)
+		if err != nil {
+			return err
+		}
+
+		if relation.Position, err = placeRelation(ctx, tx, tenantID, relation, relation.Position); err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(ctx,
+			`UPDATE product_relations SET bidirectional = $5, updated_at = NOW()
+			WHERE product_id = $1 AND type = $2 AND related_id = $3 AND tenant_id = $4
+			RETURNING created_at, updated_at`,
+			relation.ProductID, relation.Type, relation.RelatedID, tenantID, relation.Bidirectional,
+		).Scan(&relation.CreatedAt, &relation.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case relation.Bidirectional && !before.Bidirectional:
+			return saveReverse(ctx, tx, tenantID, relation)
+		case !relation.Bidirectional && before.Bidirectional:
+			return deleteReverse(ctx, tx, tenantID, relation)
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrRelationNotFound) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Delete removes the relation and, if bidirectional, the reverse relation
+func (r *PostgresRelationRepository) Delete(ctx context.Context, productID uuid.UUID, relType domain.RelationType, relatedID uuid.UUID) error {
+	ctx, span := r.tracer.Start(ctx, "RelationRepository.Delete")
+	defer span.End()
+
+	span.SetAttributes(relationAttributes(productID, relType, relatedID)...)
+
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		relation, err := scanRelation(tx.QueryRow(ctx,
+			"DELETE FROM product_relations WHERE product_id = $1 AND type = $2 AND related_id = $3 AND tenant_id = $4 RETURNING "+relationColumns,
+			productID, relType, relatedID, tenantID))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return domain.ErrRelationNotFound
+			}
+			return err
+		}
+
+		if relation.Bidirectional {
+			return deleteReverse(ctx, tx, tenantID, relation)
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrRelationNotFound) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+func getRelation(ctx context.Context, tx pgx.Tx, tenantID string, productID uuid.UUID, relType domain.RelationType, relatedID uuid.UUID, forUpdate bool) (*domain.ProductRelation, error) {
+	query := "SELECT " + relationColumns + " FROM product_relations WHERE product_id = $1 AND type = $2 AND related_id = $3 AND tenant_id = $4"
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	relation, err := scanRelation(tx.QueryRow(ctx, query, productID, relType, relatedID, tenantID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, domain.ErrRelationNotFound
+	}
+	return relation, err
+}
+
+// placeRelation moves a stored relation to position in the list of its product and
+// type, or last if position is negative or past the end, renumbering the list from 0.
+// It returns the position the relation ends up at.
+func placeRelation(ctx context.Context, tx pgx.Tx, tenantID string, relation *domain.ProductRelation, position int) (int, error) {
+	// Locking the list serializes concurrent moves within it
+	rows, err := tx.Query(ctx,
+		`SELECT related_id FROM product_relations
+		WHERE product_id = $1 AND type = $2 AND tenant_id = $3 AND related_id <> $4
+		ORDER BY position, created_at
+		FOR UPDATE`,
+		relation.ProductID, relation.Type, tenantID, relation.RelatedID)
+	if err != nil {
+		return 0, err
+	}
+
+	order, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return 0, err
+	}
+
+	if position < 0 || position > len(order) {
+		position = len(order)
+	}
+	order = slices.Insert(order, position, relation.RelatedID)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE product_relations r SET position = o.position - 1
+		FROM unnest($4::uuid[]) WITH ORDINALITY AS o(related_id, position)
+		WHERE r.product_id = $1 AND r.type = $2 AND r.tenant_id = $3 AND r.related_id = o.related_id
+			AND r.position <> o.position - 1`,
+		relation.ProductID, relation.Type, tenantID, order)
+	if err != nil {
+		return 0, err
+	}
+
+	return position, nil
+}
+
+// saveReverse adds the reverse of a bidirectional relation at the end of the list of
+// the related product, or marks it bidirectional if the related product already has it
+func saveReverse(ctx context.Context, tx pgx.Tx, tenantID string, relation *domain.ProductRelation) error {
+	reverse := relation.Reverse()
+
+	var created bool
+	err := tx.QueryRow(ctx,
+		`INSERT INTO product_relations (product_id, related_id, tenant_id, type, bidirectional)
+		VALUES ($1, $2, $3, $4, TRUE)
+		ON CONFLICT (product_id, type, related_id) DO UPDATE SET bidirectional = TRUE, updated_at = NOW()
+		RETURNING xmax = 0`,
+		reverse.ProductID, reverse.RelatedID, tenantID, reverse.Type,
+	).Scan(&created)
+	if err != nil || !created {
+		return err
+	}
+
+	_, err = placeRelation(ctx, tx, tenantID, reverse, reverse.Position)
+	return err
+}
+
+// deleteReverse removes the reverse of a relation that was bidirectional
+func deleteReverse(ctx context.Context, tx pgx.Tx, tenantID string, relation *domain.ProductRelation) error {
+	_, err := tx.Exec(ctx,
+		"DELETE FROM product_relations WHERE product_id = $1 AND type = $2 AND related_id = $3 AND tenant_id = $4 AND bidirectional",
+		relation.RelatedID, relation.Type, relation.ProductID, tenantID)
+	return err
+}
+
+func relationAttributes(productID uuid.UUID, relType domain.RelationType, relatedID uuid.UUID) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("product.id", productID.String()),
+		attribute.String("relation.type", string(relType)),
+		attribute.String("relation.related_id", relatedID.String()),
+	}
+}
+
+// scanRelation scans a row selected with relationColumns into a relation
+func scanRelation(row pgx.Row) (*domain.ProductRelation, error) {
+	var rel domain.ProductRelation
+
+	err := row.Scan(
+		&rel.ProductID,
+		&rel.RelatedID,
+		&rel.Type,
+		&rel.Position,
+		&rel.Bidirectional,
+		&rel.CreatedAt,
+		&rel.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rel, nil
+}
+
+func collectRelations(rows pgx.Rows) ([]*domain.ProductRelation, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.ProductRelation, error) {
+		return scanRelation(row)
+	})
+}
@@ -0,0 +1,264 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"microservice/services/product-service/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// taxRateColumns lists the tax rate columns in the order collectTaxRates expects them
+const taxRateColumns = "country, region, tax_class, rate, valid_from, valid_to"
+
+type PostgresTaxRepository struct {
+	DB     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewTaxRepository(db *pgxpool.Pool, tracer trace.Tracer) *PostgresTaxRepository {
+	return &PostgresTaxRepository{
+		DB:     db,
+		tracer: tracer,
+	}
+}
+
+// ListRates returns the rate table of the tenant, ordered by country, region, class
+// and start
+func (r *PostgresTaxRepository) ListRates(ctx context.Context) ([]domain.TaxRate, error) {
+	ctx, span := r.tracer.Start(ctx, "TaxRepository.ListRates")
+	defer span.End()
+
+	var rates []domain.TaxRate
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx,
+			"SELECT "+taxRateColumns+" FROM tax_rates WHERE tenant_id = $1 ORDER BY country, region, tax_class, valid_from",
+			tenantID)
+		if err != nil {
+			return err
+		}
+
+		rates, err = collectTaxRates(rows)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// FindRates returns the rates of a country and its regions
+func (r *PostgresTaxRepository) FindRates(ctx context.Context, country string) ([]domain.TaxRate, error) {
+	ctx, span := r.tracer.Start(ctx, "TaxRepository.FindRates")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("tax.country", country))
+
+	var rates []domain.TaxRate
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx,
+			"SELECT "+taxRateColumns+" FROM tax_rates WHERE tenant_id = $1 AND country = $2",
+			tenantID, country)
+		if err != nil {
+			return err
+		}
+
+		rates, err = collectTaxRates(rows)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// ReplaceRates replaces the rate table of the tenant with rates, all at once
+func (r *PostgresTaxRepository) ReplaceRates(ctx context.Context, rates []domain.TaxRate) error {
+	ctx, span := r.tracer.Start(ctx, "TaxRepository.ReplaceRates")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("tax.rates", len(rates)))
+
+	var (
+		countries  = make([]string, len(rates))
+		regions    = make([]string, len(rates))
+		classes    = make([]string, len(rates))
+		values     = make([]float64, len(rates))
+		validFroms = make([]pgtype.Date, len(rates))
+		validTos   = make([]pgtype.Date, len(rates))
+	)
+	for i, rate := range rates {
+		countries[i] = rate.Country
+		regions[i] = rate.Region
+		classes[i] = rate.Class
+		values[i] = rate.Rate
+		validFroms[i] = pgtype.Date{Time: rate.ValidFrom, Valid: true}
+		if rate.ValidTo != nil {
+			validTos[i] = pgtype.Date{Time: *rate.ValidTo, Valid: true}
+		}
+	}
+
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM tax_rates WHERE tenant_id = $1", tenantID); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx,
+			`INSERT INTO tax_rates (tenant_id, `+taxRateColumns+`)
+			SELECT $1, r.* FROM unnest($2::text[], $3::text[], $4::text[], $5::numeric[], $6::date[], $7::date[])
+				AS r(country, region, tax_class, rate, valid_from, valid_to)`,
+			tenantID, countries, regions, classes, values, validFroms, validTos)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			// Rates are checked for overlaps before, which includes starting on the same day
+			return domain.ErrInvalidTaxRate
+		}
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrInvalidTaxRate) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// TaxClasses returns the tax classes assigned to the products and the categories
+// having one, by ID
+func (r *PostgresTaxRepository) TaxClasses(ctx context.Context, productIDs, categoryIDs []uuid.UUID) (map[uuid.UUID]string, map[uuid.UUID]string, error) {
+	ctx, span := r.tracer.Start(ctx, "TaxRepository.TaxClasses")
+	defer span.End()
+
+	var products, categories map[uuid.UUID]string
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		var err error
+		products, err = findTaxClasses(ctx, tx,
+			"SELECT product_id, tax_class FROM product_tax_classes WHERE product_id = ANY($1) AND tenant_id = $2",
+			productIDs, tenantID)
+		if err != nil {
+			return err
+		}
+
+		categories, err = findTaxClasses(ctx, tx,
+			"SELECT category_id, tax_class FROM category_tax_classes WHERE category_id = ANY($1) AND tenant_id = $2",
+			categoryIDs, tenantID)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, nil, err
+	}
+
+	return products, categories, nil
+}
+
+// SetProductTaxClass assigns a tax class to a product, or removes its own class if
+// class is empty
+func (r *PostgresTaxRepository) SetProductTaxClass(ctx context.Context, productID uuid.UUID, class string) error {
+	ctx, span := r.tracer.Start(ctx, "TaxRepository.SetProductTaxClass")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()))
+
+	err := r.setTaxClass(ctx, "product_tax_classes", "product_id", productID, class)
+	if isForeignKeyViolation(err) {
+		return domain.ErrProductNotFound
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// SetCategoryTaxClass assigns a tax class to a category, or removes it if class is
+// empty
+func (r *PostgresTaxRepository) SetCategoryTaxClass(ctx context.Context, categoryID uuid.UUID, class string) error {
+	ctx, span := r.tracer.Start(ctx, "TaxRepository.SetCategoryTaxClass")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("category.id", categoryID.String()))
+
+	err := r.setTaxClass(ctx, "category_tax_classes", "category_id", categoryID, class)
+	if isForeignKeyViolation(err) {
+		return domain.ErrCategoryNotFound
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// setTaxClass upserts, or deletes if class is empty, the assignment of id in table,
+// keyed by column. table and column are never user input.
+func (r *PostgresTaxRepository) setTaxClass(ctx context.Context, table, column string, id uuid.UUID, class string) error {
+	return inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		if class == "" {
+			_, err := tx.Exec(ctx, "DELETE FROM "+table+" WHERE "+column+" = $1 AND tenant_id = $2", id, tenantID)
+			return err
+		}
+
+		_, err := tx.Exec(ctx,
+			"INSERT INTO "+table+" ("+column+", tenant_id, tax_class) VALUES ($1, $2, $3) "+
+				"ON CONFLICT ("+column+") DO UPDATE SET tax_class = $3, updated_at = NOW()",
+			id, tenantID, class)
+		return err
+	})
+}
+
+func findTaxClasses(ctx context.Context, tx pgx.Tx, query string, ids []uuid.UUID, tenantID string) (map[uuid.UUID]string, error) {
+	classes := make(map[uuid.UUID]string)
+	if len(ids) == 0 {
+		return classes, nil
+	}
+
+	rows, err := tx.Query(ctx, query, ids, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id    uuid.UUID
+			class string
+		)
+		if err := rows.Scan(&id, &class); err != nil {
+			return nil, err
+		}
+		classes[id] = class
+	}
+
+	return classes, rows.Err()
+}
+
+// collectTaxRates scans rows selected with taxRateColumns
+func collectTaxRates(rows pgx.Rows) ([]domain.TaxRate, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.TaxRate, error) {
+		var rate domain.TaxRate
+		err := row.Scan(&rate.Country, &rate.Region, &rate.Class, &rate.Rate, &rate.ValidFrom, &rate.ValidTo)
+		return rate, err
+	})
+}
@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"context"
+	"microservice/pkg/tenant"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// tenantRole is the role catalog queries run as. Unlike the owner of the tables it
+// cannot bypass the row level security policies, which restrict it to the rows of
+// the tenant in the app.tenant_id setting.
+const tenantRole = "catalog_tenant"
+
+// inTenant runs fn in a transaction restricted to the tenant of ctx by row level
+// security. fn receives the tenant to filter its queries on as well, so a query
+// missing the filter still cannot see the rows of other tenants.
+func inTenant(ctx context.Context, db *pgxpool.Pool, fn func(tx pgx.Tx, tenantID string) error) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return tenant.ErrMissingTenant
+	}
+
+	return pgx.BeginFunc(ctx, db, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, "SELECT set_config('role', $1, true), set_config('app.tenant_id', $2, true)", tenantRole, tenantID)
+		if err != nil {
+			return err
+		}
+
+		return fn(tx, tenantID)
+	})
+}
@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"microservice/services/product-service/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// translationColumns lists the translation columns in the order scanTranslation expects them
+const translationColumns = "product_id, locale, name, description, created_at, updated_at"
+
+// foreignKeyViolation is the SQLSTATE of a foreign key constraint violation
+const foreignKeyViolation = "23503"
+
+type PostgresTranslationRepository struct {
+	DB     *pgxpool.Pool
+	tracer trace.Tracer
+}
+
+func NewTranslationRepository(db *pgxpool.Pool, tracer trace.Tracer) *PostgresTranslationRepository {
+	return &PostgresTranslationRepository{
+		DB:     db,
+		tracer: tracer,
+	}
+}
+
+// ListByProduct returns the translations of a product ordered by locale
+func (r *PostgresTranslationRepository) ListByProduct(ctx context.Context, productID uuid.UUID) ([]*domain.ProductTranslation, error) {
+	ctx, span := r.tracer.Start(ctx, "TranslationRepository.ListByProduct")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()))
+
+	var translations []*domain.ProductTranslation
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx, "SELECT "+translationColumns+" FROM product_translations WHERE product_id = $1 AND tenant_id = $2 ORDER BY locale", productID, tenantID)
+		if err != nil {
+			return err
+		}
+
+		translations, err = collectTranslations(rows)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return translations, nil
+}
+
+func (r *PostgresTranslationRepository) Get(ctx context.Context, productID uuid.UUID, locale string) (*domain.ProductTranslation, error) {
+	ctx, span := r.tracer.Start(ctx, "TranslationRepository.Get")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()), attribute.String("translation.locale", locale))
+
+	var translation *domain.ProductTranslation
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		var err error
+		translation, err = scanTranslation(tx.QueryRow(ctx, "SELECT "+translationColumns+" FROM product_translations WHERE product_id = $1 AND locale = $2 AND tenant_id = $3", productID, locale, tenantID))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrTranslationNotFound
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return translation, nil
+}
+
+// Save creates or replaces the translation of a product in a locale and reports
+// whether it was created
+func (r *PostgresTranslationRepository) Save(ctx context.Context, translation *domain.ProductTranslation) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "TranslationRepository.Save")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", translation.ProductID.String()), attribute.String("translation.locale", translation.Locale))
+
+	var created bool
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		// xmax is 0 for a row inserted rather than updated by the statement
+		return tx.QueryRow(ctx,
+			`INSERT INTO product_translations (product_id, tenant_id, locale, name, description)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (product_id, locale) DO UPDATE
+			SET name = EXCLUDED.name, description = EXCLUDED.description, updated_at = NOW()
+			RETURNING created_at, updated_at, xmax = 0`,
+			translation.ProductID, tenantID, translation.Locale, translation.Name, translation.Description,
+		).Scan(&translation.CreatedAt, &translation.UpdatedAt, &created)
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return false, domain.ErrProductNotFound
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, err
+	}
+
+	return created, nil
+}
+
+func (r *PostgresTranslationRepository) Delete(ctx context.Context, productID uuid.UUID, locale string) error {
+	ctx, span := r.tracer.Start(ctx, "TranslationRepository.Delete")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product.id", productID.String()), attribute.String("translation.locale", locale))
+
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		tag, err := tx.Exec(ctx, "DELETE FROM product_translations WHERE product_id = $1 AND locale = $2 AND tenant_id = $3", productID, locale, tenantID)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return domain.ErrTranslationNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, domain.ErrTranslationNotFound) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+
+	return nil
+}
+
+// FindBest returns, for each of the products having one, its translation in the first
+// of locales it is translated into
+func (r *PostgresTranslationRepository) FindBest(ctx context.Context, productIDs []uuid.UUID, locales []string) (map[uuid.UUID]*domain.ProductTranslation, error) {
+	ctx, span := r.tracer.Start(ctx, "TranslationRepository.FindBest")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("product.count", len(productIDs)), attribute.StringSlice("translation.locales", locales))
+
+	translations := make(map[uuid.UUID]*domain.ProductTranslation, len(productIDs))
+	if len(productIDs) == 0 || len(locales) == 0 {
+		return translations, nil
+	}
+
+	err := inTenant(ctx, r.DB, func(tx pgx.Tx, tenantID string) error {
+		rows, err := tx.Query(ctx, "SELECT DISTINCT ON (product_id) "+translationColumns+` FROM product_translations
+			WHERE tenant_id = $1 AND product_id = ANY($2) AND locale = ANY($3::text[])
+			ORDER BY product_id, array_position($3::text[], locale::text)`, tenantID, productIDs, locales)
+		if err != nil {
+			return err
+		}
+
+		found, err := collectTranslations(rows)
+		if err != nil {
+			return err
+		}
+
+		for _, t := range found {
+			translations[t.ProductID] = t
+		}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return translations, nil
+}
+
+// scanTranslation scans a row selected with translationColumns into a translation
+func scanTranslation(row pgx.Row) (*domain.ProductTranslation, error) {
+	var t domain.ProductTranslation
+
+	err := row.Scan(
+		&t.ProductID,
+		&t.Locale,
+		&t.Name,
+		&t.Description,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func collectTranslations(rows pgx.Rows) ([]*domain.ProductTranslation, error) {
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.ProductTranslation, error) {
+		return scanTranslation(row)
+	})
+}
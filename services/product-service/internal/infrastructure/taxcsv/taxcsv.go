@@ -0,0 +1,161 @@
+// Package taxcsv reads and writes tax rate tables as CSV.
+//
+// A table starts with a header naming its columns, in any order:
+//
+//	country,region,tax_class,rate,valid_from,valid_to
+//	DE,,standard,19,2007-01-01,
+//	DE,,reduced,7,2007-01-01,
+//	US,CA,standard,7.25,2017-01-01,
+//
+// country, tax_class, rate and valid_from are required. region is empty, or the
+// column left out, for the rate of the whole country, and valid_to is empty while
+// the rate has no end. Dates are YYYY-MM-DD, valid_to being the first day the rate
+// no longer applies. Lines starting with # are ignored.
+package taxcsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"microservice/services/product-service/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentType is the media type of tax rate tables
+const ContentType = "text/csv"
+
+// header lists the columns in the order Write writes them
+var header = []string{"country", "region", "tax_class", "rate", "valid_from", "valid_to"}
+
+// required lists the columns a table must have
+var required = []string{"country", "tax_class", "rate", "valid_from"}
+
+// Read parses a tax rate table, checking each rate and that the rates of a class
+// and place do not overlap. Errors wrap domain.ErrInvalidTaxRate and name the line
+// at fault.
+func Read(r io.Reader) ([]domain.TaxRate, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	names, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the header is missing", domain.ErrInvalidTaxRate)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidTaxRate, err)
+	}
+
+	columns := make(map[string]int, len(names))
+	for i, name := range names {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("line 1: %w: column %s is repeated", domain.ErrInvalidTaxRate, name)
+		}
+		columns[name] = i
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("line 1: %w: column %s is missing", domain.ErrInvalidTaxRate, name)
+		}
+	}
+
+	var rates []domain.TaxRate
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidTaxRate, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rate, err := parseRate(record, columns)
+		if err == nil {
+			err = rate.Validate()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		rates = append(rates, rate)
+	}
+
+	if err := domain.ValidateTaxRates(rates); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// Write writes rates as a table Read parses back
+func Write(w io.Writer, rates []domain.TaxRate) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range rates {
+		validTo := ""
+		if r.ValidTo != nil {
+			validTo = r.ValidTo.Format(time.DateOnly)
+		}
+
+		record := []string{
+			r.Country,
+			r.Region,
+			r.Class,
+			strconv.FormatFloat(r.Rate, 'f', -1, 64),
+			r.ValidFrom.Format(time.DateOnly),
+			validTo,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// parseRate reads the rate of a record, by the position of the columns
+func parseRate(record []string, columns map[string]int) (domain.TaxRate, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rate := domain.TaxRate{
+		Country: strings.ToUpper(field("country")),
+		Region:  strings.ToUpper(field("region")),
+		Class:   field("tax_class"),
+	}
+
+	var err error
+	if rate.Rate, err = strconv.ParseFloat(field("rate"), 64); err != nil {
+		return rate, fmt.Errorf("%w: rate %q is not a number", domain.ErrInvalidTaxRate, field("rate"))
+	}
+
+	if rate.ValidFrom, err = time.Parse(time.DateOnly, field("valid_from")); err != nil {
+		return rate, fmt.Errorf("%w: valid_from %q is not a YYYY-MM-DD date", domain.ErrInvalidTaxRate, field("valid_from"))
+	}
+
+	if s := field("valid_to"); s != "" {
+		validTo, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return rate, fmt.Errorf("%w: valid_to %q is not a YYYY-MM-DD date", domain.ErrInvalidTaxRate, s)
+		}
+		rate.ValidTo = &validTo
+	}
+
+	return rate, nil
+}
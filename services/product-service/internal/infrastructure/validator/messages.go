@@ -0,0 +1,160 @@
+package validator
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"microservice/pkg/locale"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLocale is the locale of the messages of new errors and of requests whose
+// locales are not supported
+var DefaultLocale = language.English
+
+// localeFiles holds a JSON catalog of message templates per locale, named after its
+// BCP 47 tag. Templates are keyed by code; a key may be refined by the kind param, as
+// max.string, and by .one when param is 1, as max.string.one.
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalogSet holds the message templates of the supported locales
+type catalogSet struct {
+	mu       sync.RWMutex
+	catalogs map[language.Tag]map[string]string
+}
+
+var messages = loadCatalogs()
+
+func loadCatalogs() *catalogSet {
+	set := &catalogSet{catalogs: make(map[language.Tag]map[string]string)}
+
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("validator: read locales: %v", err))
+	}
+
+	for _, file := range files {
+		data, err := localeFiles.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("validator: read locale %s: %v", file.Name(), err))
+		}
+
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("validator: parse locale %s: %v", file.Name(), err))
+		}
+
+		set.add(language.MustParse(strings.TrimSuffix(file.Name(), ".json")), catalog)
+	}
+
+	return set
+}
+
+// add merges catalog into the templates of locale; the caller holds the lock or owns set
+func (set *catalogSet) add(locale language.Tag, catalog map[string]string) {
+	existing, ok := set.catalogs[locale]
+	if !ok {
+		existing = make(map[string]string, len(catalog))
+		set.catalogs[locale] = existing
+	}
+
+	for code, template := range catalog {
+		existing[code] = template
+	}
+}
+
+// RegisterMessages adds message templates to the catalog of locale, replacing those
+// with the same codes. Registering a new locale lets requests select it.
+func RegisterMessages(locale language.Tag, catalog map[string]string) {
+	messages.mu.Lock()
+	defer messages.mu.Unlock()
+
+	messages.add(locale, catalog)
+}
+
+// ContextLocale returns the first locale with messages in the locale chain of ctx,
+// as resolved by the locale middleware, or DefaultLocale
+func ContextLocale(ctx context.Context) language.Tag {
+	chain, _ := locale.FromContext(ctx)
+
+	messages.mu.RLock()
+	defer messages.mu.RUnlock()
+
+	for _, l := range chain {
+		tag, err := language.Parse(l)
+		if err != nil {
+			continue
+		}
+		if _, ok := messages.catalogs[tag]; ok {
+			return tag
+		}
+	}
+	return DefaultLocale
+}
+
+// Localize returns a copy of errs with the messages of locale. Errors without a
+// template in locale, as those added with a free-form message, keep their message.
+func Localize(errs []ValidationError, locale language.Tag) []ValidationError {
+	localized := make([]ValidationError, len(errs))
+	for i, e := range errs {
+		localized[i] = e
+		if msg, ok := lookupMessage(locale, e.Field, e.Code, e.Params); ok {
+			localized[i].Message = msg
+		}
+	}
+	return localized
+}
+
+// message renders the template of code in locale, falling back to the code itself
+func message(locale language.Tag, field, code string, params Params) string {
+	if msg, ok := lookupMessage(locale, field, code, params); ok {
+		return msg
+	}
+	return field + ": " + code
+}
+
+func lookupMessage(locale language.Tag, field, code string, params Params) (string, bool) {
+	messages.mu.RLock()
+	catalog := messages.catalogs[locale]
+	var (
+		template string
+		ok       bool
+	)
+	for _, key := range messageKeys(code, params) {
+		if template, ok = catalog[key]; ok {
+			break
+		}
+	}
+	messages.mu.RUnlock()
+
+	if !ok {
+		return "", false
+	}
+
+	replacements := []string{"{field}", field}
+	for name, value := range params {
+		replacements = append(replacements, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(replacements...).Replace(template), true
+}
+
+// messageKeys returns the keys a template of code is looked up with, most specific first
+func messageKeys(code string, params Params) []string {
+	keys := make([]string, 0, 4)
+
+	if kind := params["kind"]; kind != "" {
+		if params["param"] == "1" {
+			keys = append(keys, code+"."+kind+".one")
+		}
+		keys = append(keys, code+"."+kind)
+	}
+
+	return append(keys, code)
+}
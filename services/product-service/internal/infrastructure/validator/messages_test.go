@@ -0,0 +1,117 @@
+package validator
+
+import (
+	"context"
+	"microservice/pkg/locale"
+	"slices"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestMessageKeys(t *testing.T) {
+	tests := []struct {
+		code   string
+		params Params
+		want   []string
+	}{
+		{"required", nil, []string{"required"}},
+		{"max", Params{"param": "255"}, []string{"max"}},
+		{"max", Params{"param": "255", "kind": "string"}, []string{"max.string", "max"}},
+		{"min", Params{"param": "1", "kind": "items"}, []string{"min.items.one", "min.items", "min"}},
+	}
+
+	for _, tt := range tests {
+		if got := messageKeys(tt.code, tt.params); !slices.Equal(got, tt.want) {
+			t.Errorf("messageKeys(%s, %v) = %q, want %q", tt.code, tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	RegisterMessages(DefaultLocale, map[string]string{"sku_taken": "{field} {param} is taken"})
+
+	v := New()
+	v.AddRuleError("name", "required", nil)
+	v.AddRuleError("name", "max", Params{"param": "1", "kind": "string"})
+	v.AddRuleError("sku", "sku_taken", Params{"param": "AB-1"})
+	v.AddRuleError("sku", "not_in_any_catalog", nil)
+	v.AddError("price", "price looks wrong")
+
+	tests := []struct {
+		locale language.Tag
+		want   []string
+	}{
+		{language.English, []string{
+			"name is required",
+			"name must be at most 1 character",
+			"sku AB-1 is taken",
+			"sku: not_in_any_catalog",
+			"price looks wrong",
+		}},
+		// Codes without a German template keep the message they were added with
+		{language.German, []string{
+			"name ist erforderlich",
+			"name darf höchstens 1 Zeichen lang sein",
+			"sku AB-1 is taken",
+			"sku: not_in_any_catalog",
+			"price looks wrong",
+		}},
+		// A locale without a catalog keeps every message
+		{language.Japanese, []string{
+			"name is required",
+			"name must be at most 1 character",
+			"sku AB-1 is taken",
+			"sku: not_in_any_catalog",
+			"price looks wrong",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale.String(), func(t *testing.T) {
+			var got []string
+			for _, e := range Localize(v.Errors, tt.locale) {
+				got = append(got, e.Message)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("messages = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if v.Errors[0].Message != "name is required" {
+		t.Errorf("Localize changed the errors it was given")
+	}
+}
+
+func TestRegisterMessagesAddsLocale(t *testing.T) {
+	RegisterMessages(language.Italian, map[string]string{"required": "{field} è obbligatorio"})
+
+	got := Localize([]ValidationError{{Field: "name", Code: "required", Message: "name is required"}}, language.Italian)
+	if got[0].Message != "name è obbligatorio" {
+		t.Errorf("message = %q, want name è obbligatorio", got[0].Message)
+	}
+}
+
+func TestContextLocale(t *testing.T) {
+	tests := []struct {
+		chain []string
+		want  language.Tag
+	}{
+		{nil, DefaultLocale},
+		{[]string{"de-AT", "de", "en"}, language.German},
+		{[]string{"pt-BR", "pt", "es", "en"}, language.Spanish},
+		{[]string{"ja", "en"}, language.English},
+		{[]string{"ja"}, DefaultLocale},
+	}
+
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.chain != nil {
+			ctx = locale.WithChain(ctx, tt.chain)
+		}
+		if got := ContextLocale(ctx); got != tt.want {
+			t.Errorf("ContextLocale(%q) = %s, want %s", tt.chain, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrDecode is returned by Pipeline.Run when the request cannot be decoded
+var ErrDecode = errors.New("failed to decode request")
+
+// Decoder fills a request from its source, as the body of an HTTP request
+type Decoder[T any] func(req *T) error
+
+// DecodeJSON returns a Decoder reading a JSON document from r
+func DecodeJSON[T any](r io.Reader) Decoder[T] {
+	return func(req *T) error {
+		return json.NewDecoder(r).Decode(req)
+	}
+}
+
+// CheckFunc adds the errors of the syntactic rules of a request to v
+type CheckFunc[T any] func(v *Validator, req *T)
+
+// AsyncCheckFunc adds the errors of a rule needing I/O, as a database lookup, to v.
+// Its error aborts the validation.
+type AsyncCheckFunc[T any] func(ctx context.Context, v *Validator, req *T) error
+
+// Pipeline validates requests of type T in stages: decoding, the syntactic checks in
+// order, then the async checks concurrently. Every check runs, so a single pass
+// reports all the errors of a request. Async checks must tolerate syntactically
+// invalid requests, as a category ID that is not a UUID.
+//
+// A Pipeline is built once and is safe for concurrent use; each run validates with
+// its own Validator taken from a pool.
+type Pipeline[T any] struct {
+	checks      []CheckFunc[T]
+	asyncChecks []AsyncCheckFunc[T]
+}
+
+func NewPipeline[T any]() *Pipeline[T] {
+	return &Pipeline[T]{}
+}
+
+// Check adds a syntactic check to the pipeline
+func (p *Pipeline[T]) Check(check CheckFunc[T]) *Pipeline[T] {
+	p.checks = append(p.checks, check)
+	return p
+}
+
+// CheckAsync adds a check run concurrently with the other async checks, after the
+// syntactic ones
+func (p *Pipeline[T]) CheckAsync(check AsyncCheckFunc[T]) *Pipeline[T] {
+	p.asyncChecks = append(p.asyncChecks, check)
+	return p
+}
+
+// Run decodes a request and validates it. Decoding errors wrap ErrDecode.
+func (p *Pipeline[T]) Run(ctx context.Context, decode Decoder[T]) (*T, []ValidationError, error) {
+	req := new(T)
+	if err := decode(req); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+
+	errs, err := p.Validate(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return req, errs, nil
+}
+
+// Validate runs the checks on an already decoded request and returns the errors
+// found, or none if it is valid
+func (p *Pipeline[T]) Validate(ctx context.Context, req *T) ([]ValidationError, error) {
+	v := acquire()
+	defer release(v)
+
+	for _, check := range p.checks {
+		check(v, req)
+	}
+
+	if err := p.runAsync(ctx, v, req); err != nil {
+		return nil, err
+	}
+
+	if v.Valid() {
+		return nil, nil
+	}
+
+	// v returns to the pool, so its errors are copied out
+	return append([]ValidationError(nil), v.Errors...), nil
+}
+
+// runAsync runs the async checks, each with its own Validator, and appends their
+// errors to v in the order the checks were added. The first check to fail cancels
+// the context of the others and its error is returned.
+func (p *Pipeline[T]) runAsync(ctx context.Context, v *Validator, req *T) error {
+	switch len(p.asyncChecks) {
+	case 0:
+		return nil
+	case 1:
+		return p.asyncChecks[0](ctx, v, req)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	validators := make([]*Validator, len(p.asyncChecks))
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		failed   error
+	)
+	for i, check := range p.asyncChecks {
+		validators[i] = acquire()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := check(ctx, validators[i], req); err != nil {
+				failOnce.Do(func() {
+					failed = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, checkValidator := range validators {
+		v.Errors = append(v.Errors, checkValidator.Errors...)
+		release(checkValidator)
+	}
+
+	return failed
+}
+
+// pool holds the Validators of pipeline runs
+var pool = sync.Pool{
+	New: func() any { return New() },
+}
+
+func acquire() *Validator {
+	return pool.Get().(*Validator)
+}
+
+func release(v *Validator) {
+	clear(v.Errors)
+	v.Errors = v.Errors[:0]
+	pool.Put(v)
+}
@@ -0,0 +1,127 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type pipelineRequest struct {
+	Name       string `json:"name" validate:"required"`
+	CategoryID string `json:"category_id"`
+}
+
+func TestPipelineRun(t *testing.T) {
+	p := NewPipeline[pipelineRequest]().
+		Check(func(v *Validator, req *pipelineRequest) { v.Struct(req) }).
+		CheckAsync(func(ctx context.Context, v *Validator, req *pipelineRequest) error {
+			// Slower than the next check, yet its errors come first
+			time.Sleep(10 * time.Millisecond)
+			v.AddRuleError("category_id", "category_not_found", nil)
+			return nil
+		}).
+		CheckAsync(func(ctx context.Context, v *Validator, req *pipelineRequest) error {
+			v.AddError("name", "name is taken")
+			return nil
+		})
+
+	req, errs, err := p.Run(context.Background(), DecodeJSON[pipelineRequest](strings.NewReader(`{"category_id": "x"}`)))
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if req.CategoryID != "x" {
+		t.Errorf("category_id = %q, want x", req.CategoryID)
+	}
+
+	var codes []string
+	for _, e := range errs {
+		codes = append(codes, e.Field+" "+e.Code)
+	}
+	if want := []string{"name required", "category_id category_not_found", "name invalid"}; !slices.Equal(codes, want) {
+		t.Errorf("errors = %q, want %q", codes, want)
+	}
+}
+
+func TestPipelineRunDecodeError(t *testing.T) {
+	p := NewPipeline[pipelineRequest]()
+
+	_, _, err := p.Run(context.Background(), DecodeJSON[pipelineRequest](strings.NewReader(`{"name": 1}`)))
+	if !errors.Is(err, ErrDecode) {
+		t.Errorf("Run error = %v, want ErrDecode", err)
+	}
+}
+
+func TestPipelineCancelsAsyncChecksOnError(t *testing.T) {
+	errLookup := errors.New("lookup failed")
+	cancelled := make(chan error, 1)
+
+	p := NewPipeline[pipelineRequest]().
+		CheckAsync(func(ctx context.Context, v *Validator, req *pipelineRequest) error {
+			select {
+			case <-ctx.Done():
+				cancelled <- ctx.Err()
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+				cancelled <- nil
+				return nil
+			}
+		}).
+		CheckAsync(func(ctx context.Context, v *Validator, req *pipelineRequest) error {
+			return errLookup
+		})
+
+	_, err := p.Validate(context.Background(), &pipelineRequest{})
+	if !errors.Is(err, errLookup) {
+		t.Errorf("Validate error = %v, want the failed lookup", err)
+	}
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("the other check saw %v, want context.Canceled", err)
+	}
+}
+
+func TestPipelineStopsWhenCallerCancels(t *testing.T) {
+	check := func(ctx context.Context, v *Validator, req *pipelineRequest) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	p := NewPipeline[pipelineRequest]().CheckAsync(check).CheckAsync(check)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := p.Validate(ctx, &pipelineRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Validate error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestPipelineResetsPooledValidators(t *testing.T) {
+	p := NewPipeline[pipelineRequest]().
+		Check(func(v *Validator, req *pipelineRequest) { v.Struct(req) }).
+		CheckAsync(func(ctx context.Context, v *Validator, req *pipelineRequest) error {
+			if req.CategoryID == "" {
+				v.AddRuleError("category_id", "required", nil)
+			}
+			return nil
+		}).
+		CheckAsync(func(ctx context.Context, v *Validator, req *pipelineRequest) error { return nil })
+
+	invalid, err := p.Validate(context.Background(), &pipelineRequest{})
+	if err != nil || len(invalid) != 2 {
+		t.Fatalf("Validate = %+v, %v, want two errors", invalid, err)
+	}
+
+	for range 10 {
+		errs, err := p.Validate(context.Background(), &pipelineRequest{Name: "Kettle", CategoryID: "x"})
+		if err != nil || errs != nil {
+			t.Fatalf("Validate of a valid request = %+v, %v, want no errors", errs, err)
+		}
+	}
+
+	// Errors returned earlier are not reused by later runs
+	if invalid[0].Field != "name" || invalid[1].Field != "category_id" {
+		t.Errorf("earlier errors changed to %+v", invalid)
+	}
+}
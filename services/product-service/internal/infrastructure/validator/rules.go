@@ -0,0 +1,299 @@
+package validator
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Field is the value a rule checks
+type Field struct {
+	// Value is the value of the field, with pointers dereferenced
+	Value reflect.Value
+	// Param is the parameter of the rule, as 255 in max=255. For cross-field rules it
+	// starts with the JSON name of the other field.
+	Param string
+	// Name is the path of the field, as items[0].sku
+	Name string
+	// Parent is the struct holding the field
+	Parent reflect.Value
+	// Other is the field a cross-field rule compares with
+	Other reflect.Value
+
+	raw reflect.Value
+	arg any
+}
+
+// RuleFunc reports whether a field satisfies a rule
+type RuleFunc func(f Field) bool
+
+type rule struct {
+	fn RuleFunc
+	// params returns the params of the message of a violation; the rule parameter
+	// is passed as param if nil
+	params func(f Field) Params
+	// parse checks the parameter once, when the plan of a type is built
+	parse func(param string) (any, error)
+	// crossField rules take the Go name of another field of the struct as parameter
+	crossField bool
+}
+
+func (r *rule) paramsOf(f Field) Params {
+	if r.params != nil {
+		return r.params(f)
+	}
+	if f.Param == "" {
+		return nil
+	}
+	return Params{"param": f.Param}
+}
+
+var (
+	rulesMu sync.RWMutex
+	rules   = builtinRules()
+)
+
+// RegisterRule adds a rule usable in validate tags, its name being the code of its
+// errors. message is the template of the errors in DefaultLocale; {field} and {param}
+// are replaced with the path of the field and the parameter of the rule. Templates
+// of other locales are added with RegisterMessages. Rules must be registered before
+// the first struct using them is validated.
+func RegisterRule(name string, fn RuleFunc, message string) {
+	if name == "" || strings.ContainsAny(name, ",= .") || name == "omitempty" || name == "dive" || name == "-" {
+		panic(fmt.Sprintf("validator: invalid rule name %q", name))
+	}
+
+	rulesMu.Lock()
+	rules[name] = &rule{fn: fn}
+	rulesMu.Unlock()
+
+	RegisterMessages(DefaultLocale, map[string]string{name: message})
+}
+
+func lookupRule(name string) (*rule, bool) {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+
+	r, ok := rules[name]
+	return r, ok
+}
+
+// The messages of the built-in rules are in the catalogs of the locales directory
+func builtinRules() map[string]*rule {
+	return map[string]*rule{
+		"required": {
+			fn: func(f Field) bool { return hasValue(f.raw) },
+		},
+		"min": sizeRule(func(size, limit float64) bool { return size >= limit }),
+		"max": sizeRule(func(size, limit float64) bool { return size <= limit }),
+		"len": sizeRule(func(size, limit float64) bool { return size == limit }),
+		"gt":  sizeRule(func(size, limit float64) bool { return size > limit }),
+		"gte": sizeRule(func(size, limit float64) bool { return size >= limit }),
+		"lt":  sizeRule(func(size, limit float64) bool { return size < limit }),
+		"lte": sizeRule(func(size, limit float64) bool { return size <= limit }),
+		"oneof": {
+			fn: func(f Field) bool {
+				return f.Value.IsValid() && slices.Contains(f.arg.([]string), fmt.Sprint(f.Value.Interface()))
+			},
+			params: func(f Field) Params {
+				return Params{"values": strings.Join(f.arg.([]string), ", ")}
+			},
+			parse: func(param string) (any, error) {
+				values := strings.Fields(param)
+				if len(values) == 0 {
+					return nil, fmt.Errorf("at least one value is required")
+				}
+				return values, nil
+			},
+		},
+		"regex": {
+			fn: func(f Field) bool {
+				return f.Value.Kind() == reflect.String && f.arg.(*regexp.Regexp).MatchString(f.Value.String())
+			},
+			// The pattern means nothing to the readers of the message
+			params: func(f Field) Params { return nil },
+			parse: func(param string) (any, error) {
+				return regexp.Compile(param)
+			},
+		},
+		"uuid": stringRule(func(s string) bool {
+			_, err := uuid.Parse(s)
+			return err == nil
+		}),
+		"email": stringRule(func(s string) bool {
+			addr, err := mail.ParseAddress(s)
+			return err == nil && addr.Address == s
+		}),
+		"url": stringRule(func(s string) bool {
+			u, err := url.Parse(s)
+			return err == nil && u.Scheme != "" && u.Host != ""
+		}),
+
+		"eqfield":  equalityRule(true),
+		"nefield":  equalityRule(false),
+		"gtfield":  compareRule(func(c int) bool { return c > 0 }),
+		"gtefield": compareRule(func(c int) bool { return c >= 0 }),
+		"ltfield":  compareRule(func(c int) bool { return c < 0 }),
+		"ltefield": compareRule(func(c int) bool { return c <= 0 }),
+		"required_with": {
+			fn:         func(f Field) bool { return !hasValue(f.Other) || hasValue(f.raw) },
+			params:     otherFieldParams,
+			crossField: true,
+		},
+		"required_without": {
+			fn:         func(f Field) bool { return hasValue(f.Other) || hasValue(f.raw) },
+			params:     otherFieldParams,
+			crossField: true,
+		},
+		"required_if": {
+			fn: func(f Field) bool {
+				other := indirectValue(f.Other)
+				return !other.IsValid() || fmt.Sprint(other.Interface()) != f.arg.(string) || hasValue(f.raw)
+			},
+			params: otherFieldParams,
+			parse: func(param string) (any, error) {
+				if param == "" {
+					return nil, fmt.Errorf("a value is required")
+				}
+				return param, nil
+			},
+			crossField: true,
+		},
+	}
+}
+
+// sizeRule compares the value of numbers and the length of strings, slices, arrays
+// and maps with the parameter
+func sizeRule(ok func(size, limit float64) bool) *rule {
+	return &rule{
+		fn: func(f Field) bool {
+			size, measurable := sizeOf(f.Value)
+			return measurable && ok(size, f.arg.(float64))
+		},
+		params: func(f Field) Params {
+			params := Params{"param": f.Param}
+			if kind := kindOf(f.Value); kind != "" {
+				params["kind"] = kind
+			}
+			return params
+		},
+		parse: func(param string) (any, error) {
+			return strconv.ParseFloat(param, 64)
+		},
+	}
+}
+
+func stringRule(ok func(s string) bool) *rule {
+	return &rule{
+		fn: func(f Field) bool {
+			return f.Value.Kind() == reflect.String && ok(f.Value.String())
+		},
+	}
+}
+
+// equalityRule checks whether the field equals another field of the struct
+func equalityRule(equal bool) *rule {
+	return &rule{
+		fn: func(f Field) bool {
+			other := indirectValue(f.Other)
+			if c, ordered := compareValues(f.Value, other); ordered {
+				return (c == 0) == equal
+			}
+			return (f.Value.IsValid() && other.IsValid() && reflect.DeepEqual(f.Value.Interface(), other.Interface())) == equal
+		},
+		params:     otherFieldParams,
+		crossField: true,
+	}
+}
+
+// compareRule orders the field and another field of the struct
+func compareRule(ok func(c int) bool) *rule {
+	return &rule{
+		fn: func(f Field) bool {
+			c, comparable := compareValues(f.Value, indirectValue(f.Other))
+			return comparable && ok(c)
+		},
+		params:     otherFieldParams,
+		crossField: true,
+	}
+}
+
+// otherFieldParams passes the JSON name of the other field of a cross-field rule as
+// other and the rest of its parameter, if any, as value
+func otherFieldParams(f Field) Params {
+	other, value, _ := strings.Cut(f.Param, " ")
+	params := Params{"other": other}
+	if value != "" {
+		params["value"] = value
+	}
+	return params
+}
+
+// sizeOf returns the number a size rule compares: the value of numbers, the number
+// of characters of strings and the number of elements of collections
+func sizeOf(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	}
+	return 0, false
+}
+
+// kindOf returns the kind param of size rules: string when they count the characters
+// of v and items when they count its elements
+func kindOf(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "items"
+	}
+	return ""
+}
+
+// compareValues orders two numbers, strings or times and reports whether they can be ordered
+func compareValues(a, b reflect.Value) (int, bool) {
+	if !a.IsValid() || !b.IsValid() {
+		return 0, false
+	}
+
+	if a.Type() == timeType && b.Type() == timeType {
+		return a.Interface().(time.Time).Compare(b.Interface().(time.Time)), true
+	}
+
+	if a.Kind() == reflect.String && b.Kind() == reflect.String {
+		return strings.Compare(a.String(), b.String()), true
+	}
+
+	x, aNumber := sizeOf(a)
+	y, bNumber := sizeOf(b)
+	if !aNumber || !bNumber || kindOf(a) != "" || kindOf(b) != "" {
+		return 0, false
+	}
+
+	switch {
+	case x < y:
+		return -1, true
+	case x > y:
+		return 1, true
+	}
+	return 0, true
+}
@@ -0,0 +1,145 @@
+package validator
+
+import (
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestRules(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+	five := 5
+
+	tests := []struct {
+		name   string
+		value  any
+		code   string
+		params Params
+	}{
+		{"required string", struct {
+			F string `validate:"required"`
+		}{}, "required", nil},
+		{"required pointer to zero", struct {
+			F *int `validate:"required"`
+		}{F: new(int)}, "", nil},
+		{"min string counts characters", struct {
+			F string `validate:"min=3"`
+		}{F: "äö"}, "min", Params{"param": "3", "kind": "string"}},
+		{"max items", struct {
+			F []int `validate:"max=1"`
+		}{F: []int{1, 2}}, "max", Params{"param": "1", "kind": "items"}},
+		{"len", struct {
+			F string `validate:"len=2"`
+		}{F: "ab"}, "", nil},
+		{"gt number", struct {
+			F float64 `validate:"gt=0"`
+		}{F: 0}, "gt", Params{"param": "0"}},
+		{"lte pointer", struct {
+			F *int `validate:"lte=4"`
+		}{F: &five}, "lte", Params{"param": "4"}},
+		{"oneof", struct {
+			F string `validate:"oneof=draft published"`
+		}{F: "archived"}, "oneof", Params{"values": "draft, published"}},
+		{"oneof number", struct {
+			F int `validate:"oneof=1 2"`
+		}{F: 2}, "", nil},
+		{"regex hides the pattern", struct {
+			F string `validate:"regex=^[A-Z]{3}$"`
+		}{F: "ab1"}, "regex", nil},
+		{"uuid", struct {
+			F string `validate:"uuid"`
+		}{F: "123"}, "uuid", nil},
+		{"email with a name", struct {
+			F string `validate:"email"`
+		}{F: "Jo <jo@example.com>"}, "email", nil},
+		{"url without host", struct {
+			F string `validate:"url"`
+		}{F: "/relative"}, "url", nil},
+		{"eqfield", struct {
+			A string `json:"password"`
+			F string `validate:"eqfield=A"`
+		}{A: "x", F: "y"}, "eqfield", Params{"other": "password"}},
+		{"nefield", struct {
+			A int
+			F int `validate:"nefield=A"`
+		}{A: 1, F: 2}, "", nil},
+		{"gtfield times", struct {
+			A time.Time `json:"starts_at"`
+			F time.Time `validate:"gtfield=A"`
+		}{A: later, F: now}, "gtfield", Params{"other": "starts_at"}},
+		{"ltefield pointers", struct {
+			A *int
+			F int `validate:"ltefield=A"`
+		}{A: &five, F: 5}, "", nil},
+		{"gtfield across kinds", struct {
+			A string
+			F int `validate:"gtfield=A"`
+		}{A: "1", F: 2}, "gtfield", Params{"other": "A"}},
+		{"required_with", struct {
+			A string `json:"unit"`
+			F int    `validate:"required_with=A"`
+		}{A: "cm"}, "required_with", Params{"other": "unit"}},
+		{"required_without", struct {
+			A string
+			F string `validate:"required_without=A"`
+		}{A: "set"}, "", nil},
+		{"required_if", struct {
+			A string `json:"type"`
+			F []int  `validate:"required_if=A bundle"`
+		}{A: "bundle"}, "required_if", Params{"other": "type", "value": "bundle"}},
+		{"required_if other value", struct {
+			A string
+			F []int `validate:"required_if=A bundle"`
+		}{A: "simple"}, "", nil},
+		{"omitempty skips empty", struct {
+			F string `validate:"omitempty,email"`
+		}{}, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New()
+			v.Struct(tt.value)
+
+			if tt.code == "" {
+				if !v.Valid() {
+					t.Fatalf("errors = %+v, want none", v.Errors)
+				}
+				return
+			}
+			if len(v.Errors) != 1 {
+				t.Fatalf("errors = %+v, want one %s", v.Errors, tt.code)
+			}
+			if e := v.Errors[0]; e.Code != tt.code || !maps.Equal(e.Params, tt.params) {
+				t.Errorf("error = %s %v, want %s %v", e.Code, e.Params, tt.code, tt.params)
+			}
+		})
+	}
+}
+
+func TestRegisterRule(t *testing.T) {
+	RegisterRule("even", func(f Field) bool { return f.Value.Int()%2 == 0 }, "{field} must be even")
+
+	v := New()
+	v.Struct(struct {
+		Count int `json:"count" validate:"even"`
+	}{Count: 3})
+
+	if len(v.Errors) != 1 || v.Errors[0].Code != "even" || v.Errors[0].Message != "count must be even" {
+		t.Errorf("errors = %+v, want count must be even", v.Errors)
+	}
+}
+
+func TestRegisterRuleRejectsReservedNames(t *testing.T) {
+	for _, name := range []string{"", "dive", "omitempty", "-", "a,b", "a=b", "a.b"} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterRule(%q) did not panic", name)
+				}
+			}()
+			RegisterRule(name, func(f Field) bool { return true }, "")
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tag is the struct tag holding the rules of a field
+const Tag = "validate"
+
+// Struct validates s, a struct or a pointer to one, against the rules in the validate
+// tags of its fields and adds an error for each violation.
+//
+// Rules are separated by commas, as `validate:"required,max=255"`; a comma inside
+// brackets, as in regex=^[A-Z]{1,3}$, or escaped with a backslash belongs to the
+// parameter. omitempty skips the remaining rules of an empty field and dive applies
+// the rules after it to each element of a slice, array or map. Nested structs, and
+// structs in slices, arrays and maps, are validated too unless their field is tagged
+// validate:"-". Errors name fields by their JSON names.
+//
+// A malformed tag is a programming error and panics the first time its type is validated.
+func (v *Validator) Struct(s any) {
+	val := reflect.ValueOf(s)
+	for val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return
+		}
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validator: Struct expects a struct, got %T", s))
+	}
+
+	v.validateStruct(val, fieldPath{})
+}
+
+// fieldPath locates a field both as a Field and a Pointer of ValidationError
+type fieldPath struct {
+	field   string
+	pointer string
+}
+
+func (p fieldPath) child(name string) fieldPath {
+	field := name
+	if p.field != "" {
+		field = p.field + "." + name
+	}
+	return fieldPath{field: field, pointer: p.pointer + "/" + escapePointer(name)}
+}
+
+func (p fieldPath) index(key string) fieldPath {
+	return fieldPath{field: p.field + "[" + key + "]", pointer: p.pointer + "/" + escapePointer(key)}
+}
+
+// escapePointer escapes a reference token of a JSON pointer (RFC 6901 section 3)
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// structPlan holds the parsed rules of a struct type
+type structPlan struct {
+	fields []fieldPlan
+}
+
+type fieldPlan struct {
+	index []int
+	name  string
+	// skip disables the rules and nested validation of the field
+	skip      bool
+	omitEmpty bool
+	checks    []check
+	// dive holds the rules of the elements when the field is tagged with dive
+	dive          bool
+	diveOmitEmpty bool
+	diveChecks    []check
+}
+
+// check is a rule applied with its parameter
+type check struct {
+	name  string
+	param string
+	rule  *rule
+	arg   any
+	// other is the index of the field a cross-field rule compares with
+	other []int
+}
+
+// plans caches the plan of each validated struct type
+var plans sync.Map
+
+func planFor(t reflect.Type) *structPlan {
+	if plan, ok := plans.Load(t); ok {
+		return plan.(*structPlan)
+	}
+
+	plan, err := buildPlan(t)
+	if err != nil {
+		panic(fmt.Sprintf("validator: %s: %v", t, err))
+	}
+
+	actual, _ := plans.LoadOrStore(t, plan)
+	return actual.(*structPlan)
+}
+
+func buildPlan(t reflect.Type) (*structPlan, error) {
+	plan := &structPlan{}
+
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || isEmbeddedStruct(sf) {
+			continue
+		}
+
+		fp, err := buildFieldPlan(t, sf)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", sf.Name, err)
+		}
+		plan.fields = append(plan.fields, fp)
+	}
+
+	return plan, nil
+}
+
+// isEmbeddedStruct reports whether sf is an embedded struct whose fields are
+// promoted into the JSON object of its parent
+func isEmbeddedStruct(sf reflect.StructField) bool {
+	if !sf.Anonymous || jsonName(sf) != sf.Name {
+		return false
+	}
+	t := sf.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func buildFieldPlan(parent reflect.Type, sf reflect.StructField) (fieldPlan, error) {
+	fp := fieldPlan{index: sf.Index, name: jsonName(sf)}
+
+	tag := sf.Tag.Get(Tag)
+	if tag == "-" {
+		fp.skip = true
+		return fp, nil
+	}
+
+	for _, item := range splitTag(tag) {
+		name, param, _ := strings.Cut(item, "=")
+
+		switch name {
+		case "omitempty":
+			if fp.dive {
+				fp.diveOmitEmpty = true
+			} else {
+				fp.omitEmpty = true
+			}
+			continue
+		case "dive":
+			if fp.dive {
+				return fp, fmt.Errorf("dive may only be used once")
+			}
+			if k := indirect(sf.Type).Kind(); k != reflect.Slice && k != reflect.Array && k != reflect.Map {
+				return fp, fmt.Errorf("dive requires a slice, array or map")
+			}
+			fp.dive = true
+			continue
+		}
+
+		c, err := newCheck(parent, name, param)
+		if err != nil {
+			return fp, err
+		}
+
+		if fp.dive {
+			fp.diveChecks = append(fp.diveChecks, c)
+		} else {
+			fp.checks = append(fp.checks, c)
+		}
+	}
+
+	return fp, nil
+}
+
+func newCheck(parent reflect.Type, name, param string) (check, error) {
+	r, ok := lookupRule(name)
+	if !ok {
+		return check{}, fmt.Errorf("unknown rule %q", name)
+	}
+
+	c := check{name: name, param: param, rule: r}
+
+	if r.crossField {
+		otherName, rest, _ := strings.Cut(param, " ")
+		other, ok := parent.FieldByName(otherName)
+		if !ok {
+			return c, fmt.Errorf("rule %s: no field %q", name, otherName)
+		}
+		c.other = other.Index
+		// Messages name the other field as clients know it
+		c.param = strings.TrimSpace(jsonName(other) + " " + rest)
+		param = rest
+	}
+
+	if r.parse != nil {
+		arg, err := r.parse(param)
+		if err != nil {
+			return c, fmt.Errorf("rule %s: %w", name, err)
+		}
+		c.arg = arg
+	}
+
+	return c, nil
+}
+
+// splitTag splits a tag at the commas outside brackets and not escaped with a backslash
+func splitTag(tag string) []string {
+	var (
+		items   []string
+		current strings.Builder
+		depth   int
+	)
+
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+		switch {
+		case c == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			current.WriteByte(',')
+			i++
+			continue
+		case c == '[' || c == '(' || c == '{':
+			depth++
+		case (c == ']' || c == ')' || c == '}') && depth > 0:
+			depth--
+		case c == ',' && depth == 0:
+			if current.Len() > 0 {
+				items = append(items, current.String())
+			}
+			current.Reset()
+			continue
+		}
+		current.WriteByte(c)
+	}
+
+	if current.Len() > 0 {
+		items = append(items, current.String())
+	}
+
+	return items
+}
+
+// jsonName returns the name of the field in JSON documents
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
+
+func (v *Validator) validateStruct(val reflect.Value, path fieldPath) {
+	for _, fp := range planFor(val.Type()).fields {
+		if fp.skip {
+			continue
+		}
+
+		field, err := val.FieldByIndexErr(fp.index)
+		if err != nil {
+			// The field belongs to a nil embedded struct
+			continue
+		}
+
+		fieldPath := path.child(fp.name)
+		if v.applyChecks(fp.checks, fp.omitEmpty, field, val, fieldPath) {
+			v.validateElements(fp, field, val, fieldPath)
+		}
+	}
+}
+
+// applyChecks adds an error for the first check field fails and reports whether it passed
+func (v *Validator) applyChecks(checks []check, omitEmpty bool, field, parent reflect.Value, path fieldPath) bool {
+	if omitEmpty && !hasValue(field) {
+		return false
+	}
+
+	for _, c := range checks {
+		f := Field{
+			Value:  indirectValue(field),
+			Param:  c.param,
+			Name:   path.field,
+			Parent: parent,
+			raw:    field,
+			arg:    c.arg,
+		}
+		if c.other != nil {
+			f.Other, _ = parent.FieldByIndexErr(c.other)
+		}
+
+		if !c.rule.fn(f) {
+			v.addRuleError(path.field, path.pointer, c.name, c.rule.paramsOf(f))
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateElements validates the nested structs of a valid field and, for dive,
+// the rules of its elements
+func (v *Validator) validateElements(fp fieldPlan, field, parent reflect.Value, path fieldPath) {
+	if !hasValue(field) && field.Kind() == reflect.Pointer {
+		return
+	}
+	field = indirectValue(field)
+
+	switch field.Kind() {
+	case reflect.Struct:
+		if field.Type() != timeType {
+			v.validateStruct(field, path)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < field.Len(); i++ {
+			v.validateElement(fp, field.Index(i), parent, path.index(strconv.Itoa(i)))
+		}
+	case reflect.Map:
+		iter := field.MapRange()
+		for iter.Next() {
+			v.validateElement(fp, iter.Value(), parent, path.index(fmt.Sprint(iter.Key().Interface())))
+		}
+	}
+}
+
+func (v *Validator) validateElement(fp fieldPlan, elem, parent reflect.Value, path fieldPath) {
+	if fp.dive && !v.applyChecks(fp.diveChecks, fp.diveOmitEmpty, elem, parent, path) {
+		return
+	}
+
+	if elem.Kind() == reflect.Pointer && elem.IsNil() {
+		return
+	}
+	if elem = indirectValue(elem); elem.Kind() == reflect.Struct && elem.Type() != timeType {
+		v.validateStruct(elem, path)
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// indirect returns the type pointers of t point to
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// indirectValue dereferences pointers and interfaces, returning the zero value of
+// the pointed to type for nil pointers
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if v.Kind() == reflect.Pointer {
+				return reflect.Zero(indirect(v.Type()))
+			}
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// hasValue reports whether v was provided: a non-nil pointer, a non-empty slice,
+// map or string, or another non-zero value
+func hasValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return false
+	case reflect.Pointer, reflect.Interface:
+		return !v.IsNil()
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return v.Len() > 0
+	default:
+		return !v.IsZero()
+	}
+}
@@ -0,0 +1,186 @@
+package validator
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// violations returns the pointers and codes of the errors of v
+func violations(v *Validator) []string {
+	var got []string
+	for _, e := range v.Errors {
+		got = append(got, e.Pointer+" "+e.Code)
+	}
+	return got
+}
+
+func TestSplitTag(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want []string
+	}{
+		{"", nil},
+		{"required", []string{"required"}},
+		{"required,max=255", []string{"required", "max=255"}},
+		{`regex=^[A-Z]{1,3}$,max=3`, []string{`regex=^[A-Z]{1,3}$`, "max=3"}},
+		{`regex=^(a,b)$`, []string{`regex=^(a,b)$`}},
+		{`oneof=a\,b c`, []string{"oneof=a,b c"}},
+		{"required,,max=1,", []string{"required", "max=1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			if got := splitTag(tt.tag); !slices.Equal(got, tt.want) {
+				t.Errorf("splitTag(%q) = %q, want %q", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPlanRejectsMalformedTags(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  any
+		want string
+	}{
+		{"unknown rule", struct {
+			Name string `validate:"required,shiny"`
+		}{}, `unknown rule "shiny"`},
+		{"dive on a string", struct {
+			Name string `validate:"dive,required"`
+		}{}, "dive requires a slice, array or map"},
+		{"dive twice", struct {
+			Tags [][]string `validate:"dive,dive"`
+		}{}, "dive may only be used once"},
+		{"size parameter", struct {
+			Name string `validate:"max=many"`
+		}{}, "rule max"},
+		{"empty oneof", struct {
+			Status string `validate:"oneof="`
+		}{}, "at least one value is required"},
+		{"invalid regex", struct {
+			SKU string `validate:"regex=^[A-Z"`
+		}{}, "rule regex"},
+		{"missing other field", struct {
+			End int `validate:"gtfield=Start"`
+		}{}, `no field "Start"`},
+		{"required_if without value", struct {
+			Kind string
+			Size int `validate:"required_if=Kind"`
+		}{}, "a value is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := buildPlan(reflect.TypeOf(tt.typ))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("buildPlan error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructPanicsOnMalformedTag(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Struct did not panic")
+		}
+	}()
+
+	New().Struct(struct {
+		Name string `validate:"shiny"`
+	}{})
+}
+
+func TestStructNestedFields(t *testing.T) {
+	type dimension struct {
+		Unit  string  `json:"unit" validate:"oneof=cm in"`
+		Value float64 `json:"value" validate:"gt=0"`
+	}
+	type item struct {
+		SKU      string `json:"sku" validate:"required"`
+		Quantity int    `json:"quantity" validate:"min=1"`
+	}
+	type request struct {
+		Name   string            `json:"name" validate:"required"`
+		Width  dimension         `json:"width"`
+		Height *dimension        `json:"height"`
+		Items  []item            `json:"items" validate:"min=1"`
+		Tags   []string          `json:"tags" validate:"dive,required,max=3"`
+		Labels map[string]string `json:"labels" validate:"dive,max=2"`
+		Notes  *item             `json:"notes" validate:"-"`
+		Empty  []string          `json:"empty" validate:"omitempty,min=2"`
+	}
+
+	v := New()
+	v.Struct(&request{
+		Width:  dimension{Unit: "mm", Value: 1},
+		Height: &dimension{Unit: "cm"},
+		Items:  []item{{SKU: "A", Quantity: 1}, {Quantity: 0}},
+		Tags:   []string{"new", "", "sale"},
+		Labels: map[string]string{"a/b": "abc"},
+		Notes:  &item{},
+	})
+
+	want := []string{
+		"/name required",
+		"/width/unit oneof",
+		"/height/value gt",
+		"/items/1/sku required",
+		"/items/1/quantity min",
+		"/tags/1 required",
+		"/tags/2 max",
+		"/labels/a~1b max",
+	}
+	if got := violations(v); !slices.Equal(got, want) {
+		t.Errorf("errors = %q, want %q", got, want)
+	}
+	if v.Errors[3].Field != "items[1].sku" {
+		t.Errorf("field = %q, want items[1].sku", v.Errors[3].Field)
+	}
+}
+
+func TestStructSkipsNilNestedStructs(t *testing.T) {
+	type inner struct {
+		Name string `json:"name" validate:"required"`
+	}
+	type request struct {
+		Inner *inner   `json:"inner"`
+		List  []*inner `json:"list"`
+	}
+
+	v := New()
+	v.Struct(&request{List: []*inner{nil, {}}})
+
+	if got, want := violations(v), []string{"/list/1/name required"}; !slices.Equal(got, want) {
+		t.Errorf("errors = %q, want %q", got, want)
+	}
+}
+
+func TestPlanCacheKeepsTypesApart(t *testing.T) {
+	type first struct {
+		Name string `json:"name" validate:"required"`
+	}
+	type second struct {
+		Name string `json:"name" validate:"max=1"`
+	}
+
+	for range 2 {
+		v := New()
+		v.Struct(first{})
+		v.Struct(second{Name: "too long"})
+
+		if got, want := violations(v), []string{"/name required", "/name max"}; !slices.Equal(got, want) {
+			t.Errorf("errors = %q, want %q", got, want)
+		}
+	}
+
+	if planFor(reflect.TypeOf(first{})) != planFor(reflect.TypeOf(first{})) {
+		t.Error("the plan of a type was built twice")
+	}
+	if planFor(reflect.TypeOf(first{})) == planFor(reflect.TypeOf(second{})) {
+		t.Error("two types share a plan")
+	}
+}
@@ -1,17 +1,31 @@
 package validator
 
 import (
-	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/google/uuid"
 )
 
 type ValidationError struct {
-	Field   string `json:"field"`
+	// Field is the path of the invalid field, as items[0].sku
+	Field string `json:"field"`
+	// Pointer is the JSON pointer (RFC 6901) of the invalid field, as /items/0/sku
+	Pointer string `json:"pointer"`
+	// Code identifies the violated rule, as required or max, so clients can render
+	// their own messages
+	Code    string `json:"code"`
 	Message string `json:"message"`
+	// Params holds the values interpolated in the message, as param=255 for max=255
+	Params Params `json:"params,omitempty"`
 }
 
+// Params are the values of a message template, each replacing its {name} placeholder
+type Params map[string]string
+
+// CodeInvalid is the code of errors added with a free-form message
+const CodeInvalid = "invalid"
+
 type Validator struct {
 	Errors []ValidationError
 }
@@ -26,8 +40,9 @@ func (v *Validator) Valid() bool {
 	return len(v.Errors) == 0
 }
 
+// AddError adds an error with a free-form message, which is not localized
 func (v *Validator) AddError(field, message string) {
-	v.Errors = append(v.Errors, ValidationError{Field: field, Message: message})
+	v.Errors = append(v.Errors, ValidationError{Field: field, Pointer: "/" + escapePointer(field), Code: CodeInvalid, Message: message})
 }
 
 func (v *Validator) Check(condition bool, field, message string) {
@@ -36,32 +51,55 @@ func (v *Validator) Check(condition bool, field, message string) {
 	}
 }
 
+// AddRuleError adds an error whose message is the template of code in the message
+// catalogs, interpolated with params
+func (v *Validator) AddRuleError(field, code string, params Params) {
+	v.addRuleError(field, "/"+escapePointer(field), code, params)
+}
+
+// CheckRule adds the error of code if condition is false
+func (v *Validator) CheckRule(condition bool, field, code string, params Params) {
+	if !condition {
+		v.AddRuleError(field, code, params)
+	}
+}
+
+func (v *Validator) addRuleError(field, pointer, code string, params Params) {
+	v.Errors = append(v.Errors, ValidationError{
+		Field:   field,
+		Pointer: pointer,
+		Code:    code,
+		Message: message(DefaultLocale, field, code, params),
+		Params:  params,
+	})
+}
+
 func (v *Validator) Required(field, value string, customMsg ...string) {
 	if value == "" {
-		msg := field + " is required"
 		if len(customMsg) > 0 {
-			msg = customMsg[0]
+			v.AddError(field, customMsg[0])
+			return
 		}
-		v.AddError(field, msg)
+		v.AddRuleError(field, "required", nil)
 	}
 }
 
 func (v *Validator) MinValue(field string, value, min float64) {
 	if value < min {
-		v.AddError(field, fmt.Sprintf("%s must be greater than %f", field, min))
+		v.AddRuleError(field, "gte", Params{"param": strconv.FormatFloat(min, 'f', -1, 64)})
 	}
 }
 
 func (v *Validator) ValidUUID(field string, value string) (uuid.UUID, bool) {
 	if value == "" {
-		v.AddError(field, field+" is required")
+		v.AddRuleError(field, "required", nil)
 		return uuid.Nil, false
 	}
 
 	id, err := uuid.Parse(value)
 
 	if err != nil {
-		v.AddError(field, field+" is not a valid uuid")
+		v.AddRuleError(field, "uuid", nil)
 		return uuid.Nil, false
 	}
 
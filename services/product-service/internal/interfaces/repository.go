@@ -3,18 +3,101 @@ package interfaces
 import (
 	"context"
 	"microservice/services/product-service/internal/domain"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type ProductRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Product, error)
-	GetAll(ctx context.Context, limit, offset int) ([]*domain.Product, int, error)
+	// ResolveSlug returns the ID of the product having or having had slug
+	ResolveSlug(ctx context.Context, slug string) (uuid.UUID, error)
+	GetAll(ctx context.Context, filter domain.ProductFilter, limit, offset int) ([]*domain.Product, int, error)
 	Create(ctx context.Context, product *domain.Product) error
-	Update(ctx context.Context, product *domain.Product) error
+	// Update loads the product, locked, applies change to it and saves it, all in one
+	// transaction, and returns the saved product. The events change raises are
+	// recorded with it; an error from change aborts the update.
+	Update(ctx context.Context, id uuid.UUID, change func(*domain.Product) error) (*domain.Product, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 
-	Search(ctx context.Context, query string) ([]*domain.Product, error)
-	GetByCategory(ctx context.Context, categoryID uuid.UUID) ([]*domain.Product, error)
+	// Search also matches the translations of the products in locales
+	Search(ctx context.Context, query string, locales []string, filter domain.ProductFilter) ([]*domain.Product, error)
+	GetByCategory(ctx context.Context, categoryID uuid.UUID, filter domain.ProductFilter) ([]*domain.Product, error)
+	GetByCategoryIDs(ctx context.Context, categoryIDs []uuid.UUID, filter domain.ProductFilter) ([]*domain.Product, error)
 	CategoryExists(ctx context.Context, categoryID uuid.UUID) (bool, error)
 }
+
+type TranslationRepository interface {
+	ListByProduct(ctx context.Context, productID uuid.UUID) ([]*domain.ProductTranslation, error)
+	Get(ctx context.Context, productID uuid.UUID, locale string) (*domain.ProductTranslation, error)
+	// Save creates or replaces a translation and reports whether it was created
+	Save(ctx context.Context, translation *domain.ProductTranslation) (bool, error)
+	Delete(ctx context.Context, productID uuid.UUID, locale string) error
+	// FindBest returns the translation of each product in the first of locales it has one in
+	FindBest(ctx context.Context, productIDs []uuid.UUID, locales []string) (map[uuid.UUID]*domain.ProductTranslation, error)
+}
+
+type RelationRepository interface {
+	// List returns the relations of a product of a type, or of any type if empty, in
+	// list order with their related product if it matches filter
+	List(ctx context.Context, productID uuid.UUID, relType domain.RelationType, filter domain.ProductFilter) ([]*domain.ProductRelation, error)
+	Get(ctx context.Context, productID uuid.UUID, relType domain.RelationType, relatedID uuid.UUID) (*domain.ProductRelation, error)
+	Create(ctx context.Context, relation *domain.ProductRelation) error
+	Update(ctx context.Context, relation *domain.ProductRelation) error
+	Delete(ctx context.Context, productID uuid.UUID, relType domain.RelationType, relatedID uuid.UUID) error
+}
+
+type BundleRepository interface {
+	Get(ctx context.Context, productID uuid.UUID) (*domain.Bundle, error)
+	// FindByProducts returns the bundles of the products having one, by product
+	FindByProducts(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID]*domain.Bundle, error)
+	// Save creates or replaces the bundle of its product
+	Save(ctx context.Context, bundle *domain.Bundle) error
+}
+
+type CategoryRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Category, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Category, error)
+	GetAll(ctx context.Context, limit, offset int) ([]*domain.Category, int, error)
+}
+
+type AuditRepository interface {
+	ListByProduct(ctx context.Context, productID uuid.UUID, limit, offset int) ([]*domain.AuditEntry, int, error)
+	ListUntil(ctx context.Context, productID uuid.UUID, asOf time.Time) ([]*domain.AuditEntry, error)
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *domain.APIKey) error
+	GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+	List(ctx context.Context, limit, offset int) ([]*domain.APIKey, int, error)
+	Revoke(ctx context.Context, id uuid.UUID) (*domain.APIKey, error)
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+type PromotionRepository interface {
+	List(ctx context.Context, limit, offset int) ([]*domain.Promotion, int, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Promotion, error)
+	// FindRunning returns the enabled promotions running at the given time that apply
+	// automatically or have one of coupons as code
+	FindRunning(ctx context.Context, coupons []string, at time.Time) ([]*domain.Promotion, error)
+	Create(ctx context.Context, promotion *domain.Promotion) error
+	Update(ctx context.Context, promotion *domain.Promotion) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Redeem counts a use of each of the promotions, failing with
+	// domain.ErrCouponExhausted if one of them reached its usage limit
+	Redeem(ctx context.Context, ids []uuid.UUID) error
+}
+
+type TaxRepository interface {
+	ListRates(ctx context.Context) ([]domain.TaxRate, error)
+	// FindRates returns the rates of a country and its regions
+	FindRates(ctx context.Context, country string) ([]domain.TaxRate, error)
+	// ReplaceRates replaces the whole rate table
+	ReplaceRates(ctx context.Context, rates []domain.TaxRate) error
+	// TaxClasses returns the tax classes assigned to the products and the categories
+	// having one, by ID
+	TaxClasses(ctx context.Context, productIDs, categoryIDs []uuid.UUID) (map[uuid.UUID]string, map[uuid.UUID]string, error)
+	// SetProductTaxClass and SetCategoryTaxClass remove the assignment if class is empty
+	SetProductTaxClass(ctx context.Context, productID uuid.UUID, class string) error
+	SetCategoryTaxClass(ctx context.Context, categoryID uuid.UUID, class string) error
+}
@@ -3,12 +3,16 @@ package interfaces
 import (
 	"context"
 	"microservice/services/product-service/internal/domain"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type Service interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Product, error)
+	// GetBySlug returns the product having or having had slug; compare its Slug to
+	// tell whether slug is still current
+	GetBySlug(ctx context.Context, slug string) (*domain.Product, error)
 	GetAll(ctx context.Context, limit, offset int) ([]*domain.Product, int, error)
 	Create(ctx context.Context, product *domain.Product) error
 	Update(ctx context.Context, product *domain.Product) error
@@ -16,5 +20,69 @@ type Service interface {
 
 	Search(ctx context.Context, query string) ([]*domain.Product, error)
 	GetByCategory(ctx context.Context, categoryID uuid.UUID) ([]*domain.Product, error)
+	GetByCategoryIDs(ctx context.Context, categoryIDs []uuid.UUID) ([]*domain.Product, error)
 	CategoryExists(ctx context.Context, categoryID uuid.UUID) (bool, error)
+
+	GetHistory(ctx context.Context, id uuid.UUID, limit, offset int) ([]*domain.AuditEntry, int, error)
+	GetAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (*domain.Product, error)
+}
+
+type CategoryService interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Category, error)
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*domain.Category, error)
+	GetAll(ctx context.Context, limit, offset int) ([]*domain.Category, int, error)
+}
+
+type TranslationService interface {
+	List(ctx context.Context, productID uuid.UUID) ([]*domain.ProductTranslation, error)
+	Get(ctx context.Context, productID uuid.UUID, locale string) (*domain.ProductTranslation, error)
+	// Save creates or replaces a translation and reports whether it was created
+	Save(ctx context.Context, translation *domain.ProductTranslation) (bool, error)
+	Delete(ctx context.Context, productID uuid.UUID, locale string) error
+}
+
+type RelationService interface {
+	List(ctx context.Context, productID uuid.UUID, relType domain.RelationType) ([]*domain.ProductRelation, error)
+	Get(ctx context.Context, productID uuid.UUID, relType domain.RelationType, relatedID uuid.UUID) (*domain.ProductRelation, error)
+	Create(ctx context.Context, relation *domain.ProductRelation) error
+	Update(ctx context.Context, relation *domain.ProductRelation) error
+	Delete(ctx context.Context, productID uuid.UUID, relType domain.RelationType, relatedID uuid.UUID) error
+}
+
+type BundleService interface {
+	Get(ctx context.Context, productID uuid.UUID) (*domain.Bundle, error)
+	// Save creates or replaces the bundle of a bundle product
+	Save(ctx context.Context, bundle *domain.Bundle) error
+}
+
+type APIKeyService interface {
+	// Issue creates a key and returns it with its secret, which cannot be retrieved again
+	Issue(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*domain.APIKey, string, error)
+	List(ctx context.Context, limit, offset int) ([]*domain.APIKey, int, error)
+	Revoke(ctx context.Context, id uuid.UUID) (*domain.APIKey, error)
+}
+
+type PromotionService interface {
+	List(ctx context.Context, limit, offset int) ([]*domain.Promotion, int, error)
+	Get(ctx context.Context, id uuid.UUID) (*domain.Promotion, error)
+	Create(ctx context.Context, promotion *domain.Promotion) error
+	Update(ctx context.Context, promotion *domain.Promotion) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// Evaluate prices the items at their catalog price and applies the running
+	// promotions, redeeming the applied ones if redeem is set
+	Evaluate(ctx context.Context, items []domain.CartItem, coupons []string, redeem bool) (*domain.Quote, error)
+}
+
+type TaxService interface {
+	ListRates(ctx context.Context) ([]domain.TaxRate, error)
+	// ReplaceRates replaces the whole rate table, as loaded from a CSV file
+	ReplaceRates(ctx context.Context, rates []domain.TaxRate) error
+	// ProductTaxClass returns the tax class of a product and where it comes from
+	ProductTaxClass(ctx context.Context, productID uuid.UUID) (string, domain.TaxClassSource, error)
+	// SetProductTaxClass and SetCategoryTaxClass remove the assignment if class is empty
+	SetProductTaxClass(ctx context.Context, productID uuid.UUID, class string) error
+	SetCategoryTaxClass(ctx context.Context, categoryID uuid.UUID, class string) error
+	// Calculate taxes the items at the current price of their product. Empty display
+	// and rounding modes and a nil date take the defaults.
+	Calculate(ctx context.Context, items []domain.CartItem, destination domain.TaxDestination, at *time.Time, display domain.PriceDisplay, rounding domain.TaxRounding) (*domain.TaxCalculation, error)
 }
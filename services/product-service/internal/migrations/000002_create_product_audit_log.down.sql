@@ -0,0 +1,9 @@
+-- Drop trigger and function
+DROP TRIGGER IF EXISTS product_audit_log_immutable ON product_audit_log;
+DROP FUNCTION IF EXISTS prevent_product_audit_log_change();
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_product_audit_log_product_id_created_at;
+
+-- Drop tables
+DROP TABLE IF EXISTS product_audit_log;
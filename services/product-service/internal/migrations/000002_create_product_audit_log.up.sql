@@ -0,0 +1,24 @@
+-- Create product audit log table
+CREATE TABLE IF NOT EXISTS product_audit_log (
+    id UUID PRIMARY KEY,
+    product_id UUID NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    actor VARCHAR(255) NOT NULL,
+    request_id VARCHAR(255),
+    changes JSONB NOT NULL DEFAULT '[]'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_product_audit_log_product_id_created_at ON product_audit_log(product_id, created_at);
+
+-- Audit entries are immutable
+CREATE OR REPLACE FUNCTION prevent_product_audit_log_change() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'product_audit_log entries are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER product_audit_log_immutable
+    BEFORE UPDATE OR DELETE ON product_audit_log
+    FOR EACH ROW EXECUTE FUNCTION prevent_product_audit_log_change();
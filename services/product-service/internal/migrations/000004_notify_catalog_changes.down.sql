@@ -0,0 +1,4 @@
+-- Drop triggers and function
+DROP TRIGGER IF EXISTS categories_notify_change ON categories;
+DROP TRIGGER IF EXISTS products_notify_change ON products;
+DROP FUNCTION IF EXISTS notify_catalog_change();
@@ -0,0 +1,6 @@
+-- Publication state of products; existing products stay visible
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'published', 'archived'));
+
+CREATE INDEX IF NOT EXISTS idx_products_status ON products(status);
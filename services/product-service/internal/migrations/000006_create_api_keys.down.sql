@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_api_keys_created_at;
+
+-- Drop tables
+DROP TABLE IF EXISTS api_keys;
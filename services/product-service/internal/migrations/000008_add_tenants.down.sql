@@ -0,0 +1,50 @@
+-- Restore the notifications without tenant
+CREATE OR REPLACE FUNCTION notify_catalog_change() RETURNS TRIGGER AS $$
+DECLARE
+    row_id UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        row_id := OLD.id;
+    ELSE
+        row_id := NEW.id;
+    END IF;
+
+    PERFORM pg_notify('catalog_changes', json_build_object(
+        'table', TG_TABLE_NAME,
+        'operation', TG_OP,
+        'id', row_id
+    )::text);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Drop policies
+DROP POLICY IF EXISTS tenant_isolation ON product_audit_log;
+DROP POLICY IF EXISTS tenant_isolation ON products;
+DROP POLICY IF EXISTS tenant_isolation ON categories;
+
+ALTER TABLE product_audit_log DISABLE ROW LEVEL SECURITY;
+ALTER TABLE products DISABLE ROW LEVEL SECURITY;
+ALTER TABLE categories DISABLE ROW LEVEL SECURITY;
+
+REVOKE ALL ON categories, products, product_audit_log, outbox FROM catalog_tenant;
+REVOKE ALL ON SEQUENCE outbox_id_seq FROM catalog_tenant;
+REVOKE ALL ON SCHEMA public FROM catalog_tenant;
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_api_keys_tenant_id_created_at;
+DROP INDEX IF EXISTS idx_product_audit_log_tenant_id_product_id;
+
+-- Restore the global constraints; fails if tenants share SKUs
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_tenant_id_category_id_fkey;
+ALTER TABLE products ADD CONSTRAINT products_category_id_fkey FOREIGN KEY (category_id) REFERENCES categories(id);
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_tenant_id_sku_key;
+ALTER TABLE products ADD CONSTRAINT products_sku_key UNIQUE (sku);
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_tenant_id_id_key;
+
+-- Drop columns
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE product_audit_log DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE products DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE categories DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,79 @@
+-- Every catalog row belongs to a tenant; existing rows go to the default tenant
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE product_audit_log ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+
+-- New rows must name their tenant
+ALTER TABLE categories ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE products ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE product_audit_log ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE api_keys ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- SKUs are unique within a tenant and products may only use categories of their tenant
+ALTER TABLE categories ADD CONSTRAINT categories_tenant_id_id_key UNIQUE (tenant_id, id);
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_sku_key;
+ALTER TABLE products ADD CONSTRAINT products_tenant_id_sku_key UNIQUE (tenant_id, sku);
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_category_id_fkey;
+ALTER TABLE products ADD CONSTRAINT products_tenant_id_category_id_fkey
+    FOREIGN KEY (tenant_id, category_id) REFERENCES categories(tenant_id, id);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_product_audit_log_tenant_id_product_id ON product_audit_log(tenant_id, product_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id_created_at ON api_keys(tenant_id, created_at);
+
+-- The service queries catalog data as catalog_tenant, which cannot bypass row level
+-- security, with the tenant of the request in the app.tenant_id setting
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'catalog_tenant') THEN
+        CREATE ROLE catalog_tenant NOLOGIN;
+    END IF;
+END
+$$;
+
+GRANT catalog_tenant TO CURRENT_USER;
+GRANT USAGE ON SCHEMA public TO catalog_tenant;
+GRANT SELECT, INSERT, UPDATE, DELETE ON categories, products TO catalog_tenant;
+GRANT SELECT, INSERT ON product_audit_log TO catalog_tenant;
+GRANT INSERT ON outbox TO catalog_tenant;
+GRANT USAGE ON SEQUENCE outbox_id_seq TO catalog_tenant;
+
+ALTER TABLE categories ENABLE ROW LEVEL SECURITY;
+ALTER TABLE products ENABLE ROW LEVEL SECURITY;
+ALTER TABLE product_audit_log ENABLE ROW LEVEL SECURITY;
+
+-- Without app.tenant_id set, current_setting returns NULL and no row matches
+CREATE POLICY tenant_isolation ON categories
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+CREATE POLICY tenant_isolation ON products
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+CREATE POLICY tenant_isolation ON product_audit_log
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- Change notifications carry the tenant so listeners can address tenant scoped caches
+CREATE OR REPLACE FUNCTION notify_catalog_change() RETURNS TRIGGER AS $$
+DECLARE
+    changed RECORD;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+    ELSE
+        changed := NEW;
+    END IF;
+
+    PERFORM pg_notify('catalog_changes', json_build_object(
+        'table', TG_TABLE_NAME,
+        'operation', TG_OP,
+        'id', changed.id,
+        'tenant_id', changed.tenant_id
+    )::text);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,12 @@
+-- Drop policies
+DROP POLICY IF EXISTS tenant_isolation ON product_translations;
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_product_translations_search_vector;
+
+-- Drop tables
+DROP TABLE IF EXISTS product_translations;
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_tenant_id_id_key;
+
+DROP FUNCTION IF EXISTS product_search_config(TEXT);
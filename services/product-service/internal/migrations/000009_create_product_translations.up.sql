@@ -0,0 +1,58 @@
+-- Text search configuration of a locale, by its language subtag; languages
+-- without a configuration are indexed without stemming
+CREATE OR REPLACE FUNCTION product_search_config(locale TEXT) RETURNS regconfig AS $$
+    SELECT CASE lower(split_part(locale, '-', 1))
+        WHEN 'ar' THEN 'arabic'
+        WHEN 'da' THEN 'danish'
+        WHEN 'de' THEN 'german'
+        WHEN 'el' THEN 'greek'
+        WHEN 'en' THEN 'english'
+        WHEN 'es' THEN 'spanish'
+        WHEN 'fi' THEN 'finnish'
+        WHEN 'fr' THEN 'french'
+        WHEN 'hu' THEN 'hungarian'
+        WHEN 'id' THEN 'indonesian'
+        WHEN 'it' THEN 'italian'
+        WHEN 'nb' THEN 'norwegian'
+        WHEN 'nl' THEN 'dutch'
+        WHEN 'nn' THEN 'norwegian'
+        WHEN 'no' THEN 'norwegian'
+        WHEN 'pt' THEN 'portuguese'
+        WHEN 'ro' THEN 'romanian'
+        WHEN 'ru' THEN 'russian'
+        WHEN 'sv' THEN 'swedish'
+        WHEN 'tr' THEN 'turkish'
+        ELSE 'simple'
+    END::regconfig
+$$ LANGUAGE SQL IMMUTABLE PARALLEL SAFE;
+
+-- Translations must belong to a product of their tenant
+ALTER TABLE products ADD CONSTRAINT products_tenant_id_id_key UNIQUE (tenant_id, id);
+
+-- Create product translations table
+CREATE TABLE IF NOT EXISTS product_translations (
+    product_id UUID NOT NULL,
+    tenant_id VARCHAR(63) NOT NULL,
+    locale VARCHAR(35) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL,
+    search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector(product_search_config(locale), name), 'A') ||
+        setweight(to_tsvector(product_search_config(locale), description), 'B')
+    ) STORED,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_id, locale),
+    FOREIGN KEY (tenant_id, product_id) REFERENCES products(tenant_id, id) ON DELETE CASCADE
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_product_translations_search_vector ON product_translations USING GIN (search_vector);
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON product_translations TO catalog_tenant;
+
+ALTER TABLE product_translations ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON product_translations
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,23 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS products_assign_slug ON products;
+DROP TRIGGER IF EXISTS products_record_slug ON products;
+DROP TRIGGER IF EXISTS products_record_renamed_slug ON products;
+DROP TRIGGER IF EXISTS categories_assign_slug ON categories;
+DROP TRIGGER IF EXISTS categories_record_slug ON categories;
+DROP TRIGGER IF EXISTS categories_record_renamed_slug ON categories;
+
+-- Drop policies
+DROP POLICY IF EXISTS tenant_isolation ON product_slugs;
+DROP POLICY IF EXISTS tenant_isolation ON category_slugs;
+
+-- Drop tables
+DROP TABLE IF EXISTS product_slugs;
+DROP TABLE IF EXISTS category_slugs;
+
+ALTER TABLE products DROP COLUMN IF EXISTS slug;
+ALTER TABLE categories DROP COLUMN IF EXISTS slug;
+
+DROP FUNCTION IF EXISTS assign_slug();
+DROP FUNCTION IF EXISTS record_slug();
+DROP FUNCTION IF EXISTS slugify(TEXT);
+DROP EXTENSION IF EXISTS unaccent;
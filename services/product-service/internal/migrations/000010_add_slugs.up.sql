@@ -0,0 +1,155 @@
+-- Slugs are generated from names transliterated to ASCII by unaccent
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- slugify returns the URL-safe form of a name: lowercase ASCII letters and digits
+-- separated by single hyphens, at most 100 characters
+CREATE OR REPLACE FUNCTION slugify(name TEXT) RETURNS TEXT AS $$
+    SELECT trim(BOTH '-' FROM left(
+        trim(BOTH '-' FROM regexp_replace(lower(unaccent(coalesce(name, ''))), '[^a-z0-9]+', '-', 'g')),
+        100))
+$$ LANGUAGE sql STABLE;
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS slug VARCHAR(120);
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS slug VARCHAR(120);
+
+-- Every slug a product or category ever had, so old URLs keep resolving to it
+CREATE TABLE IF NOT EXISTS product_slugs (
+    tenant_id VARCHAR(63) NOT NULL,
+    slug VARCHAR(120) NOT NULL,
+    product_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, slug),
+    FOREIGN KEY (tenant_id, product_id) REFERENCES products(tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS category_slugs (
+    tenant_id VARCHAR(63) NOT NULL,
+    slug VARCHAR(120) NOT NULL,
+    category_id UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, slug),
+    FOREIGN KEY (tenant_id, category_id) REFERENCES categories(tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_slugs_product_id ON product_slugs(tenant_id, product_id);
+CREATE INDEX IF NOT EXISTS idx_category_slugs_category_id ON category_slugs(tenant_id, category_id);
+
+-- assign_slug gives a row with a new or renamed name a slug unique within its tenant,
+-- suffixing -2, -3... while the slug is taken. A slug stays with the row that first
+-- had it, though a row may take back one of its own old slugs. The arguments are the
+-- history table, its column referencing the row and the slug of names without any
+-- letter or digit.
+CREATE OR REPLACE FUNCTION assign_slug() RETURNS TRIGGER AS $$
+DECLARE
+    history TEXT := TG_ARGV[0];
+    owner_column TEXT := TG_ARGV[1];
+    base TEXT;
+    candidate TEXT;
+    suffix INT := 1;
+    taken BOOLEAN;
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.name IS NOT DISTINCT FROM OLD.name AND OLD.slug IS NOT NULL THEN
+        RETURN NEW;
+    END IF;
+
+    base := slugify(NEW.name);
+    IF base = '' THEN
+        base := TG_ARGV[2];
+    END IF;
+
+    -- Serializes the rows of a tenant competing for the same slug
+    PERFORM pg_advisory_xact_lock(hashtext(TG_TABLE_NAME || '/' || NEW.tenant_id || '/' || base));
+
+    candidate := base;
+    LOOP
+        -- Rows written earlier by the same statement are not in the history yet
+        EXECUTE format(
+            'SELECT EXISTS (SELECT 1 FROM %I WHERE tenant_id = $1 AND slug = $2 AND id <> $3)
+                OR EXISTS (SELECT 1 FROM %I WHERE tenant_id = $1 AND slug = $2 AND %I <> $3)',
+            TG_TABLE_NAME, history, owner_column)
+        INTO taken USING NEW.tenant_id, candidate, NEW.id;
+
+        EXIT WHEN NOT taken;
+        suffix := suffix + 1;
+        candidate := base || '-' || suffix;
+    END LOOP;
+
+    NEW.slug := candidate;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- record_slug adds the slug of a row to its history table
+CREATE OR REPLACE FUNCTION record_slug() RETURNS TRIGGER AS $$
+BEGIN
+    EXECUTE format('INSERT INTO %I (tenant_id, slug, %I) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING',
+        TG_ARGV[0], TG_ARGV[1])
+    USING NEW.tenant_id, NEW.slug, NEW.id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_assign_slug ON products;
+CREATE TRIGGER products_assign_slug
+    BEFORE INSERT OR UPDATE OF name ON products
+    FOR EACH ROW EXECUTE FUNCTION assign_slug('product_slugs', 'product_id', 'product');
+
+DROP TRIGGER IF EXISTS products_record_slug ON products;
+CREATE TRIGGER products_record_slug
+    AFTER INSERT ON products
+    FOR EACH ROW EXECUTE FUNCTION record_slug('product_slugs', 'product_id');
+
+DROP TRIGGER IF EXISTS products_record_renamed_slug ON products;
+CREATE TRIGGER products_record_renamed_slug
+    AFTER UPDATE ON products
+    FOR EACH ROW WHEN (OLD.slug IS DISTINCT FROM NEW.slug)
+    EXECUTE FUNCTION record_slug('product_slugs', 'product_id');
+
+DROP TRIGGER IF EXISTS categories_assign_slug ON categories;
+CREATE TRIGGER categories_assign_slug
+    BEFORE INSERT OR UPDATE OF name ON categories
+    FOR EACH ROW EXECUTE FUNCTION assign_slug('category_slugs', 'category_id', 'category');
+
+DROP TRIGGER IF EXISTS categories_record_slug ON categories;
+CREATE TRIGGER categories_record_slug
+    AFTER INSERT ON categories
+    FOR EACH ROW EXECUTE FUNCTION record_slug('category_slugs', 'category_id');
+
+DROP TRIGGER IF EXISTS categories_record_renamed_slug ON categories;
+CREATE TRIGGER categories_record_renamed_slug
+    AFTER UPDATE ON categories
+    FOR EACH ROW WHEN (OLD.slug IS DISTINCT FROM NEW.slug)
+    EXECUTE FUNCTION record_slug('category_slugs', 'category_id');
+
+-- Give existing rows their slugs, oldest first so they keep the unsuffixed ones
+DO $$
+DECLARE
+    r RECORD;
+BEGIN
+    FOR r IN SELECT id, tenant_id FROM categories ORDER BY created_at, id LOOP
+        UPDATE categories SET name = name WHERE id = r.id AND tenant_id = r.tenant_id;
+    END LOOP;
+    FOR r IN SELECT id, tenant_id FROM products ORDER BY created_at, id LOOP
+        UPDATE products SET name = name WHERE id = r.id AND tenant_id = r.tenant_id;
+    END LOOP;
+END
+$$;
+
+ALTER TABLE products ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE categories ALTER COLUMN slug SET NOT NULL;
+ALTER TABLE products ADD CONSTRAINT products_tenant_id_slug_key UNIQUE (tenant_id, slug);
+ALTER TABLE categories ADD CONSTRAINT categories_tenant_id_slug_key UNIQUE (tenant_id, slug);
+
+GRANT SELECT, INSERT ON product_slugs, category_slugs TO catalog_tenant;
+
+ALTER TABLE product_slugs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE category_slugs ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON product_slugs
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+CREATE POLICY tenant_isolation ON category_slugs
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
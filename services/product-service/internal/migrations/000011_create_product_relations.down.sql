@@ -0,0 +1,14 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS products_remove_archived_relations ON products;
+
+-- Drop policies
+DROP POLICY IF EXISTS tenant_isolation ON product_relations;
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_product_relations_position;
+DROP INDEX IF EXISTS idx_product_relations_related_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS product_relations;
+
+DROP FUNCTION IF EXISTS remove_archived_relations();
@@ -0,0 +1,43 @@
+-- Create product relations table
+CREATE TABLE IF NOT EXISTS product_relations (
+    product_id UUID NOT NULL,
+    related_id UUID NOT NULL,
+    tenant_id VARCHAR(63) NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('related', 'cross_sell', 'up_sell', 'accessory')),
+    position INT NOT NULL DEFAULT 0 CHECK (position >= 0),
+    bidirectional BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_id, type, related_id),
+    CHECK (product_id <> related_id),
+    FOREIGN KEY (tenant_id, product_id) REFERENCES products(tenant_id, id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id, related_id) REFERENCES products(tenant_id, id) ON DELETE CASCADE
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_product_relations_position ON product_relations(product_id, type, position);
+CREATE INDEX IF NOT EXISTS idx_product_relations_related_id ON product_relations(related_id);
+
+-- Archived products leave the lists they were in, and lose their own
+CREATE OR REPLACE FUNCTION remove_archived_relations() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM product_relations
+    WHERE tenant_id = NEW.tenant_id AND (product_id = NEW.id OR related_id = NEW.id);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_remove_archived_relations ON products;
+CREATE TRIGGER products_remove_archived_relations
+    AFTER UPDATE OF status ON products
+    FOR EACH ROW WHEN (NEW.status = 'archived' AND OLD.status IS DISTINCT FROM 'archived')
+    EXECUTE FUNCTION remove_archived_relations();
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON product_relations TO catalog_tenant;
+
+ALTER TABLE product_relations ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON product_relations
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
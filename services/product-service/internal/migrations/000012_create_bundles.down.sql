@@ -0,0 +1,17 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS products_protect_bundle_components ON products;
+
+-- Drop policies
+DROP POLICY IF EXISTS tenant_isolation ON bundle_components;
+DROP POLICY IF EXISTS tenant_isolation ON bundles;
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_bundle_components_component_id;
+
+-- Drop tables
+DROP TABLE IF EXISTS bundle_components;
+DROP TABLE IF EXISTS bundles;
+
+DROP FUNCTION IF EXISTS protect_bundle_components();
+
+ALTER TABLE products DROP COLUMN IF EXISTS type;
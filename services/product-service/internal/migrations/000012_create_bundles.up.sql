@@ -0,0 +1,65 @@
+-- Products are sold on their own or as bundles of other products
+ALTER TABLE products ADD COLUMN IF NOT EXISTS type VARCHAR(20) NOT NULL DEFAULT 'simple'
+    CHECK (type IN ('simple', 'bundle'));
+
+-- Create bundles table
+CREATE TABLE IF NOT EXISTS bundles (
+    product_id UUID PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    pricing VARCHAR(20) NOT NULL CHECK (pricing IN ('fixed', 'discount')),
+    discount_percent DECIMAL(5, 2) NOT NULL DEFAULT 0 CHECK (discount_percent BETWEEN 0 AND 100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (pricing = 'discount' OR discount_percent = 0),
+    FOREIGN KEY (tenant_id, product_id) REFERENCES products(tenant_id, id) ON DELETE CASCADE
+);
+
+-- Create bundle components table
+CREATE TABLE IF NOT EXISTS bundle_components (
+    bundle_id UUID NOT NULL REFERENCES bundles(product_id) ON DELETE CASCADE,
+    component_id UUID NOT NULL,
+    tenant_id VARCHAR(63) NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    position INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (bundle_id, component_id),
+    CHECK (bundle_id <> component_id),
+    -- Archived bundles lose their deleted components; active ones keep them, see below
+    FOREIGN KEY (tenant_id, component_id) REFERENCES products(tenant_id, id) ON DELETE CASCADE
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_bundle_components_component_id ON bundle_components(component_id);
+
+-- Components of bundles that are not archived cannot be deleted
+CREATE OR REPLACE FUNCTION protect_bundle_components() RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM bundle_components c
+        JOIN products b ON b.tenant_id = c.tenant_id AND b.id = c.bundle_id
+        WHERE c.tenant_id = OLD.tenant_id AND c.component_id = OLD.id AND b.status <> 'archived'
+    ) THEN
+        RAISE EXCEPTION 'product % is a component of an active bundle', OLD.id
+            USING ERRCODE = 'foreign_key_violation', CONSTRAINT = 'bundle_components_component_in_use';
+    END IF;
+
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS products_protect_bundle_components ON products;
+CREATE TRIGGER products_protect_bundle_components
+    BEFORE DELETE ON products
+    FOR EACH ROW EXECUTE FUNCTION protect_bundle_components();
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON bundles, bundle_components TO catalog_tenant;
+
+ALTER TABLE bundles ENABLE ROW LEVEL SECURITY;
+ALTER TABLE bundle_components ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON bundles
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+CREATE POLICY tenant_isolation ON bundle_components
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,9 @@
+-- Drop policies
+DROP POLICY IF EXISTS tenant_isolation ON promotions;
+
+-- Drop indexes
+DROP INDEX IF EXISTS idx_promotions_tenant_id_created_at;
+DROP INDEX IF EXISTS idx_promotions_active;
+
+-- Drop tables
+DROP TABLE IF EXISTS promotions;
@@ -0,0 +1,40 @@
+-- Create promotions table
+CREATE TABLE IF NOT EXISTS promotions (
+    id UUID PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('percentage', 'fixed', 'buy_x_get_y')),
+    value DECIMAL(10, 2) NOT NULL CHECK (value > 0),
+    buy_quantity INT NOT NULL DEFAULT 0 CHECK (buy_quantity >= 0),
+    get_quantity INT NOT NULL DEFAULT 0 CHECK (get_quantity >= 0),
+    category_ids UUID[] NOT NULL DEFAULT '{}',
+    skus TEXT[] NOT NULL DEFAULT '{}',
+    min_spend DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (min_spend >= 0),
+    -- Stored upper case; NULL for promotions applying automatically
+    coupon_code VARCHAR(50),
+    usage_limit INT CHECK (usage_limit > 0),
+    usage_count INT NOT NULL DEFAULT 0,
+    priority INT NOT NULL DEFAULT 0,
+    exclusive BOOLEAN NOT NULL DEFAULT FALSE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT promotions_tenant_id_coupon_code_key UNIQUE (tenant_id, coupon_code),
+    -- Redemptions past the limit fail on this constraint
+    CONSTRAINT promotions_usage_limit_check CHECK (usage_count >= 0 AND (usage_limit IS NULL OR usage_count <= usage_limit)),
+    CHECK (starts_at IS NULL OR ends_at IS NULL OR ends_at > starts_at)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_promotions_tenant_id_created_at ON promotions(tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_promotions_active ON promotions(tenant_id) WHERE active;
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON promotions TO catalog_tenant;
+
+ALTER TABLE promotions ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON promotions
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
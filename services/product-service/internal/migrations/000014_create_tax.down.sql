@@ -0,0 +1,9 @@
+-- Drop policies
+DROP POLICY IF EXISTS tenant_isolation ON category_tax_classes;
+DROP POLICY IF EXISTS tenant_isolation ON product_tax_classes;
+DROP POLICY IF EXISTS tenant_isolation ON tax_rates;
+
+-- Drop tables
+DROP TABLE IF EXISTS category_tax_classes;
+DROP TABLE IF EXISTS product_tax_classes;
+DROP TABLE IF EXISTS tax_rates;
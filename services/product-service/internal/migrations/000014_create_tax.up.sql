@@ -0,0 +1,47 @@
+-- Create tax rates table; region is empty for the rate of the whole country
+CREATE TABLE IF NOT EXISTS tax_rates (
+    tenant_id VARCHAR(63) NOT NULL,
+    country CHAR(2) NOT NULL,
+    region VARCHAR(3) NOT NULL DEFAULT '',
+    tax_class VARCHAR(50) NOT NULL,
+    rate DECIMAL(7, 4) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    valid_from DATE NOT NULL,
+    valid_to DATE,
+    PRIMARY KEY (tenant_id, country, region, tax_class, valid_from),
+    CHECK (valid_to IS NULL OR valid_to > valid_from)
+);
+
+-- Create tax class assignments; products without one take the class of their category
+CREATE TABLE IF NOT EXISTS product_tax_classes (
+    product_id UUID PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    tax_class VARCHAR(50) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (tenant_id, product_id) REFERENCES products(tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS category_tax_classes (
+    category_id UUID PRIMARY KEY,
+    tenant_id VARCHAR(63) NOT NULL,
+    tax_class VARCHAR(50) NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (tenant_id, category_id) REFERENCES categories(tenant_id, id) ON DELETE CASCADE
+);
+
+GRANT SELECT, INSERT, UPDATE, DELETE ON tax_rates, product_tax_classes, category_tax_classes TO catalog_tenant;
+
+ALTER TABLE tax_rates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE product_tax_classes ENABLE ROW LEVEL SECURITY;
+ALTER TABLE category_tax_classes ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY tenant_isolation ON tax_rates
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+CREATE POLICY tenant_isolation ON product_tax_classes
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+CREATE POLICY tenant_isolation ON category_tax_classes
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
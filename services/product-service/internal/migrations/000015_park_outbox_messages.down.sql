@@ -0,0 +1,5 @@
+-- Drop indexes
+DROP INDEX IF EXISTS idx_outbox_pending_aggregate;
+
+-- Drop columns
+ALTER TABLE outbox DROP COLUMN IF EXISTS parked_at;
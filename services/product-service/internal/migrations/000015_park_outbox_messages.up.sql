@@ -0,0 +1,8 @@
+-- Messages that failed to publish too many times are parked: set aside for an
+-- operator, and no longer holding back the later messages of their aggregate.
+-- Clearing parked_at and attempts retries a parked message.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS parked_at TIMESTAMPTZ;
+
+-- Finds the earlier failed messages of an aggregate when selecting pending ones
+CREATE INDEX IF NOT EXISTS idx_outbox_pending_aggregate ON outbox(aggregate_id, id)
+    WHERE published_at IS NULL AND parked_at IS NULL;
@@ -0,0 +1,4 @@
+-- Remove the backfilled entries, bypassing the trigger keeping entries immutable
+ALTER TABLE product_audit_log DISABLE TRIGGER product_audit_log_immutable;
+DELETE FROM product_audit_log WHERE action = 'create' AND actor = 'system' AND request_id = 'backfill';
+ALTER TABLE product_audit_log ENABLE TRIGGER product_audit_log_immutable;
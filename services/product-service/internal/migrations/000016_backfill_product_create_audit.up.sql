@@ -0,0 +1,42 @@
+-- Products created before the audit log existed have no create entry to replay, so
+-- their state as of any time was not found. Give each a create entry at its
+-- creation time, holding each field as it was before its first recorded change, or
+-- as it is now if it never changed.
+INSERT INTO product_audit_log (id, tenant_id, product_id, action, actor, request_id, changes, created_at)
+SELECT
+    gen_random_uuid(),
+    p.tenant_id,
+    p.id,
+    'create',
+    'system',
+    'backfill',
+    jsonb_build_array(
+        jsonb_build_object('field', 'name', 'old', NULL, 'new', COALESCE(f.name, to_jsonb(p.name))),
+        jsonb_build_object('field', 'description', 'old', NULL, 'new', COALESCE(f.description, to_jsonb(COALESCE(p.description, '')))),
+        jsonb_build_object('field', 'price', 'old', NULL, 'new', COALESCE(f.price, to_jsonb(p.price::float8))),
+        jsonb_build_object('field', 'sku', 'old', NULL, 'new', COALESCE(f.sku, to_jsonb(p.sku))),
+        jsonb_build_object('field', 'category_id', 'old', NULL, 'new', COALESCE(f.category_id, to_jsonb(p.category_id::text))),
+        jsonb_build_object('field', 'status', 'old', NULL, 'new', COALESCE(f.status, to_jsonb(p.status))),
+        jsonb_build_object('field', 'type', 'old', NULL, 'new', COALESCE(f.type, to_jsonb(p.type)))
+    ),
+    -- Never after a recorded change, so replays apply it first
+    LEAST(p.created_at, f.first_at)
+FROM products p
+CROSS JOIN LATERAL (
+    SELECT
+        min(a.created_at) AS first_at,
+        (array_agg(c.change->'old' ORDER BY a.created_at) FILTER (WHERE c.change->>'field' = 'name'))[1] AS name,
+        (array_agg(c.change->'old' ORDER BY a.created_at) FILTER (WHERE c.change->>'field' = 'description'))[1] AS description,
+        (array_agg(c.change->'old' ORDER BY a.created_at) FILTER (WHERE c.change->>'field' = 'price'))[1] AS price,
+        (array_agg(c.change->'old' ORDER BY a.created_at) FILTER (WHERE c.change->>'field' = 'sku'))[1] AS sku,
+        (array_agg(c.change->'old' ORDER BY a.created_at) FILTER (WHERE c.change->>'field' = 'category_id'))[1] AS category_id,
+        (array_agg(c.change->'old' ORDER BY a.created_at) FILTER (WHERE c.change->>'field' = 'status'))[1] AS status,
+        (array_agg(c.change->'old' ORDER BY a.created_at) FILTER (WHERE c.change->>'field' = 'type'))[1] AS type
+    FROM product_audit_log a
+    CROSS JOIN LATERAL jsonb_array_elements(a.changes) AS c(change)
+    WHERE a.tenant_id = p.tenant_id AND a.product_id = p.id AND a.action = 'update'
+) f
+WHERE NOT EXISTS (
+    SELECT 1 FROM product_audit_log a
+    WHERE a.tenant_id = p.tenant_id AND a.product_id = p.id AND a.action = 'create'
+);
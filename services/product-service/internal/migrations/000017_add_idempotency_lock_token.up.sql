@@ -0,0 +1,3 @@
+-- Requests lock their key with a token, so one whose lock timed out and was taken
+-- over by a retry cannot store its response over the retry's
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS lock_token UUID;